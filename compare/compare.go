@@ -3,6 +3,9 @@ package compare
 import (
 	"fmt"
 	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
 
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
@@ -14,24 +17,100 @@ type Diff struct {
 	Path []string
 }
 
+// Mode selects how values are compared. Modes can be combined.
+type Mode int
+
+const (
+	// Strict compares values including their type.
+	Strict Mode = 0
+	// NumericValues compares numbers (and strings containing
+	// numbers) by their numeric value.
+	NumericValues Mode = 1 << iota
+	// UnorderedLists compares lists of simple values
+	// independently of the order of their entries.
+	UnorderedLists
+	// OrderedEntries additionally reports lists whose entries,
+	// matched by their keys, are found in a different order.
+	OrderedEntries
+)
+
+// Options configures the comparison of documents.
+type Options struct {
+	// Mode selects how values are compared (default Strict).
+	Mode Mode
+	// Keys declares the key fields identifying list entries
+	// (default name).
+	Keys yaml.ListKeys
+	// IndexPaths addresses list entries in patches by
+	// their index instead of their key.
+	IndexPaths bool
+	// Ignore excludes paths (and their subtrees) from
+	// the comparison.
+	Ignore IgnoreRules
+}
+
 func Compare(a, b yaml.Node) []Diff {
-	return compare(a, b, []string{})
+	return CompareWithOptions(a, b, Options{})
+}
+
+// CompareWithOptions compares two documents using the given options.
+func CompareWithOptions(a, b yaml.Node, opts Options) []Diff {
+	c := comparer{opts}
+	return c.compare(a, b, []string{})
+}
+
+type comparer struct {
+	opts Options
 }
 
-func compare(a, b yaml.Node, path []string) []Diff {
+// keyName determines the key name for the entries of a list.
+func (c comparer) keyName(list yaml.Node, path []string) string {
+	if key := c.opts.Keys.Lookup(path); key != "" {
+		return key
+	}
+	return list.KeyName()
+}
+
+// DiffsAsNode provides a list of the given differences with
+// the fields path, a and b. Missing values are omitted.
+func DiffsAsNode(diffs []Diff) yaml.Node {
+	list := []yaml.Node{}
+	for _, diff := range diffs {
+		path := []yaml.Node{}
+		for _, step := range diff.Path {
+			path = append(path, yaml.NewNode(step, "diff"))
+		}
+		entry := map[string]yaml.Node{
+			"path": yaml.NewNode(path, "diff"),
+		}
+		if diff.A != nil {
+			entry["a"] = diff.A
+		}
+		if diff.B != nil {
+			entry["b"] = diff.B
+		}
+		list = append(list, yaml.NewNode(entry, "diff"))
+	}
+	return yaml.NewNode(list, "diff")
+}
+
+func (c comparer) compare(a, b yaml.Node, path []string) []Diff {
+	if c.opts.Ignore.Matches(path) {
+		return []Diff{}
+	}
 	mismatch := Diff{A: a, B: b, Path: path}
 
 	switch av := a.Value().(type) {
 	case map[string]yaml.Node:
 		switch bv := b.Value().(type) {
 		case map[string]yaml.Node:
-			return compareMap(av, bv, path)
+			return c.compareMap(av, bv, path)
 
 		case []yaml.Node:
-			toMap := listToMap(bv)
+			toMap := listToMap(bv, c.keyName(b, path))
 
 			if toMap != nil {
-				return compareMap(av, toMap, path)
+				return c.compareMap(av, toMap, path)
 			} else {
 				return []Diff{mismatch}
 			}
@@ -43,32 +122,133 @@ func compare(a, b yaml.Node, path []string) []Diff {
 	case []yaml.Node:
 		switch bv := b.Value().(type) {
 		case []yaml.Node:
-			return compareList(av, bv, path)
+			if c.opts.Mode&UnorderedLists != 0 && simpleList(av) && simpleList(bv) {
+				return c.compareUnordered(av, bv, path)
+			}
+			diffs := c.compareList(av, bv, path, c.keyName(a, path))
+			if c.opts.Mode&OrderedEntries != 0 && !c.sameOrder(av, bv, path, c.keyName(a, path)) {
+				diffs = append(diffs, mismatch)
+			}
+			return diffs
 		default:
 			return []Diff{mismatch}
 		}
 
 	default:
-		atype := reflect.TypeOf(a)
-		btype := reflect.TypeOf(b)
-
-		if atype != btype {
+		if !c.equalValues(av, b.Value()) {
 			return []Diff{mismatch}
 		}
+	}
+
+	return []Diff{}
+}
 
-		if av != b.Value() {
-			return []Diff{Diff{A: a, B: b, Path: path}}
+// equalValues compares simple values according to the mode.
+func (c comparer) equalValues(a, b interface{}) bool {
+	if reflect.TypeOf(a) == reflect.TypeOf(b) && a == b {
+		return true
+	}
+	if c.opts.Mode&NumericValues != 0 {
+		na, oka := numericValue(a)
+		nb, okb := numericValue(b)
+		if oka && okb {
+			return na == nb
 		}
 	}
+	if reflect.TypeOf(a) != reflect.TypeOf(b) {
+		return false
+	}
+	return a == b
+}
 
-	return []Diff{}
+// numericString matches the plain decimal notation of integers
+// and floats accepted for strings in numeric mode.
+var numericString = regexp.MustCompile(`^[-+]?([0-9]+(\.[0-9]*)?|\.[0-9]+)$`)
+
+func numericValue(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	case string:
+		n = strings.TrimSpace(n)
+		if !numericString.MatchString(n) {
+			return 0, false
+		}
+		f, err := strconv.ParseFloat(n, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
+
+// sameOrder checks whether the entries found by key in both lists
+// have the same order. The order of jobs is already reported by
+// their index.
+func (c comparer) sameOrder(a, b []yaml.Node, path []string, keyName string) bool {
+	if len(path) == 1 && path[0] == "jobs" {
+		return true
+	}
+	if listToMap(a, keyName) == nil || listToMap(b, keyName) == nil {
+		return true
+	}
+	common := func(list, other []yaml.Node) []string {
+		keys := []string{}
+		for _, e := range list {
+			key, _ := yaml.ListEntryKey(false, e, keyName)
+			if _, _, found := findByName(key, other, keyName); found {
+				keys = append(keys, key)
+			}
+		}
+		return keys
+	}
+	return reflect.DeepEqual(common(a, b), common(b, a))
+}
+
+// simpleList checks whether a list contains only simple values.
+func simpleList(list []yaml.Node) bool {
+	for _, e := range list {
+		switch e.Value().(type) {
+		case map[string]yaml.Node, []yaml.Node:
+			return false
+		}
+	}
+	return true
+}
+
+// compareUnordered compares lists of simple values as multisets.
+// Entries without an equal counterpart are reported by their index.
+func (c comparer) compareUnordered(a, b []yaml.Node, path []string) []Diff {
+	diff := []Diff{}
+	matched := make([]bool, len(b))
+	for i, aval := range a {
+		found := false
+		for j, bval := range b {
+			if !matched[j] && c.equalValues(aval.Value(), bval.Value()) {
+				matched[j] = true
+				found = true
+				break
+			}
+		}
+		if !found && !c.opts.Ignore.Matches(addPath(path, fmt.Sprintf("[%d]", i))) {
+			diff = append(diff, Diff{A: aval, B: nil, Path: addPath(path, fmt.Sprintf("[%d]", i))})
+		}
+	}
+	for j, bval := range b {
+		if !matched[j] && !c.opts.Ignore.Matches(addPath(path, fmt.Sprintf("[%d]", j))) {
+			diff = append(diff, Diff{A: nil, B: bval, Path: addPath(path, fmt.Sprintf("[%d]", j))})
+		}
+	}
+	return diff
 }
 
-func listToMap(list []yaml.Node) map[string]yaml.Node {
+func listToMap(list []yaml.Node, keyName string) map[string]yaml.Node {
 	toMap := make(map[string]yaml.Node)
 
 	for _, val := range list {
-		name, ok := yaml.FindString(val, "name")
+		name, ok := yaml.ListEntryKey(false, val, keyName)
 		if !ok {
 			return nil
 		}
@@ -80,7 +260,7 @@ func listToMap(list []yaml.Node) map[string]yaml.Node {
 
 		newMap := make(map[string]yaml.Node)
 		for key, val := range asMap {
-			if key != "name" {
+			if !yaml.IsKeyField(key, keyName) {
 				newMap[key] = val
 			}
 		}
@@ -91,21 +271,21 @@ func listToMap(list []yaml.Node) map[string]yaml.Node {
 	return toMap
 }
 
-func compareMap(a, b map[string]yaml.Node, path []string) []Diff {
+func (c comparer) compareMap(a, b map[string]yaml.Node, path []string) []Diff {
 	diff := []Diff{}
 
 	for key, aval := range a {
 		bval, present := b[key]
 		if present {
-			diff = append(diff, compare(aval, bval, addPath(path, key))...)
-		} else {
+			diff = append(diff, c.compare(aval, bval, addPath(path, key))...)
+		} else if !c.opts.Ignore.Matches(addPath(path, key)) {
 			diff = append(diff, Diff{A: aval, B: nil, Path: addPath(path, key)})
 		}
 	}
 
 	for key, bval := range b {
 		_, present := a[key]
-		if !present {
+		if !present && !c.opts.Ignore.Matches(addPath(path, key)) {
 			diff = append(diff, Diff{A: nil, B: bval, Path: addPath(path, key)})
 			continue
 		}
@@ -114,28 +294,30 @@ func compareMap(a, b map[string]yaml.Node, path []string) []Diff {
 	return diff
 }
 
-func compareList(a, b []yaml.Node, path []string) []Diff {
+func (c comparer) compareList(a, b []yaml.Node, path []string, keyName string) []Diff {
 	diff := []Diff{}
 
 	if len(path) == 1 && path[0] == "jobs" {
-		return compareJobs(a, b, path)
+		return c.compareJobs(a, b, path, keyName)
 	}
 
 	for index, aval := range a {
-		key, bval, found := findByNameOrIndex(aval, b, index)
+		key, bval, found := findByNameOrIndex(aval, b, index, keyName)
 
 		if !found {
-			diff = append(diff, Diff{A: aval, B: nil, Path: addPath(path, key)})
+			if !c.opts.Ignore.Matches(addPath(path, key)) {
+				diff = append(diff, Diff{A: aval, B: nil, Path: addPath(path, key)})
+			}
 			continue
 		}
 
-		diff = append(diff, compare(aval, bval, addPath(path, key))...)
+		diff = append(diff, c.compare(aval, bval, addPath(path, key))...)
 	}
 
 	for index, bval := range b {
 		key := fmt.Sprintf("[%d]", index)
 
-		if len(a) <= index {
+		if len(a) <= index && !c.opts.Ignore.Matches(addPath(path, key)) {
 			diff = append(diff, Diff{A: nil, B: bval, Path: addPath(path, key)})
 			continue
 		}
@@ -144,18 +326,22 @@ func compareList(a, b []yaml.Node, path []string) []Diff {
 	return diff
 }
 
-func compareJobs(ajobs, bjobs []yaml.Node, path []string) []Diff {
-	return compareMap(jobMap(ajobs), jobMap(bjobs), path)
+func (c comparer) compareJobs(ajobs, bjobs []yaml.Node, path []string, keyName string) []Diff {
+	return c.compareMap(jobMap(ajobs, keyName), jobMap(bjobs, keyName), path)
 }
 
-func jobMap(jobs []yaml.Node) map[string]yaml.Node {
+func jobMap(jobs []yaml.Node, keyName string) map[string]yaml.Node {
 	byName := make(map[string]yaml.Node)
 
 	for index, job := range jobs {
-		attrs, ok := job.Value().(map[string]yaml.Node)
+		orig, ok := job.Value().(map[string]yaml.Node)
+		attrs := make(map[string]yaml.Node, len(orig)+1)
+		for k, v := range orig {
+			attrs[k] = v
+		}
 		attrs["index"] = yaml.NewNode(index, job.SourceName())
 
-		name, ok := yaml.FindString(job, "name")
+		name, ok := yaml.ListEntryKey(false, job, keyName)
 		if !ok {
 			panic("job without string name")
 		}
@@ -166,13 +352,13 @@ func jobMap(jobs []yaml.Node) map[string]yaml.Node {
 	return byName
 }
 
-func findByNameOrIndex(node yaml.Node, others []yaml.Node, index int) (string, yaml.Node, bool) {
-	name, ok := yaml.FindString(node, "name")
+func findByNameOrIndex(node yaml.Node, others []yaml.Node, index int, keyName string) (string, yaml.Node, bool) {
+	name, ok := yaml.ListEntryKey(false, node, keyName)
 	if !ok {
 		return findByIndex(others, index)
 	}
 
-	key, node, found := findByName(name, others)
+	key, node, found := findByName(name, others, keyName)
 	if !found {
 		return findByIndex(others, index)
 	}
@@ -180,9 +366,9 @@ func findByNameOrIndex(node yaml.Node, others []yaml.Node, index int) (string, y
 	return key, node, true
 }
 
-func findByName(name string, nodes []yaml.Node) (string, yaml.Node, bool) {
+func findByName(name string, nodes []yaml.Node, keyName string) (string, yaml.Node, bool) {
 	for _, node := range nodes {
-		otherName, ok := yaml.FindString(node, "name")
+		otherName, ok := yaml.ListEntryKey(false, node, keyName)
 		if !ok {
 			continue
 		}
@@ -1,8 +1,12 @@
 package compare
 
 import (
+	"strings"
+
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
 var _ = Describe("Diffing YAML", func() {
@@ -400,4 +404,184 @@ foo:
 			})
 		})
 	})
+
+	Describe("declared list keys", func() {
+		a := parseYAML(`
+---
+instances:
+- name: web
+  az: z1
+  size: 1
+- name: web
+  az: z2
+  size: 2
+`)
+
+		b := parseYAML(`
+---
+instances:
+- name: web
+  az: z2
+  size: 2
+- name: web
+  az: z1
+  size: 3
+`)
+
+		It("identifies list entries by the declared composite key", func() {
+			keys, err := yaml.ParseListKeys(parseYAML(`instances: name,az`))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(CompareWithOptions(a, b, Options{Keys: keys})).To(Equal([]Diff{
+				Diff{
+					A:    parseYAML("1"),
+					B:    parseYAML("3"),
+					Path: []string{"instances", "web,z1", "size"},
+				},
+			}))
+		})
+	})
+
+	Describe("comparison modes", func() {
+		a := parseYAML(`
+---
+int: 1
+float: 1.0
+string: "2"
+list: [ 1, 2, 2 ]
+`)
+
+		b := parseYAML(`
+---
+int: 1.0
+float: 1
+string: 2
+list: [ 2, 1, 3 ]
+`)
+
+		It("compares values including their type in strict mode", func() {
+			diffs := CompareWithOptions(a, b, Options{Mode: Strict})
+			paths := []string{}
+			for _, d := range diffs {
+				paths = append(paths, strings.Join(d.Path, "."))
+			}
+			Expect(paths).To(ConsistOf("int", "float", "string", "list.[0]", "list.[1]", "list.[2]"))
+		})
+
+		It("compares numbers by value in numeric mode", func() {
+			diffs := CompareWithOptions(a, b, Options{Mode: NumericValues})
+			for _, d := range diffs {
+				Expect(d.Path[0]).To(Equal("list"))
+			}
+		})
+
+		It("compares only decimal strings by value in numeric mode", func() {
+			a := parseYAML(`[ "nan", "NaN", "Inf", "1e3", " 2.50 " ]`)
+			b := parseYAML(`[ "nan", nan, inf, 1000, 2.5 ]`)
+			paths := []string{}
+			for _, d := range CompareWithOptions(a, b, Options{Mode: NumericValues}) {
+				paths = append(paths, strings.Join(d.Path, "."))
+			}
+			Expect(paths).To(ConsistOf("[1]", "[2]", "[3]"))
+		})
+
+		It("ignores the order of simple list entries in unordered mode", func() {
+			Expect(CompareWithOptions(a, b, Options{Mode: NumericValues | UnorderedLists})).To(Equal([]Diff{
+				Diff{
+					A:    parseYAML("2"),
+					Path: []string{"list", "[2]"},
+				},
+				Diff{
+					B:    parseYAML("3"),
+					Path: []string{"list", "[2]"},
+				},
+			}))
+		})
+
+		It("reports reordered keyed list entries in ordered mode", func() {
+			a := parseYAML(`
+---
+groups: [ { name: a }, { name: b }, { name: c } ]
+`)
+			b := parseYAML(`
+---
+groups: [ { name: b }, { name: a } ]
+`)
+			Expect(CompareWithOptions(a, b, Options{})).To(HaveLen(1))
+			diffs := CompareWithOptions(a, b, Options{Mode: OrderedEntries})
+			Expect(diffs).To(HaveLen(2))
+			Expect(diffs[1]).To(Equal(Diff{
+				A:    a.Value().(map[string]yaml.Node)["groups"],
+				B:    b.Value().(map[string]yaml.Node)["groups"],
+				Path: []string{"groups"},
+			}))
+		})
+	})
+
+	Describe("ignored paths", func() {
+		a := parseYAML(`
+---
+jobs:
+- name: web
+  properties:
+    password: a
+    uuid: 1
+    port: 80
+secrets:
+  admin_password: a
+list: [ 1 ]
+`)
+
+		b := parseYAML(`
+---
+jobs:
+- name: web
+  properties:
+    password: b
+    uuid: 2
+    port: 81
+secrets:
+  admin_password: b
+  db_password: c
+list: [ 1, 2 ]
+`)
+
+		It("neither reports nor traverses ignored subtrees", func() {
+			rules := ParseIgnoreRules("**.password", "jobs.name:*.properties.uuid", "secrets.*_password", "list.[1]")
+			Expect(CompareWithOptions(a, b, Options{Ignore: rules})).To(Equal([]Diff{
+				Diff{
+					A:    parseYAML("80"),
+					B:    parseYAML("81"),
+					Path: []string{"jobs", "web", "properties", "port"},
+				},
+			}))
+		})
+
+		It("reads rules from files", func() {
+			rules := ReadIgnoreRules([]byte("# generated values\njobs.*.properties\n\nsecrets\n"))
+			Expect(rules).To(Equal(IgnoreRules{{"jobs", "*", "properties"}, {"secrets"}}))
+			Expect(rules.Matches([]string{"jobs", "web", "properties"})).To(BeTrue())
+			Expect(rules.Matches([]string{"jobs", "web"})).To(BeFalse())
+		})
+	})
+
+	Describe("machine readable differences", func() {
+		It("lists the differences with path and values", func() {
+			diffs := []Diff{
+				{A: parseYAML("1"), B: parseYAML("2"), Path: []string{"foo", "[0]"}},
+				{A: nil, B: parseYAML("3"), Path: []string{"bar"}},
+			}
+			Expect(DiffsAsNode(diffs)).To(Equal(yaml.NewNode([]yaml.Node{
+				yaml.NewNode(map[string]yaml.Node{
+					"path": yaml.NewNode([]yaml.Node{yaml.NewNode("foo", "diff"), yaml.NewNode("[0]", "diff")}, "diff"),
+					"a":    parseYAML("1"),
+					"b":    parseYAML("2"),
+				}, "diff"),
+				yaml.NewNode(map[string]yaml.Node{
+					"path": yaml.NewNode([]yaml.Node{yaml.NewNode("bar", "diff")}, "diff"),
+					"b":    parseYAML("3"),
+				}, "diff"),
+			}, "diff")))
+		})
+	})
 })
@@ -0,0 +1,201 @@
+package compare
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+// classifications of the paths found by a three-way comparison
+const (
+	// changed only in ours
+	ChangedOurs = "ours"
+	// changed only in theirs
+	ChangedTheirs = "theirs"
+	// changed the same way in both documents
+	ChangedBoth = "both"
+	// changed differently in both documents
+	Conflict = "conflict"
+)
+
+// Diff3 describes a path changed by at least one of the documents
+// of a three-way comparison. Missing values are nil. Unchanged paths
+// are not reported.
+type Diff3 struct {
+	Base   yaml.Node
+	Ours   yaml.Node
+	Theirs yaml.Node
+
+	Path   []string
+	Change string
+}
+
+// Compare3 compares two documents (ours and theirs) derived
+// from a common base document.
+func Compare3(base, ours, theirs yaml.Node) []Diff3 {
+	return Compare3WithOptions(base, ours, theirs, Options{})
+}
+
+// Compare3WithOptions compares two documents derived from a common
+// base document using the given options.
+func Compare3WithOptions(base, ours, theirs yaml.Node, opts Options) []Diff3 {
+	c := comparer{opts}
+	return c.compare3(base, ours, theirs, []string{})
+}
+
+// Conflicts provides the conflicting changes of a three-way comparison.
+func Conflicts(diffs []Diff3) []Diff3 {
+	conflicts := []Diff3{}
+	for _, d := range diffs {
+		if d.Change == Conflict {
+			conflicts = append(conflicts, d)
+		}
+	}
+	return conflicts
+}
+
+// Diffs3AsNode provides a list of the given differences with
+// the fields path, change, base, ours and theirs. Missing values
+// are omitted.
+func Diffs3AsNode(diffs []Diff3) yaml.Node {
+	list := []yaml.Node{}
+	for _, diff := range diffs {
+		path := []yaml.Node{}
+		for _, step := range diff.Path {
+			path = append(path, yaml.NewNode(step, "diff"))
+		}
+		entry := map[string]yaml.Node{
+			"path":   yaml.NewNode(path, "diff"),
+			"change": yaml.NewNode(diff.Change, "diff"),
+		}
+		if diff.Base != nil {
+			entry["base"] = diff.Base
+		}
+		if diff.Ours != nil {
+			entry["ours"] = diff.Ours
+		}
+		if diff.Theirs != nil {
+			entry["theirs"] = diff.Theirs
+		}
+		list = append(list, yaml.NewNode(entry, "diff"))
+	}
+	return yaml.NewNode(list, "diff")
+}
+
+func (c comparer) compare3(base, ours, theirs yaml.Node, path []string) []Diff3 {
+	if c.opts.Ignore.Matches(path) {
+		return []Diff3{}
+	}
+	changedOurs := !c.equal(base, ours, path)
+	changedTheirs := !c.equal(base, theirs, path)
+	if !changedOurs && !changedTheirs {
+		return []Diff3{}
+	}
+
+	// descend into structures present in all documents to
+	// narrow the changes down to the affected paths. Changes
+	// not visible for the entries (like the order of jobs) are
+	// reported for the whole structure.
+	var nested []Diff3
+	if bm, om, tm, ok := c.asMaps(base, ours, theirs, path); ok {
+		nested = c.compareMap3(bm, om, tm, path)
+	} else if bl, ol, tl, ok := asLists(base, ours, theirs); ok {
+		nested = c.compareList3(bl, ol, tl, path)
+	}
+	if len(nested) > 0 {
+		return nested
+	}
+
+	diff := Diff3{Base: base, Ours: ours, Theirs: theirs, Path: path}
+	switch {
+	case !changedTheirs:
+		diff.Change = ChangedOurs
+	case !changedOurs:
+		diff.Change = ChangedTheirs
+	case c.equal(ours, theirs, path):
+		diff.Change = ChangedBoth
+	default:
+		diff.Change = Conflict
+	}
+	return []Diff3{diff}
+}
+
+func (c comparer) compareMap3(base, ours, theirs map[string]yaml.Node, path []string) []Diff3 {
+	keys := map[string]bool{}
+	for _, m := range []map[string]yaml.Node{base, ours, theirs} {
+		for k := range m {
+			keys[k] = true
+		}
+	}
+	sorted := []string{}
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	diff := []Diff3{}
+	for _, k := range sorted {
+		diff = append(diff, c.compare3(base[k], ours[k], theirs[k], addPath(path, k))...)
+	}
+	return diff
+}
+
+// compareList3 compares lists of equal length entry by entry.
+func (c comparer) compareList3(base, ours, theirs []yaml.Node, path []string) []Diff3 {
+	diff := []Diff3{}
+	for i := range base {
+		diff = append(diff, c.compare3(base[i], ours[i], theirs[i], addPath(path, fmt.Sprintf("[%d]", i)))...)
+	}
+	return diff
+}
+
+// asMaps provides the map values of three nodes. Lists with
+// key fields are handled as maps of their entries.
+func (c comparer) asMaps(base, ours, theirs yaml.Node, path []string) (map[string]yaml.Node, map[string]yaml.Node, map[string]yaml.Node, bool) {
+	maps := []map[string]yaml.Node{}
+	for _, n := range []yaml.Node{base, ours, theirs} {
+		if n == nil {
+			return nil, nil, nil, false
+		}
+		switch v := n.Value().(type) {
+		case map[string]yaml.Node:
+			maps = append(maps, v)
+		case []yaml.Node:
+			m := listToMap(v, c.keyName(n, path))
+			if m == nil || len(m) != len(v) {
+				return nil, nil, nil, false
+			}
+			maps = append(maps, m)
+		default:
+			return nil, nil, nil, false
+		}
+	}
+	return maps[0], maps[1], maps[2], true
+}
+
+// asLists provides the list values of three nodes. Lists with
+// different lengths are compared as a whole.
+func asLists(base, ours, theirs yaml.Node) ([]yaml.Node, []yaml.Node, []yaml.Node, bool) {
+	lists := [][]yaml.Node{}
+	for _, n := range []yaml.Node{base, ours, theirs} {
+		if n == nil {
+			return nil, nil, nil, false
+		}
+		l, ok := n.Value().([]yaml.Node)
+		if !ok || (len(lists) > 0 && len(l) != len(lists[0])) {
+			return nil, nil, nil, false
+		}
+		lists = append(lists, l)
+	}
+	return lists[0], lists[1], lists[2], true
+}
+
+// equal checks whether two (possibly missing) nodes
+// are structurally equal.
+func (c comparer) equal(a, b yaml.Node, path []string) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return len(c.compare(a, b, path)) == 0
+}
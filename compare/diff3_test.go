@@ -0,0 +1,137 @@
+package compare
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+var _ = Describe("Three-way diffing YAML", func() {
+	base := parseYAML(`
+---
+foo: 1
+bar: 1
+alice: 1
+bob: 1
+`)
+
+	It("reports no differences for unchanged documents", func() {
+		Expect(Compare3(base, base, base)).To(BeEmpty())
+	})
+
+	It("classifies the changed paths", func() {
+		ours := parseYAML(`
+---
+foo: 2
+bar: 1
+alice: 2
+bob: 2
+`)
+		theirs := parseYAML(`
+---
+foo: 1
+bar: 3
+alice: 2
+bob: 3
+`)
+		Expect(Compare3(base, ours, theirs)).To(Equal([]Diff3{
+			{Base: parseYAML("1"), Ours: parseYAML("2"), Theirs: parseYAML("2"), Path: []string{"alice"}, Change: ChangedBoth},
+			{Base: parseYAML("1"), Ours: parseYAML("1"), Theirs: parseYAML("3"), Path: []string{"bar"}, Change: ChangedTheirs},
+			{Base: parseYAML("1"), Ours: parseYAML("2"), Theirs: parseYAML("3"), Path: []string{"bob"}, Change: Conflict},
+			{Base: parseYAML("1"), Ours: parseYAML("2"), Theirs: parseYAML("1"), Path: []string{"foo"}, Change: ChangedOurs},
+		}))
+	})
+
+	It("reports added and removed nodes", func() {
+		ours := parseYAML(`
+---
+foo: 1
+bar: 1
+alice: 1
+peter: 1
+`)
+		theirs := parseYAML(`
+---
+foo: 1
+bar: 1
+alice: 1
+bob: 2
+`)
+		Expect(Compare3(base, ours, theirs)).To(Equal([]Diff3{
+			{Base: parseYAML("1"), Ours: nil, Theirs: parseYAML("2"), Path: []string{"bob"}, Change: Conflict},
+			{Base: nil, Ours: parseYAML("1"), Theirs: nil, Path: []string{"peter"}, Change: ChangedOurs},
+		}))
+	})
+
+	Context("with lists", func() {
+		base := parseYAML(`
+---
+jobs:
+- name: web
+  instances: 1
+- name: db
+  instances: 1
+list:
+- 1
+- 2
+`)
+
+		It("compares named entries by name", func() {
+			ours := parseYAML(`
+---
+jobs:
+- name: web
+  instances: 2
+- name: db
+  instances: 1
+list:
+- 1
+- 3
+`)
+			theirs := parseYAML(`
+---
+jobs:
+- name: web
+  instances: 1
+- name: db
+  instances: 2
+list:
+- 1
+- 4
+- 5
+`)
+			Expect(Compare3(base, ours, theirs)).To(Equal([]Diff3{
+				{Base: parseYAML("1"), Ours: parseYAML("1"), Theirs: parseYAML("2"), Path: []string{"jobs", "db", "instances"}, Change: ChangedTheirs},
+				{Base: parseYAML("1"), Ours: parseYAML("2"), Theirs: parseYAML("1"), Path: []string{"jobs", "web", "instances"}, Change: ChangedOurs},
+				{Base: parseYAML("[1, 2]"), Ours: parseYAML("[1, 3]"), Theirs: parseYAML("[1, 4, 5]"), Path: []string{"list"}, Change: Conflict},
+			}))
+		})
+	})
+
+	Describe("conflicts", func() {
+		It("selects the conflicting changes", func() {
+			diffs := []Diff3{
+				{Path: []string{"foo"}, Change: ChangedOurs},
+				{Path: []string{"bar"}, Change: Conflict},
+			}
+			Expect(Conflicts(diffs)).To(Equal([]Diff3{{Path: []string{"bar"}, Change: Conflict}}))
+		})
+	})
+
+	Describe("machine readable differences", func() {
+		It("lists the differences with path, change and values", func() {
+			diffs := []Diff3{
+				{Base: parseYAML("1"), Ours: parseYAML("2"), Path: []string{"foo"}, Change: Conflict},
+			}
+			Expect(Diffs3AsNode(diffs)).To(Equal(yaml.NewNode([]yaml.Node{
+				yaml.NewNode(map[string]yaml.Node{
+					"path":   yaml.NewNode([]yaml.Node{yaml.NewNode("foo", "diff")}, "diff"),
+					"change": yaml.NewNode(Conflict, "diff"),
+					"base":   parseYAML("1"),
+					"ours":   parseYAML("2"),
+				}, "diff"),
+			}, "diff")))
+		})
+	})
+})
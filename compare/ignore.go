@@ -0,0 +1,82 @@
+package compare
+
+import (
+	"bufio"
+	"bytes"
+	"path"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+// IgnoreRules is a set of path patterns excluded from comparisons.
+// The steps of a pattern are separated by dots. A step matches map
+// keys and list entries (by key value, optionally given with the key
+// name as in name:web) and may contain the wildcards * and ?. The
+// step ** matches any number of steps.
+type IgnoreRules [][]string
+
+// ParseIgnoreRules parses dot separated path patterns.
+func ParseIgnoreRules(patterns ...string) IgnoreRules {
+	rules := IgnoreRules{}
+	for _, p := range patterns {
+		if p = strings.TrimSpace(p); p != "" {
+			rules = append(rules, strings.Split(p, "."))
+		}
+	}
+	return rules
+}
+
+// ReadIgnoreRules parses a file with one pattern per line. Empty
+// lines and lines starting with # are skipped.
+func ReadIgnoreRules(data []byte) IgnoreRules {
+	patterns := []string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if !strings.HasPrefix(line, "#") {
+			patterns = append(patterns, line)
+		}
+	}
+	return ParseIgnoreRules(patterns...)
+}
+
+// Matches checks whether a path is matched by a rule.
+func (r IgnoreRules) Matches(path []string) bool {
+	for _, rule := range r {
+		if matchSteps(rule, path) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchSteps(pattern, steps []string) bool {
+	if len(pattern) == 0 {
+		return len(steps) == 0
+	}
+	if pattern[0] == "**" {
+		for i := 0; i <= len(steps); i++ {
+			if matchSteps(pattern[1:], steps[i:]) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(steps) == 0 || !matchStep(pattern[0], steps[0]) {
+		return false
+	}
+	return matchSteps(pattern[1:], steps[1:])
+}
+
+func matchStep(pattern, step string) bool {
+	if pattern == step {
+		return true
+	}
+	pattern = yaml.PathComponent(pattern)
+	if pattern == step {
+		return true
+	}
+	ok, err := path.Match(pattern, step)
+	return err == nil && ok
+}
@@ -24,5 +24,25 @@ func parseYAMLFrom(source string, name string) yaml.Node {
 		panic(err)
 	}
 
-	return parsed
+	return withoutPositions(parsed)
+}
+
+// diffs are compared by value, so source positions
+// of the parsed documents would break the equality
+func withoutPositions(node yaml.Node) yaml.Node {
+	switch v := node.Value().(type) {
+	case map[string]yaml.Node:
+		m := map[string]yaml.Node{}
+		for k, e := range v {
+			m[k] = withoutPositions(e)
+		}
+		return yaml.NewNode(m, node.SourceName())
+	case []yaml.Node:
+		l := []yaml.Node{}
+		for _, e := range v {
+			l = append(l, withoutPositions(e))
+		}
+		return yaml.NewNode(l, node.SourceName())
+	}
+	return yaml.NewNode(node.Value(), node.SourceName())
 }
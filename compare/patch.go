@@ -0,0 +1,644 @@
+package compare
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+// operations of a JSON Patch (RFC 6902)
+const (
+	PatchAdd     = "add"
+	PatchRemove  = "remove"
+	PatchReplace = "replace"
+	PatchMove    = "move"
+	PatchCopy    = "copy"
+	PatchTest    = "test"
+)
+
+// PatchOperation is an operation of a JSON Patch (RFC 6902).
+// Besides indices, list entries can be addressed by a key step
+// (field:value), which is stable against the insertion of entries.
+type PatchOperation struct {
+	Op    string
+	Path  string
+	From  string
+	Value yaml.Node
+}
+
+// JSONPatch provides a JSON Patch transforming the document a into
+// the document b. Entries of lists with key fields are matched by
+// their keys and addressed by key steps unless indices are requested
+// by the options. Key steps (like name:web) are no RFC 6901 array
+// indices, they are only understood by ApplyPatch. Insertions and
+// moves are addressed by the target index, ignored entries are
+// kept in place.
+func JSONPatch(a, b yaml.Node, opts Options) []PatchOperation {
+	p := &patcher{comparer: comparer{opts}, ops: []PatchOperation{}}
+	p.patch(a, b, []string{}, []string{})
+	return p.ops
+}
+
+// patcher collects the operations of a JSON Patch. Operations for
+// the entries of a list are emitted before the list itself is
+// changed, so indices always refer to the current state of the list.
+type patcher struct {
+	comparer
+	ops []PatchOperation
+}
+
+func (p *patcher) add(op string, segments []string, value yaml.Node) {
+	p.ops = append(p.ops, PatchOperation{Op: op, Path: formatPointer(segments), Value: value})
+}
+
+// patch compares the nodes a and b found at the given (diff) path and
+// pointer segments.
+func (p *patcher) patch(a, b yaml.Node, path []string, segments []string) {
+	if p.opts.Ignore.Matches(path) {
+		return
+	}
+	switch av := a.Value().(type) {
+	case map[string]yaml.Node:
+		if bv, ok := b.Value().(map[string]yaml.Node); ok {
+			p.patchMap(av, bv, path, segments, "")
+			return
+		}
+	case []yaml.Node:
+		if bv, ok := b.Value().([]yaml.Node); ok {
+			keyName := p.keyName(a, path)
+			if keyedList(av, keyName) && keyedList(bv, keyName) {
+				p.patchKeyedList(av, bv, path, segments, keyName)
+			} else {
+				p.patchList(av, bv, path, segments)
+			}
+			return
+		}
+	default:
+		if p.equalValues(av, b.Value()) {
+			return
+		}
+	}
+	p.add(PatchReplace, segments, b)
+}
+
+// patchMap patches the fields of a map. The key fields of keyed
+// list entries are left untouched.
+func (p *patcher) patchMap(a, b map[string]yaml.Node, path []string, segments []string, keyName string) {
+	for _, key := range sortedKeys(a, b) {
+		if keyName != "" && yaml.IsKeyField(key, keyName) {
+			continue
+		}
+		if p.opts.Ignore.Matches(addPath(path, key)) {
+			continue
+		}
+		aval, inA := a[key]
+		bval, inB := b[key]
+		switch {
+		case !inB:
+			p.add(PatchRemove, addPath(segments, key), nil)
+		case !inA:
+			p.add(PatchAdd, addPath(segments, key), bval)
+		default:
+			p.patch(aval, bval, addPath(path, key), addPath(segments, key))
+		}
+	}
+}
+
+// patchList patches a list entry by entry according to the indices.
+func (p *patcher) patchList(a, b []yaml.Node, path []string, segments []string) {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		step := fmt.Sprintf("[%d]", i)
+		p.patch(a[i], b[i], addPath(path, step), addPath(segments, strconv.Itoa(i)))
+	}
+	for i := len(a) - 1; i >= len(b); i-- {
+		if !p.opts.Ignore.Matches(addPath(path, fmt.Sprintf("[%d]", i))) {
+			p.add(PatchRemove, addPath(segments, strconv.Itoa(i)), nil)
+		}
+	}
+	for i := len(a); i < len(b); i++ {
+		if !p.opts.Ignore.Matches(addPath(path, fmt.Sprintf("[%d]", i))) {
+			p.add(PatchAdd, addPath(segments, strconv.Itoa(i)), b[i])
+		}
+	}
+}
+
+// patchKeyedList patches a list whose entries are identified by
+// keys. Entries present in both lists are patched recursively,
+// entries found only in a are removed, entries found only in b are
+// added and the remaining entries are moved to their position in b.
+func (p *patcher) patchKeyedList(a, b []yaml.Node, path []string, segments []string, keyName string) {
+	keyStep := keyName
+	if keyStep == "" {
+		keyStep = "name"
+	}
+	// actual is the current state of the list, current omits the
+	// ignored entries kept in the list, they are not moved.
+	actual := listKeys(a, keyName)
+	current := []string{}
+	// entry provides the pointer segment for an entry of the
+	// current state of the list
+	entry := func(key string) string {
+		if p.opts.IndexPaths {
+			return strconv.Itoa(indexOf(actual, key))
+		}
+		return keyStep + ":" + key
+	}
+	// target provides the index in the actual list for a position
+	// in the current list
+	target := func(pos int) int {
+		if pos < len(current) {
+			return indexOf(actual, current[pos])
+		}
+		return len(actual)
+	}
+
+	bEntries := map[string]yaml.Node{}
+	for _, e := range b {
+		key, _ := yaml.ListEntryKey(false, e, keyName)
+		bEntries[key] = e
+	}
+	for _, e := range a {
+		key, _ := yaml.ListEntryKey(false, e, keyName)
+		if be, ok := bEntries[key]; ok && !p.opts.Ignore.Matches(addPath(path, key)) {
+			am, aok := e.Value().(map[string]yaml.Node)
+			bm, bok := be.Value().(map[string]yaml.Node)
+			if aok && bok {
+				p.patchMap(am, bm, addPath(path, key), addPath(segments, entry(key)), keyName)
+			} else {
+				p.patch(e, be, addPath(path, key), addPath(segments, entry(key)))
+			}
+		}
+	}
+
+	for _, key := range listKeys(a, keyName) {
+		if _, ok := bEntries[key]; !ok {
+			if !p.opts.Ignore.Matches(addPath(path, key)) {
+				p.add(PatchRemove, addPath(segments, entry(key)), nil)
+				actual = remove(actual, indexOf(actual, key))
+			}
+			continue
+		}
+		current = append(current, key)
+	}
+
+	pos := 0
+	for _, key := range listKeys(b, keyName) {
+		index := indexOf(current, key)
+		switch {
+		case index == pos:
+		case index < 0:
+			if p.opts.Ignore.Matches(addPath(path, key)) {
+				continue
+			}
+			p.add(PatchAdd, addPath(segments, strconv.Itoa(target(pos))), bEntries[key])
+			actual = insert(actual, target(pos), key)
+			current = insert(current, pos, key)
+		default:
+			to := target(pos)
+			p.ops = append(p.ops, PatchOperation{Op: PatchMove,
+				From: formatPointer(addPath(segments, entry(key))),
+				Path: formatPointer(addPath(segments, strconv.Itoa(to)))})
+			actual = insert(remove(actual, indexOf(actual, key)), to, key)
+			current = insert(remove(current, index), pos, key)
+		}
+		pos++
+	}
+}
+
+// keyedList checks whether all entries of a list have a unique key.
+func keyedList(list []yaml.Node, keyName string) bool {
+	found := map[string]bool{}
+	for _, e := range list {
+		key, ok := yaml.ListEntryKey(false, e, keyName)
+		if !ok || found[key] {
+			return false
+		}
+		found[key] = true
+	}
+	return true
+}
+
+func listKeys(list []yaml.Node, keyName string) []string {
+	keys := []string{}
+	for _, e := range list {
+		key, _ := yaml.ListEntryKey(false, e, keyName)
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func remove(keys []string, index int) []string {
+	return append(keys[:index:index], keys[index+1:]...)
+}
+
+func insert(keys []string, index int, key string) []string {
+	return append(keys[:index:index], append([]string{key}, keys[index:]...)...)
+}
+
+func indexOf(keys []string, key string) int {
+	for i, k := range keys {
+		if k == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// sortedKeys provides the sorted union of the keys of two maps.
+func sortedKeys(a, b map[string]yaml.Node) []string {
+	keys := []string{}
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// PatchAsNode provides the operations of a JSON Patch as document.
+func PatchAsNode(ops []PatchOperation) yaml.Node {
+	list := []yaml.Node{}
+	for _, op := range ops {
+		entry := map[string]yaml.Node{
+			"op":   yaml.NewNode(op.Op, "patch"),
+			"path": yaml.NewNode(op.Path, "patch"),
+		}
+		switch op.Op {
+		case PatchAdd, PatchReplace, PatchTest:
+			entry["value"] = op.Value
+		case PatchMove, PatchCopy:
+			entry["from"] = yaml.NewNode(op.From, "patch")
+		}
+		list = append(list, yaml.NewNode(entry, "patch"))
+	}
+	return yaml.NewNode(list, "patch")
+}
+
+// ParsePatch reads the operations of a JSON Patch document.
+func ParsePatch(node yaml.Node) ([]PatchOperation, error) {
+	list, ok := node.Value().([]yaml.Node)
+	if !ok {
+		return nil, fmt.Errorf("patch must be a list of operations")
+	}
+	ops := []PatchOperation{}
+	for i, e := range list {
+		op := PatchOperation{}
+		op.Op, _ = yaml.FindString(e, "op")
+		switch op.Op {
+		case PatchAdd, PatchRemove, PatchReplace, PatchMove, PatchCopy, PatchTest:
+		default:
+			return nil, fmt.Errorf("operation %d: invalid op '%s'", i+1, op.Op)
+		}
+		if op.Path, ok = yaml.FindString(e, "path"); !ok {
+			return nil, fmt.Errorf("operation %d: path missing", i+1)
+		}
+		switch op.Op {
+		case PatchMove, PatchCopy:
+			if op.From, ok = yaml.FindString(e, "from"); !ok {
+				return nil, fmt.Errorf("operation %d: from missing", i+1)
+			}
+		case PatchAdd, PatchReplace, PatchTest:
+			if op.Value, ok = yaml.Find(e, "value"); !ok {
+				return nil, fmt.Errorf("operation %d: value missing", i+1)
+			}
+		}
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// ApplyPatch applies the operations of a JSON Patch to a document.
+// The given document is not modified.
+func ApplyPatch(doc yaml.Node, ops []PatchOperation) (yaml.Node, error) {
+	var err error
+	for i, op := range ops {
+		doc, err = applyOperation(doc, op)
+		if err != nil {
+			return nil, fmt.Errorf("operation %d (%s %s): %s", i+1, op.Op, op.Path, err)
+		}
+	}
+	return doc, nil
+}
+
+func applyOperation(doc yaml.Node, op PatchOperation) (yaml.Node, error) {
+	path, err := parsePointer(op.Path)
+	if err != nil {
+		return nil, err
+	}
+	switch op.Op {
+	case PatchAdd:
+		return addNode(doc, path, op.Value)
+	case PatchRemove:
+		return removeNode(doc, path)
+	case PatchReplace:
+		if _, err := getNode(doc, path); err != nil {
+			return nil, err
+		}
+		return setNode(doc, path, op.Value)
+	case PatchTest:
+		node, err := getNode(doc, path)
+		if err != nil {
+			return nil, err
+		}
+		if len(Compare(node, op.Value)) > 0 {
+			return nil, fmt.Errorf("test failed")
+		}
+		return doc, nil
+	case PatchMove, PatchCopy:
+		from, err := parsePointer(op.From)
+		if err != nil {
+			return nil, err
+		}
+		node, err := getNode(doc, from)
+		if err != nil {
+			return nil, err
+		}
+		if op.Op == PatchMove {
+			if doc, err = removeNode(doc, from); err != nil {
+				return nil, err
+			}
+		}
+		return addNode(doc, path, node)
+	}
+	return nil, fmt.Errorf("invalid op '%s'", op.Op)
+}
+
+func getNode(doc yaml.Node, path []string) (yaml.Node, error) {
+	node := doc
+	for _, segment := range path {
+		switch v := node.Value().(type) {
+		case map[string]yaml.Node:
+			next, ok := v[segment]
+			if !ok {
+				return nil, fmt.Errorf("'%s' not found", segment)
+			}
+			node = next
+		case []yaml.Node:
+			index, err := listIndex(v, segment, false)
+			if err != nil {
+				return nil, err
+			}
+			node = v[index]
+		default:
+			return nil, fmt.Errorf("'%s' not found", segment)
+		}
+	}
+	return node, nil
+}
+
+// update replaces the container of the last path segment by the
+// result of the given function, copying all enclosing nodes.
+func update(doc yaml.Node, path []string, f func(container yaml.Node, segment string) (yaml.Node, error)) (yaml.Node, error) {
+	if len(path) == 1 {
+		return f(doc, path[0])
+	}
+	segment := path[0]
+	switch v := doc.Value().(type) {
+	case map[string]yaml.Node:
+		child, ok := v[segment]
+		if !ok {
+			return nil, fmt.Errorf("'%s' not found", segment)
+		}
+		child, err := update(child, path[1:], f)
+		if err != nil {
+			return nil, err
+		}
+		m := copyMap(v)
+		m[segment] = child
+		return yaml.SubstituteNode(m, doc), nil
+	case []yaml.Node:
+		index, err := listIndex(v, segment, false)
+		if err != nil {
+			return nil, err
+		}
+		child, err := update(v[index], path[1:], f)
+		if err != nil {
+			return nil, err
+		}
+		l := append([]yaml.Node{}, v...)
+		l[index] = child
+		return yaml.SubstituteNode(l, doc), nil
+	}
+	return nil, fmt.Errorf("'%s' not found", segment)
+}
+
+func setNode(doc yaml.Node, path []string, value yaml.Node) (yaml.Node, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(container yaml.Node, segment string) (yaml.Node, error) {
+		switch v := container.Value().(type) {
+		case map[string]yaml.Node:
+			m := copyMap(v)
+			m[segment] = value
+			return yaml.SubstituteNode(m, container), nil
+		case []yaml.Node:
+			index, err := listIndex(v, segment, false)
+			if err != nil {
+				return nil, err
+			}
+			l := append([]yaml.Node{}, v...)
+			l[index] = value
+			return yaml.SubstituteNode(l, container), nil
+		}
+		return nil, fmt.Errorf("'%s' not found", segment)
+	})
+}
+
+func addNode(doc yaml.Node, path []string, value yaml.Node) (yaml.Node, error) {
+	if len(path) == 0 {
+		return value, nil
+	}
+	return update(doc, path, func(container yaml.Node, segment string) (yaml.Node, error) {
+		switch v := container.Value().(type) {
+		case map[string]yaml.Node:
+			m := copyMap(v)
+			m[segment] = value
+			return yaml.SubstituteNode(m, container), nil
+		case []yaml.Node:
+			index, err := listIndex(v, segment, true)
+			if err != nil {
+				return nil, err
+			}
+			l := append([]yaml.Node{}, v[:index]...)
+			l = append(l, value)
+			l = append(l, v[index:]...)
+			return yaml.SubstituteNode(l, container), nil
+		}
+		return nil, fmt.Errorf("cannot add '%s' to a value", segment)
+	})
+}
+
+func removeNode(doc yaml.Node, path []string) (yaml.Node, error) {
+	if len(path) == 0 {
+		return nil, fmt.Errorf("cannot remove the document")
+	}
+	return update(doc, path, func(container yaml.Node, segment string) (yaml.Node, error) {
+		switch v := container.Value().(type) {
+		case map[string]yaml.Node:
+			if _, ok := v[segment]; !ok {
+				return nil, fmt.Errorf("'%s' not found", segment)
+			}
+			m := copyMap(v)
+			delete(m, segment)
+			return yaml.SubstituteNode(m, container), nil
+		case []yaml.Node:
+			index, err := listIndex(v, segment, false)
+			if err != nil {
+				return nil, err
+			}
+			l := append([]yaml.Node{}, v[:index]...)
+			l = append(l, v[index+1:]...)
+			return yaml.SubstituteNode(l, container), nil
+		}
+		return nil, fmt.Errorf("'%s' not found", segment)
+	})
+}
+
+// listIndex resolves a pointer segment for a list. Segments are
+// indices, - (the end of the list, if allowed) or key steps.
+func listIndex(list []yaml.Node, segment string, end bool) (int, error) {
+	if segment == "-" && end {
+		return len(list), nil
+	}
+	if i := strings.Index(segment, ":"); i > 0 {
+		key := segment[:i]
+		for index, e := range list {
+			if name, ok := yaml.ListEntryKey(false, e, key); ok && name == segment[i+1:] {
+				return index, nil
+			}
+		}
+		return 0, fmt.Errorf("'%s' not found", segment)
+	}
+	index, err := strconv.Atoi(segment)
+	if err != nil || index < 0 {
+		return 0, fmt.Errorf("invalid list index '%s'", segment)
+	}
+	if index > len(list) || (index == len(list) && !end) {
+		return 0, fmt.Errorf("list index %d out of range", index)
+	}
+	return index, nil
+}
+
+func copyMap(m map[string]yaml.Node) map[string]yaml.Node {
+	r := make(map[string]yaml.Node, len(m))
+	for k, v := range m {
+		r[k] = v
+	}
+	return r
+}
+
+func parsePointer(pointer string) ([]string, error) {
+	if pointer == "" {
+		return []string{}, nil
+	}
+	if !strings.HasPrefix(pointer, "/") {
+		return nil, fmt.Errorf("invalid pointer '%s'", pointer)
+	}
+	segments := strings.Split(pointer[1:], "/")
+	for i, s := range segments {
+		segments[i] = strings.Replace(strings.Replace(s, "~1", "/", -1), "~0", "~", -1)
+	}
+	return segments, nil
+}
+
+func formatPointer(segments []string) string {
+	pointer := ""
+	for _, s := range segments {
+		pointer += "/" + strings.Replace(strings.Replace(s, "~", "~0", -1), "/", "~1", -1)
+	}
+	return pointer
+}
+
+// MergePatch converts the differences of the documents a and b into
+// a JSON Merge Patch (RFC 7396) transforming a into b. The values are
+// taken from b. Lists cannot be patched partially, they are replaced
+// as a whole.
+func MergePatch(b yaml.Node, diffs []Diff) yaml.Node {
+	patch := &mergeNode{}
+	for _, d := range diffs {
+		patch.add(b, d.Path)
+	}
+	return patch.node()
+}
+
+// mergeNode is a node of a merge patch under construction, either
+// a complete value or a map of nested patches.
+type mergeNode struct {
+	value  yaml.Node
+	fields map[string]*mergeNode
+}
+
+// add adds the change of a path of the target document b.
+func (p *mergeNode) add(b yaml.Node, path []string) {
+	if p.value != nil {
+		return
+	}
+	m, ok := b.Value().(map[string]yaml.Node)
+	if len(path) == 0 || !ok {
+		p.value = b
+		p.fields = nil
+		return
+	}
+	if p.fields == nil {
+		p.fields = map[string]*mergeNode{}
+	}
+	child := p.fields[path[0]]
+	if child == nil {
+		child = &mergeNode{}
+		p.fields[path[0]] = child
+	}
+	next, ok := m[path[0]]
+	if !ok {
+		// removed from b
+		child.value = yaml.NewNode(nil, "patch")
+		child.fields = nil
+		return
+	}
+	child.add(next, path[1:])
+}
+
+func (p *mergeNode) node() yaml.Node {
+	if p.value != nil {
+		return p.value
+	}
+	m := map[string]yaml.Node{}
+	for k, f := range p.fields {
+		m[k] = f.node()
+	}
+	return yaml.NewNode(m, "patch")
+}
+
+// ApplyMergePatch applies a JSON Merge Patch (RFC 7396) to a
+// document. The given document is not modified.
+func ApplyMergePatch(doc yaml.Node, patch yaml.Node) yaml.Node {
+	pm, ok := patch.Value().(map[string]yaml.Node)
+	if !ok {
+		return patch
+	}
+	var m map[string]yaml.Node
+	if doc != nil {
+		if dm, ok := doc.Value().(map[string]yaml.Node); ok {
+			m = copyMap(dm)
+		}
+	}
+	if m == nil {
+		m = map[string]yaml.Node{}
+		doc = patch
+	}
+	for k, v := range pm {
+		if v == nil || v.Value() == nil {
+			delete(m, k)
+		} else {
+			m[k] = ApplyMergePatch(m[k], v)
+		}
+	}
+	return yaml.SubstituteNode(m, doc)
+}
@@ -0,0 +1,281 @@
+package compare
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+var _ = Describe("Patching YAML", func() {
+	a := parseYAML(`
+---
+jobs:
+- name: web
+  instances: 1
+- name: db
+  instances: 1
+list: [ 1, 2, 3 ]
+props:
+  alice: 1
+  b/c: 2
+`)
+	b := parseYAML(`
+---
+jobs:
+- name: web
+  instances: 2
+- name: db
+  instances: 1
+list: [ 1 ]
+props:
+  alice: 1
+  bob: 3
+`)
+
+	Describe("JSON Patch", func() {
+		It("addresses named list entries by key", func() {
+			Expect(JSONPatch(a, b, Options{})).To(Equal([]PatchOperation{
+				{Op: PatchReplace, Path: "/jobs/name:web/instances", Value: parseYAML("2")},
+				{Op: PatchRemove, Path: "/list/2"},
+				{Op: PatchRemove, Path: "/list/1"},
+				{Op: PatchRemove, Path: "/props/b~1c"},
+				{Op: PatchAdd, Path: "/props/bob", Value: parseYAML("3")},
+			}))
+		})
+
+		It("addresses list entries by index if requested", func() {
+			ops := JSONPatch(a, b, Options{IndexPaths: true})
+			Expect(ops[0]).To(Equal(PatchOperation{Op: PatchReplace, Path: "/jobs/0/instances", Value: parseYAML("2")}))
+		})
+
+		It("transforms the first document into the second one", func() {
+			for _, opts := range []Options{{}, {IndexPaths: true}} {
+				patched, err := ApplyPatch(a, JSONPatch(a, b, opts))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(Compare(patched, b)).To(BeEmpty())
+			}
+		})
+
+		Context("for lists with named entries", func() {
+			list := parseYAML(`
+---
+list:
+- name: a
+  v: 1
+- name: b
+  v: 2
+- name: c
+  v: 3
+`)
+
+			roundTrip := func(a, b yaml.Node) {
+				for _, opts := range []Options{{}, {IndexPaths: true}} {
+					patched, err := ApplyPatch(a, JSONPatch(a, b, opts))
+					Expect(err).NotTo(HaveOccurred())
+					Expect(patched).To(Equal(b))
+				}
+			}
+
+			It("removes entries by key", func() {
+				b := parseYAML(`
+---
+list:
+- name: a
+  v: 1
+- name: c
+  v: 3
+`)
+				Expect(JSONPatch(list, b, Options{})).To(Equal([]PatchOperation{
+					{Op: PatchRemove, Path: "/list/name:b"},
+				}))
+				roundTrip(list, b)
+			})
+
+			It("inserts entries at their position", func() {
+				a := parseYAML(`
+---
+list:
+- name: a
+  v: 1
+- name: c
+  v: 3
+`)
+				Expect(JSONPatch(a, list, Options{})).To(Equal([]PatchOperation{
+					{Op: PatchAdd, Path: "/list/1", Value: list.Value().(map[string]yaml.Node)["list"].Value().([]yaml.Node)[1]},
+				}))
+				roundTrip(a, list)
+			})
+
+			It("moves reordered entries", func() {
+				b := parseYAML(`
+---
+list:
+- name: c
+  v: 4
+- name: d
+- name: a
+  v: 1
+`)
+				Expect(JSONPatch(list, b, Options{})).To(Equal([]PatchOperation{
+					{Op: PatchReplace, Path: "/list/name:c/v", Value: parseYAML("4")},
+					{Op: PatchRemove, Path: "/list/name:b"},
+					{Op: PatchMove, From: "/list/name:c", Path: "/list/0"},
+					{Op: PatchAdd, Path: "/list/1", Value: b.Value().(map[string]yaml.Node)["list"].Value().([]yaml.Node)[1]},
+				}))
+				roundTrip(list, b)
+				roundTrip(b, list)
+			})
+
+			It("keeps ignored entries in place", func() {
+				b := parseYAML(`
+---
+list:
+- name: a
+  v: 1
+- name: c
+  v: 3
+- name: d
+`)
+				rules := ParseIgnoreRules("list.b")
+				for _, opts := range []Options{{Ignore: rules}, {Ignore: rules, IndexPaths: true}} {
+					ops := JSONPatch(list, b, opts)
+					Expect(ops).To(HaveLen(1))
+					Expect(ops[0].Op).To(Equal(PatchAdd))
+					Expect(ops[0].Path).To(Equal("/list/3"))
+
+					patched, err := ApplyPatch(list, ops)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(entryNames(patched)).To(Equal([]string{"a", "b", "c", "d"}))
+				}
+			})
+
+			It("moves entries around ignored entries", func() {
+				b := parseYAML(`
+---
+list:
+- name: c
+  v: 3
+- name: a
+  v: 1
+`)
+				rules := ParseIgnoreRules("list.b")
+				for _, opts := range []Options{{Ignore: rules}, {Ignore: rules, IndexPaths: true}} {
+					ops := JSONPatch(list, b, opts)
+					Expect(ops).To(HaveLen(1))
+					Expect(ops[0].Op).To(Equal(PatchMove))
+					Expect(ops[0].Path).To(Equal("/list/0"))
+
+					patched, err := ApplyPatch(list, ops)
+					Expect(err).NotTo(HaveOccurred())
+					Expect(entryNames(patched)).To(Equal([]string{"c", "a", "b"}))
+				}
+			})
+
+			It("never replaces key fields", func() {
+				b := parseYAML(`
+---
+list:
+- name: a
+  v: 1
+- name: x
+  v: 2
+- name: c
+  v: 3
+`)
+				for _, op := range JSONPatch(list, b, Options{}) {
+					Expect(op.Path).NotTo(HaveSuffix("/name"))
+				}
+				roundTrip(list, b)
+			})
+		})
+
+		It("is read from a document", func() {
+			ops := []PatchOperation{
+				{Op: PatchReplace, Path: "/jobs/name:web/instances", Value: parseYAML("2")},
+				{Op: PatchRemove, Path: "/list/1"},
+			}
+			parsed, err := ParsePatch(PatchAsNode(ops))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal(ops))
+		})
+
+		It("supports all operations", func() {
+			patch := parseYAML(`
+---
+- op: add
+  path: /jobs/-
+  value:
+    name: worker
+- op: copy
+  from: /props/alice
+  path: /props/peter
+- op: move
+  from: /list/0
+  path: /list/1
+- op: test
+  path: /props/peter
+  value: 1
+`)
+			ops, err := ParsePatch(patch)
+			Expect(err).NotTo(HaveOccurred())
+			patched, err := ApplyPatch(a, ops)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(Compare(patched, parseYAML(`
+---
+jobs:
+- name: web
+  instances: 1
+- name: db
+  instances: 1
+- name: worker
+list: [ 2, 1, 3 ]
+props:
+  alice: 1
+  peter: 1
+  b/c: 2
+`))).To(BeEmpty())
+		})
+
+		It("reports failing operations", func() {
+			_, err := ApplyPatch(a, []PatchOperation{
+				{Op: PatchTest, Path: "/props/alice", Value: parseYAML("1")},
+				{Op: PatchRemove, Path: "/jobs/name:worker"},
+			})
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("operation 2 (remove /jobs/name:worker): 'name:worker' not found"))
+		})
+
+		It("rejects invalid operations", func() {
+			_, err := ParsePatch(parseYAML(`[ { op: delete, path: /props } ]`))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(Equal("operation 1: invalid op 'delete'"))
+		})
+	})
+
+	Describe("JSON Merge Patch", func() {
+		It("replaces lists as a whole", func() {
+			Expect(MergePatch(b, Compare(a, b))).To(Equal(yaml.NewNode(map[string]yaml.Node{
+				"jobs": b.Value().(map[string]yaml.Node)["jobs"],
+				"list": b.Value().(map[string]yaml.Node)["list"],
+				"props": yaml.NewNode(map[string]yaml.Node{
+					"bob": parseYAML("3"),
+					"b/c": yaml.NewNode(nil, "patch"),
+				}, "patch"),
+			}, "patch")))
+		})
+
+		It("transforms the first document into the second one", func() {
+			Expect(Compare(ApplyMergePatch(a, MergePatch(b, Compare(a, b))), b)).To(BeEmpty())
+		})
+	})
+})
+
+// entryNames provides the names of the entries of the field list.
+func entryNames(doc yaml.Node) []string {
+	names := []string{}
+	for _, e := range doc.Value().(map[string]yaml.Node)["list"].Value().([]yaml.Node) {
+		names = append(names, e.Value().(map[string]yaml.Node)["name"].Value().(string))
+	}
+	return names
+}
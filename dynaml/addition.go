@@ -18,7 +18,7 @@ func (e AdditionExpr) Evaluate(binding Binding, locally bool) (interface{}, Eval
 		return nil, info, false
 	}
 
-	bint, info, ok := ResolveIntegerExpressionOrPushEvaluation(&e.B, &resolved, &info, binding, false)
+	b, info, ok := ResolveExpressionOrPushEvaluation(&e.B, &resolved, &info, binding, false)
 	if !ok {
 		return nil, info, false
 	}
@@ -27,6 +27,19 @@ func (e AdditionExpr) Evaluate(binding Binding, locally bool) (interface{}, Eval
 		return e, info, true
 	}
 
+	fa, fb, float, ok := floatOperands(a, b)
+	if float {
+		if !ok {
+			return info.Error("numeric operands required for PLUS")
+		}
+		return fa + fb, info, true
+	}
+
+	bint, ok := b.(int64)
+	if !ok {
+		return info.Error("integer operand required")
+	}
+
 	aint, ok := a.(int64)
 	if ok {
 		return aint + bint, info, true
@@ -40,7 +53,7 @@ func (e AdditionExpr) Evaluate(binding Binding, locally bool) (interface{}, Eval
 		}
 		return info.Error("string argument for PLUS must be an IP address")
 	}
-	return info.Error("first argument of PLUS must be IP address or integer")
+	return info.Error("first argument of PLUS must be IP address or number")
 }
 
 func (e AdditionExpr) String() string {
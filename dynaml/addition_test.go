@@ -92,4 +92,15 @@ var _ = Describe("addition", func() {
 			Expect(expr).To(EvaluateAs("10.9.9.255", FakeBinding{}))
 		})
 	})
+
+	Context("when one side is a float", func() {
+		It("adds both numbers as floats", func() {
+			expr := AdditionExpr{
+				FloatExpr{2.5},
+				IntegerExpr{3},
+			}
+
+			Expect(expr).To(EvaluateAs(5.5, FakeBinding{}))
+		})
+	})
 })
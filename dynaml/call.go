@@ -3,8 +3,6 @@ package dynaml
 import (
 	"fmt"
 	"strings"
-
-	"github.com/cloudfoundry-incubator/spiff/debug"
 )
 
 type CallExpr struct {
@@ -26,32 +24,32 @@ func (e CallExpr) Evaluate(binding Binding, locally bool) (interface{}, Evaluati
 		if okf && resolved {
 			_, okf = value.(LambdaValue)
 			if !okf {
-				debug.Debug("function: no string or lambda value: %T\n", value)
+				binding.GetState().Debug("function: no string or lambda value: %T\n", value)
 				return info.Error("function call '%s' requires function name or lambda value", e.Function)
 			}
 		}
 	}
 
-	switch funcName {
-	case "defined":
-		return e.defined(binding)
-	case "require":
-		return e.require(binding)
-	case "valid":
-		return e.valid(binding)
-	case "stub":
-		return e.stub(binding)
+	var function *FunctionDefinition
+	if funcName != "" {
+		if !binding.GetState().FunctionAllowed(funcName) {
+			return info.Error("function '%s' not allowed", funcName)
+		}
+		function = binding.GetState().GetFunctions().LookupFunction(funcName)
+		if function != nil && function.unevaluated {
+			return function.call(e, nil, binding, locally)
+		}
 	}
 
 	values, info, ok := ResolveExpressionListOrPushEvaluation(&e.Arguments, &resolved, nil, binding, false)
 
 	if !okf {
-		debug.Debug("failed to resolve function: %s\n", info.Issue)
+		binding.GetState().Debug("failed to resolve function: %s\n", info.Issue)
 		return nil, info, false
 	}
 
 	if !ok {
-		debug.Debug("call args failed\n")
+		binding.GetState().Debug("call args failed\n")
 		return nil, info, false
 	}
 
@@ -62,91 +60,14 @@ func (e CallExpr) Evaluate(binding Binding, locally bool) (interface{}, Evaluati
 	var result interface{}
 	var sub EvaluationInfo
 
-	switch funcName {
-	case "":
-		debug.Debug("calling lambda function %#v\n", value)
+	if funcName == "" {
+		binding.GetState().Debug("calling lambda function %#v\n", value)
 		result, sub, ok = value.(LambdaValue).Evaluate(values, binding, false)
-
-	case "static_ips":
-		result, sub, ok = func_static_ips(e.Arguments, binding)
-
-	case "join":
-		result, sub, ok = func_join(values, binding)
-
-	case "split":
-		result, sub, ok = func_split(values, binding)
-
-	case "trim":
-		result, sub, ok = func_trim(values, binding)
-
-	case "length":
-		result, sub, ok = func_length(values, binding)
-
-	case "uniq":
-		result, sub, ok = func_uniq(values, binding)
-
-	case "element":
-		result, sub, ok = func_element(values, binding)
-
-	case "compact":
-		result, sub, ok = func_compact(values, binding)
-
-	case "contains":
-		result, sub, ok = func_contains(values, binding)
-
-	case "index":
-		result, sub, ok = func_index(values, binding)
-
-	case "lastindex":
-		result, sub, ok = func_lastindex(values, binding)
-
-	case "replace":
-		result, sub, ok = func_replace(values, binding)
-
-	case "match":
-		result, sub, ok = func_match(values, binding)
-
-	case "exec":
-		result, sub, ok = func_exec(values, binding)
-
-	case "eval":
-		result, sub, ok = func_eval(values, binding, locally)
-
-	case "env":
-		result, sub, ok = func_env(values, binding)
-
-	case "read":
-		result, sub, ok = func_read(values, binding)
-
-	case "format":
-		result, sub, ok = func_format(values, binding)
-
-	case "error":
-		result, sub, ok = func_error(values, binding)
-
-	case "min_ip":
-		result, sub, ok = func_minIP(values, binding)
-
-	case "max_ip":
-		result, sub, ok = func_maxIP(values, binding)
-
-	case "num_ip":
-		result, sub, ok = func_numIP(values, binding)
-
-	case "makemap":
-		result, sub, ok = func_makemap(values, binding)
-
-	case "list_to_map":
-		result, sub, ok = func_list_to_map(e.Arguments[0], values, binding)
-
-	case "ipset":
-		result, sub, ok = func_ipset(values, binding)
-
-	case "merge":
-		result, sub, ok = func_merge(values, binding)
-
-	default:
-		return info.Error("unknown function '%s'", funcName)
+	} else {
+		if function == nil {
+			return info.Error("unknown function '%s'", funcName)
+		}
+		result, sub, ok = function.call(e, values, binding, locally)
 	}
 
 	if ok && (result == nil || isExpression(result)) {
@@ -163,3 +84,75 @@ func (e CallExpr) String() string {
 
 	return fmt.Sprintf("%s(%s)", e.Function, strings.Join(args, ", "))
 }
+
+func init() {
+	registerExpressionBuiltin("defined", func(e CallExpr, _ []interface{}, binding Binding, _ bool) (interface{}, EvaluationInfo, bool) {
+		return e.defined(binding)
+	}, true)
+	registerExpressionBuiltin("require", func(e CallExpr, _ []interface{}, binding Binding, _ bool) (interface{}, EvaluationInfo, bool) {
+		return e.require(binding)
+	}, true)
+	registerExpressionBuiltin("valid", func(e CallExpr, _ []interface{}, binding Binding, _ bool) (interface{}, EvaluationInfo, bool) {
+		return e.valid(binding)
+	}, true)
+	registerExpressionBuiltin("stub", func(e CallExpr, _ []interface{}, binding Binding, _ bool) (interface{}, EvaluationInfo, bool) {
+		return e.stub(binding)
+	}, true)
+
+	registerExpressionBuiltin("static_ips", func(e CallExpr, _ []interface{}, binding Binding, _ bool) (interface{}, EvaluationInfo, bool) {
+		return func_static_ips(e.Arguments, binding)
+	}, false)
+	registerExpressionBuiltin("list_to_map", func(e CallExpr, values []interface{}, binding Binding, _ bool) (interface{}, EvaluationInfo, bool) {
+		return func_list_to_map(e.Arguments[0], values, binding)
+	}, false)
+	registerExpressionBuiltin("eval", func(e CallExpr, values []interface{}, binding Binding, locally bool) (interface{}, EvaluationInfo, bool) {
+		return func_eval(values, binding, locally)
+	}, false)
+
+	registerBuiltin("join", func_join)
+	registerBuiltin("split", func_split)
+	registerBuiltin("trim", func_trim)
+	registerBuiltin("length", func_length)
+	registerBuiltin("uniq", func_uniq)
+	registerBuiltin("element", func_element)
+	registerBuiltin("compact", func_compact)
+	registerBuiltin("contains", func_contains)
+	registerBuiltin("index", func_index)
+	registerBuiltin("lastindex", func_lastindex)
+	registerBuiltin("replace", func_replace)
+	registerBuiltin("match", func_match)
+	registerBuiltin("exec", func_exec)
+	registerBuiltin("env", func_env)
+	registerBuiltin("read", func_read)
+	registerBuiltin("format", func_format)
+	registerBuiltin("error", func_error)
+	registerBuiltin("min_ip", func_minIP)
+	registerBuiltin("max_ip", func_maxIP)
+	registerBuiltin("num_ip", func_numIP)
+	registerBuiltin("makemap", func_makemap)
+	registerBuiltin("ipset", func_ipset)
+	registerBuiltin("merge", func_merge)
+	registerBuiltin("integer", func_integer)
+	registerBuiltin("float", func_float)
+	registerBuiltin("string", func_string)
+	registerBuiltin("bool", func_bool)
+	registerBuiltin("validate", func_validate)
+	registerBuiltin("type", func_type)
+	registerBuiltin("sort", func_sort)
+	registerBuiltin("reverse", func_reverse)
+	registerBuiltin("keys", func_keys)
+	registerBuiltin("values", func_values)
+	registerBuiltin("flatten", func_flatten)
+	for name, t := range map[string]ArgumentType{
+		"is_string":   TypeString,
+		"is_integer":  TypeInt,
+		"is_float":    TypeFloat,
+		"is_bool":     TypeBool,
+		"is_list":     TypeList,
+		"is_map":      TypeMap,
+		"is_lambda":   TypeLambda,
+		"is_template": TypeTemplate,
+	} {
+		registerBuiltin(name, typePredicate(name, t))
+	}
+}
@@ -163,7 +163,7 @@ var _ = Describe("calls", func() {
 			Expect(expr).To(
 				EvaluateAs(
 					"",
-					nil,
+					FakeBinding{},
 				),
 			)
 		})
@@ -174,7 +174,7 @@ var _ = Describe("calls", func() {
 				Arguments: []Expression{},
 			}
 
-			Expect(expr).To(FailToEvaluate(nil))
+			Expect(expr).To(FailToEvaluate(FakeBinding{}))
 		})
 
 		It("fails for wrong separator type", func() {
@@ -185,7 +185,7 @@ var _ = Describe("calls", func() {
 				},
 			}
 
-			Expect(expr).To(FailToEvaluate(nil))
+			Expect(expr).To(FailToEvaluate(FakeBinding{}))
 		})
 	})
 
@@ -286,4 +286,177 @@ var _ = Describe("calls", func() {
 			})
 		})
 	})
+
+	Describe("integer(value)", func() {
+		call := func(arg Expression) CallExpr {
+			return CallExpr{
+				Function:  ReferenceExpr{[]string{"integer"}},
+				Arguments: []Expression{arg},
+			}
+		}
+
+		It("converts floats, strings and booleans", func() {
+			Expect(call(FloatExpr{2.7})).To(EvaluateAs(2, FakeBinding{}))
+			Expect(call(StringExpr{"42"})).To(EvaluateAs(42, FakeBinding{}))
+			Expect(call(StringExpr{"4.2"})).To(EvaluateAs(4, FakeBinding{}))
+			Expect(call(BooleanExpr{true})).To(EvaluateAs(1, FakeBinding{}))
+		})
+
+		It("fails for invalid values", func() {
+			Expect(call(StringExpr{"foo"})).To(FailToEvaluate(FakeBinding{}))
+		})
+	})
+
+	Describe("float(value)", func() {
+		call := func(arg Expression) CallExpr {
+			return CallExpr{
+				Function:  ReferenceExpr{[]string{"float"}},
+				Arguments: []Expression{arg},
+			}
+		}
+
+		It("converts integers and strings", func() {
+			Expect(call(IntegerExpr{2})).To(EvaluateAs(2.0, FakeBinding{}))
+			Expect(call(StringExpr{"1.5"})).To(EvaluateAs(1.5, FakeBinding{}))
+		})
+
+		It("fails for invalid values", func() {
+			Expect(call(StringExpr{"foo"})).To(FailToEvaluate(FakeBinding{}))
+			Expect(call(BooleanExpr{true})).To(FailToEvaluate(FakeBinding{}))
+		})
+	})
+
+	Describe("type(value)", func() {
+		binding := FakeBinding{
+			FoundReferences: map[string]yaml.Node{
+				"list":     parseYAML("[ 1 ]"),
+				"map":      parseYAML("a: 1"),
+				"lambda":   node(LambdaValue{}, nil),
+				"template": node(TemplateValue{}, nil),
+			},
+		}
+
+		call := func(function string, arg Expression) CallExpr {
+			return CallExpr{
+				Function:  ReferenceExpr{[]string{function}},
+				Arguments: []Expression{arg},
+			}
+		}
+		ref := func(name string) Expression {
+			return ReferenceExpr{[]string{name}}
+		}
+
+		It("provides the type name", func() {
+			Expect(call("type", IntegerExpr{1})).To(EvaluateAs("int", binding))
+			Expect(call("type", FloatExpr{1.5})).To(EvaluateAs("float", binding))
+			Expect(call("type", StringExpr{"a"})).To(EvaluateAs("string", binding))
+			Expect(call("type", BooleanExpr{true})).To(EvaluateAs("bool", binding))
+			Expect(call("type", ref("list"))).To(EvaluateAs("list", binding))
+			Expect(call("type", ref("map"))).To(EvaluateAs("map", binding))
+			Expect(call("type", ref("lambda"))).To(EvaluateAs("lambda", binding))
+			Expect(call("type", ref("template"))).To(EvaluateAs("template", binding))
+		})
+
+		It("checks the type", func() {
+			Expect(call("is_map", ref("map"))).To(EvaluateAs(true, binding))
+			Expect(call("is_map", ref("list"))).To(EvaluateAs(false, binding))
+			Expect(call("is_list", ref("list"))).To(EvaluateAs(true, binding))
+			Expect(call("is_string", StringExpr{"a"})).To(EvaluateAs(true, binding))
+			Expect(call("is_string", IntegerExpr{1})).To(EvaluateAs(false, binding))
+			Expect(call("is_integer", IntegerExpr{1})).To(EvaluateAs(true, binding))
+			Expect(call("is_integer", FloatExpr{1})).To(EvaluateAs(false, binding))
+			Expect(call("is_lambda", ref("lambda"))).To(EvaluateAs(true, binding))
+			Expect(call("is_template", ref("template"))).To(EvaluateAs(true, binding))
+			Expect(call("is_template", ref("map"))).To(EvaluateAs(false, binding))
+		})
+
+		It("converts values to strings", func() {
+			Expect(call("string", IntegerExpr{42})).To(EvaluateAs("42", binding))
+			Expect(call("string", FloatExpr{1.5})).To(EvaluateAs("1.5", binding))
+			Expect(call("string", BooleanExpr{false})).To(EvaluateAs("false", binding))
+			Expect(call("string", ref("list"))).To(FailToEvaluate(binding))
+		})
+
+		It("converts values to booleans", func() {
+			Expect(call("bool", StringExpr{"true"})).To(EvaluateAs(true, binding))
+			Expect(call("bool", IntegerExpr{0})).To(EvaluateAs(false, binding))
+			Expect(call("bool", FloatExpr{0.5})).To(EvaluateAs(true, binding))
+			Expect(call("bool", StringExpr{"maybe"})).To(FailToEvaluate(binding))
+			Expect(call("bool", ref("map"))).To(FailToEvaluate(binding))
+		})
+	})
+
+	Describe("list functions", func() {
+		binding := FakeBinding{
+			FoundReferences: map[string]yaml.Node{
+				"numbers": parseYAML("[ 3, 1.5, 2 ]"),
+				"strings": parseYAML("[ b, c, a ]"),
+				"mixed":   parseYAML("[ 1, a ]"),
+				"nested":  parseYAML("[ 1, [ 2, [ 3 ] ], [] ]"),
+				"map":     parseYAML("{ b: 2, a: 1 }"),
+			},
+		}
+
+		call := func(function string, args ...Expression) CallExpr {
+			return CallExpr{
+				Function:  ReferenceExpr{[]string{function}},
+				Arguments: args,
+			}
+		}
+		ref := func(name string) Expression {
+			return ReferenceExpr{[]string{name}}
+		}
+
+		It("sorts numbers and strings", func() {
+			Expect(call("sort", ref("numbers"))).To(EvaluateAs([]yaml.Node{node(1.5, nil), node(2, nil), node(3, nil)}, binding))
+			Expect(call("sort", ref("strings"))).To(EvaluateAs([]yaml.Node{node("a", nil), node("b", nil), node("c", nil)}, binding))
+			Expect(call("sort", ref("mixed"))).To(FailToEvaluate(binding))
+			Expect(call("sort", ref("map"))).To(FailToEvaluate(binding))
+		})
+
+		It("reverses lists", func() {
+			Expect(call("reverse", ref("strings"))).To(EvaluateAs([]yaml.Node{node("a", nil), node("c", nil), node("b", nil)}, binding))
+		})
+
+		It("provides the keys and values of maps", func() {
+			Expect(call("keys", ref("map"))).To(EvaluateAs([]yaml.Node{node("a", nil), node("b", nil)}, binding))
+			Expect(call("values", ref("map"))).To(EvaluateAs([]yaml.Node{node(1, nil), node(2, nil)}, binding))
+			Expect(call("keys", ref("strings"))).To(FailToEvaluate(binding))
+		})
+
+		It("flattens nested lists", func() {
+			Expect(call("flatten", ref("nested"))).To(EvaluateAs([]yaml.Node{node(1, nil), node(2, nil), node(3, nil)}, binding))
+		})
+	})
+
+	Describe("validate(value, schema)", func() {
+		binding := FakeBinding{
+			FoundReferences: map[string]yaml.Node{
+				"schema": parseYAML(`
+type: integer
+minimum: 1
+`),
+			},
+		}
+
+		call := func(arg Expression) CallExpr {
+			return CallExpr{
+				Function:  ReferenceExpr{[]string{"validate"}},
+				Arguments: []Expression{arg, ReferenceExpr{[]string{"schema"}}},
+			}
+		}
+
+		It("provides valid values", func() {
+			Expect(call(IntegerExpr{2})).To(EvaluateAs(2, binding))
+		})
+
+		It("fails for schema violations", func() {
+			_, info, ok := call(IntegerExpr{0}).Evaluate(binding, false)
+			Expect(ok).To(BeFalse())
+			Expect(info.Issue.Issue).To(Equal("schema validation failed"))
+			Expect(info.Issue.Nested[0].Issue).To(Equal("<root>: value 0 less than minimum 1"))
+
+			Expect(call(StringExpr{"foo"})).To(FailToEvaluate(binding))
+		})
+	})
 })
@@ -5,7 +5,6 @@ import (
 	"reflect"
 	"strconv"
 
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
@@ -37,9 +36,9 @@ func (e ComparisonExpr) Evaluate(binding Binding, locally bool) (interface{}, Ev
 
 	switch e.Op {
 	case "==":
-		result, infor, ok = compareEquals(a, b)
+		result, infor, ok = compareEquals(a, b, binding)
 	case "!=":
-		result, infor, ok = compareEquals(a, b)
+		result, infor, ok = compareEquals(a, b, binding)
 		result = !result
 	case "<=":
 		fallthrough
@@ -48,14 +47,31 @@ func (e ComparisonExpr) Evaluate(binding Binding, locally bool) (interface{}, Ev
 	case ">":
 		fallthrough
 	case ">=":
+		fa, fb, float, okf := floatOperands(a, b)
+		if float {
+			if !okf {
+				return infor.Error("comparision %s only for numbers", e.Op)
+			}
+			switch e.Op {
+			case "<=":
+				result = fa <= fb
+			case "<":
+				result = fa < fb
+			case ">":
+				result = fa > fb
+			case ">=":
+				result = fa >= fb
+			}
+			break
+		}
 		var va, vb int64
 		va, ok = a.(int64)
 		if !ok {
-			return infor.Error("comparision %s only for integers", e.Op)
+			return infor.Error("comparision %s only for numbers", e.Op)
 		}
 		vb, ok = b.(int64)
 		if !ok {
-			return infor.Error("comparision %s only for integers", e.Op)
+			return infor.Error("comparision %s only for numbers", e.Op)
 		}
 		switch e.Op {
 		case "<=":
@@ -80,11 +96,11 @@ func (e ComparisonExpr) String() string {
 	return fmt.Sprintf("%s %s %s", e.A, e.Op, e.B)
 }
 
-func compareEquals(a, b interface{}) (bool, EvaluationInfo, bool) {
+func compareEquals(a, b interface{}, binding Binding) (bool, EvaluationInfo, bool) {
 	info := DefaultInfo()
 
-	debug.Debug("compare a '%#v'\n", a)
-	debug.Debug("compare b '%#v' \n", b)
+	binding.GetState().Debug("compare a '%#v'\n", a)
+	binding.GetState().Debug("compare b '%#v' \n", b)
 	switch va := a.(type) {
 	case string:
 		var vb string
@@ -93,6 +109,8 @@ func compareEquals(a, b interface{}) (bool, EvaluationInfo, bool) {
 			vb = v
 		case int64:
 			vb = strconv.FormatInt(v, 10)
+		case float64:
+			vb = strconv.FormatFloat(v, 'f', -1, 64)
 		case LambdaValue:
 			vb = v.String()
 		case bool:
@@ -103,10 +121,31 @@ func compareEquals(a, b interface{}) (bool, EvaluationInfo, bool) {
 		}
 		return va == vb, info, true
 
+	case float64:
+		var vb float64
+		var err error
+		switch v := b.(type) {
+		case string:
+			vb, err = strconv.ParseFloat(v, 64)
+			if err != nil {
+				return false, info, true
+			}
+		case int64:
+			vb = float64(v)
+		case float64:
+			vb = v
+		default:
+			info.Issue = yaml.NewIssue("types uncomparable")
+			return false, info, false
+		}
+		return va == vb, info, true
+
 	case int64:
 		var vb int64
 		var err error
 		switch v := b.(type) {
+		case float64:
+			return float64(va) == v, info, true
 		case string:
 			vb, err = strconv.ParseInt(v, 10, 64)
 			if err != nil {
@@ -140,13 +179,13 @@ func compareEquals(a, b interface{}) (bool, EvaluationInfo, bool) {
 	case []yaml.Node:
 		vb, ok := b.([]yaml.Node)
 		if !ok || len(va) != len(vb) {
-			debug.Debug("compare list len mismatch")
+			binding.GetState().Debug("compare list len mismatch\n")
 			break
 		}
 		for i, v := range vb {
-			result, info, _ := compareEquals(va[i].Value(), v.Value())
+			result, info, _ := compareEquals(va[i].Value(), v.Value(), binding)
 			if !result {
-				debug.Debug(fmt.Sprintf("compare list entry %d mismatch", i))
+				binding.GetState().Debug("compare list entry %d mismatch\n", i)
 				return false, info, true
 			}
 		}
@@ -159,7 +198,7 @@ func compareEquals(a, b interface{}) (bool, EvaluationInfo, bool) {
 		}
 
 		for k, v := range vb {
-			result, info, _ := compareEquals(va[k].Value(), v.Value())
+			result, info, _ := compareEquals(va[k].Value(), v.Value(), binding)
 			if !result {
 				return false, info, true
 			}
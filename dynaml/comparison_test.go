@@ -54,4 +54,18 @@ var _ = Describe("comparison operators", func() {
 			Expect(expr).To(FailToEvaluate(FakeBinding{}))
 		})
 	})
+
+	Context("when comparing floats", func() {
+		It("compares floats and integers", func() {
+			Expect(ComparisonExpr{FloatExpr{1.5}, "<", IntegerExpr{2}}).To(EvaluateAs(true, FakeBinding{}))
+			Expect(ComparisonExpr{IntegerExpr{2}, "<=", FloatExpr{1.5}}).To(EvaluateAs(false, FakeBinding{}))
+			Expect(ComparisonExpr{FloatExpr{2.0}, "==", IntegerExpr{2}}).To(EvaluateAs(true, FakeBinding{}))
+			Expect(ComparisonExpr{IntegerExpr{2}, "!=", FloatExpr{2.5}}).To(EvaluateAs(true, FakeBinding{}))
+			Expect(ComparisonExpr{StringExpr{"1.5"}, "==", FloatExpr{1.5}}).To(EvaluateAs(true, FakeBinding{}))
+		})
+
+		It("fails for non numeric operands", func() {
+			Expect(ComparisonExpr{FloatExpr{1.5}, "<", StringExpr{"foo"}}).To(FailToEvaluate(FakeBinding{}))
+		})
+	})
 })
@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"strconv"
 
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
@@ -16,30 +15,30 @@ type ConcatenationExpr struct {
 func (e ConcatenationExpr) Evaluate(binding Binding, locally bool) (interface{}, EvaluationInfo, bool) {
 	resolved := true
 
-	debug.Debug("CONCAT %+v,%+v\n", e.A, e.B)
+	binding.GetState().Debug("CONCAT %+v,%+v\n", e.A, e.B)
 
 	a, infoa, ok := ResolveExpressionOrPushEvaluation(&e.A, &resolved, nil, binding, false)
 	if !ok {
-		debug.Debug("  eval a failed\n")
+		binding.GetState().Debug("  eval a failed\n")
 		return nil, infoa, false
 	}
 
 	b, info, ok := ResolveExpressionOrPushEvaluation(&e.B, &resolved, &infoa, binding, false)
 	if !ok {
-		debug.Debug("  eval b failed\n")
+		binding.GetState().Debug("  eval b failed\n")
 		return nil, info, false
 	}
 
 	if !resolved {
-		debug.Debug("  still unresolved operands\n")
+		binding.GetState().Debug("  still unresolved operands\n")
 		return e, info, true
 	}
 
-	debug.Debug("CONCAT resolved %+v,%+v\n", a, b)
+	binding.GetState().Debug("CONCAT resolved %+v,%+v\n", a, b)
 
 	val, ok := concatenateString(a, b)
 	if ok {
-		debug.Debug("CONCAT --> string %+v\n", val)
+		binding.GetState().Debug("CONCAT --> string %+v\n", val)
 		return val, info, true
 	}
 
@@ -84,6 +83,8 @@ func concatenateString(a interface{}, b interface{}) (string, bool) {
 		aString = v
 	case int64:
 		aString = strconv.FormatInt(v, 10)
+	case float64:
+		aString = strconv.FormatFloat(v, 'f', -1, 64)
 	case bool:
 		aString = strconv.FormatBool(v)
 	default:
@@ -95,6 +96,8 @@ func concatenateString(a interface{}, b interface{}) (string, bool) {
 		return aString + v, true
 	case int64:
 		return aString + strconv.FormatInt(v, 10), true
+	case float64:
+		return aString + strconv.FormatFloat(v, 'f', -1, 64), true
 	case bool:
 		return aString + strconv.FormatBool(v), true
 	case LambdaValue:
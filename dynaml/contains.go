@@ -22,7 +22,7 @@ func func_contains(arguments []interface{}, binding Binding) (interface{}, Evalu
 		elem := arguments[1]
 
 		for _, v := range val {
-			r, _, _ := compareEquals(v.Value(), elem)
+			r, _, _ := compareEquals(v.Value(), elem, binding)
 			if r {
 				return true, info, true
 			}
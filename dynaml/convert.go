@@ -0,0 +1,108 @@
+package dynaml
+
+import (
+	"strconv"
+)
+
+func func_integer(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("integer takes exactly one argument")
+	}
+
+	switch v := arguments[0].(type) {
+	case int64:
+		return v, info, true
+	case float64:
+		return int64(v), info, true
+	case bool:
+		if v {
+			return int64(1), info, true
+		}
+		return int64(0), info, true
+	case string:
+		i, err := strconv.ParseInt(v, 10, 64)
+		if err == nil {
+			return i, info, true
+		}
+		f, err := strconv.ParseFloat(v, 64)
+		if err == nil {
+			return int64(f), info, true
+		}
+		return info.Error("'%s' is no integer value", v)
+	default:
+		return info.Error("%s value cannot be converted to integer", TypeName(v))
+	}
+}
+
+func func_float(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("float takes exactly one argument")
+	}
+
+	switch v := arguments[0].(type) {
+	case int64:
+		return float64(v), info, true
+	case float64:
+		return v, info, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return info.Error("'%s' is no float value", v)
+		}
+		return f, info, true
+	default:
+		return info.Error("%s value cannot be converted to float", TypeName(v))
+	}
+}
+
+func func_string(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("string takes exactly one argument")
+	}
+
+	switch v := arguments[0].(type) {
+	case string:
+		return v, info, true
+	case int64:
+		return strconv.FormatInt(v, 10), info, true
+	case float64:
+		return FormatFloat(v), info, true
+	case bool:
+		return strconv.FormatBool(v), info, true
+	case LambdaValue:
+		return v.String(), info, true
+	default:
+		return info.Error("%s value cannot be converted to string", TypeName(v))
+	}
+}
+
+func func_bool(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("bool takes exactly one argument")
+	}
+
+	switch v := arguments[0].(type) {
+	case bool:
+		return v, info, true
+	case int64:
+		return v != 0, info, true
+	case float64:
+		return v != 0, info, true
+	case string:
+		b, err := strconv.ParseBool(v)
+		if err != nil {
+			return info.Error("'%s' is no boolean value", v)
+		}
+		return b, info, true
+	default:
+		return info.Error("%s value cannot be converted to bool", TypeName(v))
+	}
+}
@@ -0,0 +1,159 @@
+package dynaml
+
+// dynamicFunctions access nodes not given as arguments.
+var dynamicFunctions = map[string]bool{
+	"eval":       true,
+	"static_ips": true,
+}
+
+// References determines the references an expression uses to access
+// other nodes. The second result reports whether the set of references
+// is known statically. It is false for expressions whose accessed nodes
+// depend on the evaluation itself, like dynamic references, eval,
+// templates or lambda values stored in other nodes.
+func References(e Expression) ([][]string, bool) {
+	r := &references{}
+	r.add(e, nil)
+	if r.dynamic {
+		return nil, false
+	}
+	return r.refs, true
+}
+
+type references struct {
+	refs    [][]string
+	dynamic bool
+}
+
+func (r *references) add(e Expression, locals map[string]bool) {
+	if r.dynamic || e == nil {
+		return
+	}
+	switch v := e.(type) {
+	case NilExpr, UndefinedExpr, BooleanExpr, IntegerExpr, FloatExpr, StringExpr, MergeExpr:
+
+	case ReferenceExpr:
+		r.reference(v.Path, locals)
+
+	case AdditionExpr:
+		r.add(v.A, locals)
+		r.add(v.B, locals)
+	case SubtractionExpr:
+		r.add(v.A, locals)
+		r.add(v.B, locals)
+	case MultiplicationExpr:
+		r.add(v.A, locals)
+		r.add(v.B, locals)
+	case DivisionExpr:
+		r.add(v.A, locals)
+		r.add(v.B, locals)
+	case ModuloExpr:
+		r.add(v.A, locals)
+		r.add(v.B, locals)
+	case ConcatenationExpr:
+		r.add(v.A, locals)
+		r.add(v.B, locals)
+	case ComparisonExpr:
+		r.add(v.A, locals)
+		r.add(v.B, locals)
+	case LogAndExpr:
+		r.add(v.A, locals)
+		r.add(v.B, locals)
+	case LogOrExpr:
+		r.add(v.A, locals)
+		r.add(v.B, locals)
+	case OrExpr:
+		r.add(v.A, locals)
+		r.add(v.B, locals)
+	case NotExpr:
+		r.add(v.Expr, locals)
+	case CondExpr:
+		r.add(v.C, locals)
+		r.add(v.T, locals)
+		r.add(v.F, locals)
+	case PreferExpr:
+		r.add(v.expression, locals)
+	case MarkerExpr:
+		if v.Has(TEMPLATE) {
+			r.dynamic = true
+			return
+		}
+		r.add(v.expr, locals)
+
+	case QualifiedExpr:
+		// the qualifying reference is resolved relative to the value
+		r.add(v.Expression, locals)
+	case SliceExpr:
+		r.add(v.Expression, locals)
+		r.add(v.Range, locals)
+	case RangeExpr:
+		r.add(v.Start, locals)
+		r.add(v.End, locals)
+	case ListExpr:
+		for _, c := range v.Contents {
+			r.add(c, locals)
+		}
+	case CreateMapExpr:
+		for _, a := range v.Assignments {
+			r.add(a.Key, locals)
+			r.add(a.Value, locals)
+		}
+
+	case CallExpr:
+		ref, ok := v.Function.(ReferenceExpr)
+		if !ok || len(ref.Path) != 1 || ref.Path[0] == "" || ref.Path[0] == "_" || dynamicFunctions[ref.Path[0]] {
+			r.dynamic = true
+			return
+		}
+		for _, a := range v.Arguments {
+			r.add(a, locals)
+		}
+
+	case LambdaExpr:
+		scope := map[string]bool{}
+		for n := range locals {
+			scope[n] = true
+		}
+		for _, n := range v.Names {
+			scope[n] = true
+		}
+		r.add(v.E, scope)
+	case MapExpr:
+		r.add(v.A, locals)
+		r.lambda(v.Lambda, locals)
+	case MapMapExpr:
+		r.add(v.A, locals)
+		r.lambda(v.Lambda, locals)
+	case SelectExpr:
+		r.add(v.A, locals)
+		r.lambda(v.Lambda, locals)
+	case SelectMapExpr:
+		r.add(v.A, locals)
+		r.lambda(v.Lambda, locals)
+	case SumExpr:
+		r.add(v.A, locals)
+		r.add(v.I, locals)
+		r.lambda(v.Lambda, locals)
+
+	default:
+		r.dynamic = true
+	}
+}
+
+func (r *references) lambda(e Expression, locals map[string]bool) {
+	if _, ok := e.(LambdaExpr); !ok {
+		r.dynamic = true
+		return
+	}
+	r.add(e, locals)
+}
+
+func (r *references) reference(path []string, locals map[string]bool) {
+	switch {
+	case path[0] == "__ctx" || locals[path[0]]:
+	case path[0] == "_":
+		r.dynamic = true
+	default:
+		r.refs = append(r.refs, path)
+	}
+}
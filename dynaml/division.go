@@ -23,7 +23,7 @@ func (e DivisionExpr) Evaluate(binding Binding, locally bool) (interface{}, Eval
 		return nil, info, false
 	}
 
-	bint, info, ok := ResolveIntegerExpressionOrPushEvaluation(&e.B, &resolved, &info, binding, false)
+	b, info, ok := ResolveExpressionOrPushEvaluation(&e.B, &resolved, &info, binding, false)
 	if !ok {
 		return nil, info, false
 	}
@@ -32,6 +32,22 @@ func (e DivisionExpr) Evaluate(binding Binding, locally bool) (interface{}, Eval
 		return e, info, true
 	}
 
+	fa, fb, float, ok := floatOperands(a, b)
+	if float {
+		if !ok {
+			return info.Error("numeric operands required for DIVISION")
+		}
+		if fb == 0 {
+			return info.Error("division by zero")
+		}
+		return fa / fb, info, true
+	}
+
+	bint, ok := b.(int64)
+	if !ok {
+		return info.Error("integer operand required")
+	}
+
 	if bint == 0 {
 		return info.Error("division by zero")
 	}
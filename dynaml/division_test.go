@@ -74,4 +74,26 @@ var _ = Describe("division", func() {
 			Expect(expr).To(FailToEvaluate(FakeBinding{}))
 		})
 	})
+
+	Context("when one side is a float", func() {
+		It("divides both numbers as floats", func() {
+			expr := DivisionExpr{
+				IntegerExpr{3},
+				FloatExpr{2.0},
+			}
+
+			Expect(expr).To(EvaluateAs(1.5, FakeBinding{}))
+		})
+	})
+
+	Context("when dividing a float by zero", func() {
+		It("fails", func() {
+			expr := DivisionExpr{
+				FloatExpr{1.5},
+				FloatExpr{0.0},
+			}
+
+			Expect(expr).To(FailToEvaluate(FakeBinding{}))
+		})
+	})
 })
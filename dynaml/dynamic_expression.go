@@ -3,7 +3,6 @@ package dynaml
 import (
 	"fmt"
 
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
@@ -31,7 +30,7 @@ func (e DynamicExpr) Evaluate(binding Binding, locally bool) (interface{}, Evalu
 		return nil, info, false
 	}
 
-	debug.Debug("dynamic reference: %v\n", dyn)
+	binding.GetState().Debug("dynamic reference: %v\n", dyn)
 
 	var qual []string
 	switch v := dyn.(type) {
@@ -52,6 +52,7 @@ const (
 	ruleNot
 	ruleGrouped
 	ruleRange
+	ruleFloat
 	ruleInteger
 	ruleString
 	ruleBoolean
@@ -84,6 +85,9 @@ const (
 	ruleIP
 	rulews
 	rulereq_ws
+	ruleMapMapping
+	ruleSelection
+	ruleMapSelection
 
 	rulePre
 	ruleIn
@@ -130,6 +134,7 @@ var rul3s = [...]string{
 	"Not",
 	"Grouped",
 	"Range",
+	"Float",
 	"Integer",
 	"String",
 	"Boolean",
@@ -162,6 +167,9 @@ var rul3s = [...]string{
 	"IP",
 	"ws",
 	"req_ws",
+	"MapMapping",
+	"Selection",
+	"MapSelection",
 
 	"Pre_",
 	"_In_",
@@ -480,7 +488,7 @@ func (t *tokens32) Expand(index int) tokenTree {
 type DynamlGrammar struct {
 	Buffer string
 	buffer []rune
-	rules  [71]func() bool
+	rules  [75]func() bool
 	Parse  func(rule ...int) error
 	Reset  func()
 	Pretty bool
@@ -775,7 +783,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position14, tokenIndex14, depth14
 			return false
 		},
-		/* 4 Marker <- <('&' (('t' 'e' 'm' 'p' 'l' 'a' 't' 'e') / ('t' 'e' 'm' 'p' 'o' 'r' 'a' 'r' 'y') / ('l' 'o' 'c' 'a' 'l')))> */
+		/* 4 Marker <- <('&' (('t' 'e' 'm' 'p' 'l' 'a' 't' 'e') / ('t' 'e' 'm' 'p' 'o' 'r' 'a' 'r' 'y') / ('l' 'o' 'c' 'a' 'l') / ('d' 'e' 'l' 'e' 't' 'e')))> */
 		func() bool {
 			position16, tokenIndex16, depth16 := position, tokenIndex, depth
 			{
@@ -862,18 +870,33 @@ func (p *DynamlGrammar) Init() {
 				l20:
 					position, tokenIndex, depth = position18, tokenIndex18, depth18
 					if buffer[position] != rune('l') {
-						goto l16
+						goto l346
 					}
 					position++
 					if buffer[position] != rune('o') {
-						goto l16
+						goto l346
 					}
 					position++
 					if buffer[position] != rune('c') {
-						goto l16
+						goto l346
 					}
 					position++
 					if buffer[position] != rune('a') {
+						goto l346
+					}
+					position++
+					if buffer[position] != rune('l') {
+						goto l346
+					}
+					position++
+					goto l18
+				l346:
+					position, tokenIndex, depth = position18, tokenIndex18, depth18
+					if buffer[position] != rune('d') {
+						goto l16
+					}
+					position++
+					if buffer[position] != rune('e') {
 						goto l16
 					}
 					position++
@@ -881,6 +904,18 @@ func (p *DynamlGrammar) Init() {
 						goto l16
 					}
 					position++
+					if buffer[position] != rune('e') {
+						goto l16
+					}
+					position++
+					if buffer[position] != rune('t') {
+						goto l16
+					}
+					position++
+					if buffer[position] != rune('e') {
+						goto l16
+					}
+					position++
 				}
 			l18:
 				depth--
@@ -1537,7 +1572,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position89, tokenIndex89, depth89
 			return false
 		},
-		/* 25 Level0 <- <(IP / String / Integer / Boolean / Undefined / Nil / Not / Substitution / Merge / Auto / Lambda / Chained)> */
+		/* 25 Level0 <- <(IP / String / Float / Integer / Boolean / Undefined / Nil / Not / Substitution / Merge / Auto / Lambda / Chained)> */
 		func() bool {
 			position91, tokenIndex91, depth91 := position, tokenIndex, depth
 			{
@@ -1556,6 +1591,12 @@ func (p *DynamlGrammar) Init() {
 					}
 					goto l93
 				l95:
+					position, tokenIndex, depth = position93, tokenIndex93, depth93
+					if !_rules[ruleFloat]() {
+						goto l321
+					}
+					goto l93
+				l321:
 					position, tokenIndex, depth = position93, tokenIndex93, depth93
 					if !_rules[ruleInteger]() {
 						goto l96
@@ -1624,7 +1665,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position91, tokenIndex91, depth91
 			return false
 		},
-		/* 26 Chained <- <((Mapping / Sum / List / Map / Range / Grouped / Reference) ChainedQualifiedExpression*)> */
+		/* 26 Chained <- <((MapMapping / Mapping / MapSelection / Selection / Sum / List / Map / Range / Grouped / Reference) ChainedQualifiedExpression*)> */
 		func() bool {
 			position105, tokenIndex105, depth105 := position, tokenIndex, depth
 			{
@@ -1632,7 +1673,25 @@ func (p *DynamlGrammar) Init() {
 				depth++
 				{
 					position107, tokenIndex107, depth107 := position, tokenIndex, depth
+					if !_rules[ruleMapMapping]() {
+						goto l363
+					}
+					goto l107
+				l363:
+					position, tokenIndex, depth = position107, tokenIndex107, depth107
 					if !_rules[ruleMapping]() {
+						goto l364
+					}
+					goto l107
+				l364:
+					position, tokenIndex, depth = position107, tokenIndex107, depth107
+					if !_rules[ruleMapSelection]() {
+						goto l365
+					}
+					goto l107
+				l365:
+					position, tokenIndex, depth = position107, tokenIndex107, depth107
+					if !_rules[ruleSelection]() {
 						goto l108
 					}
 					goto l107
@@ -1989,7 +2048,147 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position145, tokenIndex145, depth145
 			return false
 		},
-		/* 38 Integer <- <('-'? [0-9] ([0-9] / '_')*)> */
+		/* 38 Float <- <('-'? [0-9] ([0-9] / '_')* '.' [0-9] ([0-9] / '_')* (('e' / 'E') ('-' / '+')? [0-9]+)?)> */
+		func() bool {
+			position322, tokenIndex322, depth322 := position, tokenIndex, depth
+			{
+				position323 := position
+				depth++
+				{
+					position324, tokenIndex324, depth324 := position, tokenIndex, depth
+					if buffer[position] != rune('-') {
+						goto l324
+					}
+					position++
+					goto l325
+				l324:
+					position, tokenIndex, depth = position324, tokenIndex324, depth324
+				}
+			l325:
+				if c := buffer[position]; c < rune('0') || c > rune('9') {
+					goto l322
+				}
+				position++
+			l326:
+				{
+					position327, tokenIndex327, depth327 := position, tokenIndex, depth
+					{
+						position328, tokenIndex328, depth328 := position, tokenIndex, depth
+						if c := buffer[position]; c < rune('0') || c > rune('9') {
+							goto l329
+						}
+						position++
+						goto l328
+					l329:
+						position, tokenIndex, depth = position328, tokenIndex328, depth328
+						if buffer[position] != rune('_') {
+							goto l327
+						}
+						position++
+					}
+				l328:
+					goto l326
+				l327:
+					position, tokenIndex, depth = position327, tokenIndex327, depth327
+				}
+				if buffer[position] != rune('.') {
+					goto l322
+				}
+				position++
+				if c := buffer[position]; c < rune('0') || c > rune('9') {
+					goto l322
+				}
+				position++
+			l330:
+				{
+					position331, tokenIndex331, depth331 := position, tokenIndex, depth
+					{
+						position332, tokenIndex332, depth332 := position, tokenIndex, depth
+						if c := buffer[position]; c < rune('0') || c > rune('9') {
+							goto l333
+						}
+						position++
+						goto l332
+					l333:
+						position, tokenIndex, depth = position332, tokenIndex332, depth332
+						if buffer[position] != rune('_') {
+							goto l331
+						}
+						position++
+					}
+				l332:
+					goto l330
+				l331:
+					position, tokenIndex, depth = position331, tokenIndex331, depth331
+				}
+				{
+					position334, tokenIndex334, depth334 := position, tokenIndex, depth
+					{
+						position336, tokenIndex336, depth336 := position, tokenIndex, depth
+						if buffer[position] != rune('e') {
+							goto l337
+						}
+						position++
+						goto l336
+					l337:
+						position, tokenIndex, depth = position336, tokenIndex336, depth336
+						if buffer[position] != rune('E') {
+							goto l334
+						}
+						position++
+					}
+				l336:
+					{
+						position338, tokenIndex338, depth338 := position, tokenIndex, depth
+						{
+							position340, tokenIndex340, depth340 := position, tokenIndex, depth
+							if buffer[position] != rune('-') {
+								goto l341
+							}
+							position++
+							goto l340
+						l341:
+							position, tokenIndex, depth = position340, tokenIndex340, depth340
+							if buffer[position] != rune('+') {
+								goto l338
+							}
+							position++
+						}
+					l340:
+						goto l339
+					l338:
+						position, tokenIndex, depth = position338, tokenIndex338, depth338
+					}
+				l339:
+					if c := buffer[position]; c < rune('0') || c > rune('9') {
+						goto l334
+					}
+					position++
+				l342:
+					{
+						position343, tokenIndex343, depth343 := position, tokenIndex, depth
+						if c := buffer[position]; c < rune('0') || c > rune('9') {
+							goto l343
+						}
+						position++
+						goto l342
+					l343:
+						position, tokenIndex, depth = position343, tokenIndex343, depth343
+					}
+					goto l335
+				l334:
+					position, tokenIndex, depth = position334, tokenIndex334, depth334
+				}
+			l335:
+				depth--
+				add(ruleFloat, position323)
+			}
+			return true
+		l322:
+			position, tokenIndex, depth = position322, tokenIndex322, depth322
+			return false
+		},
+		/* 39 Integer <- <('-'? [0-9] ([0-9] / '_')*)> */
 		func() bool {
 			position147, tokenIndex147, depth147 := position, tokenIndex, depth
 			{
@@ -2040,7 +2239,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position147, tokenIndex147, depth147
 			return false
 		},
-		/* 39 String <- <('"' (('\\' '"') / (!'"' .))* '"')> */
+		/* 40 String <- <('"' (('\\' '"') / (!'"' .))* '"')> */
 		func() bool {
 			position155, tokenIndex155, depth155 := position, tokenIndex, depth
 			{
@@ -2097,7 +2296,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position155, tokenIndex155, depth155
 			return false
 		},
-		/* 40 Boolean <- <(('t' 'r' 'u' 'e') / ('f' 'a' 'l' 's' 'e'))> */
+		/* 41 Boolean <- <(('t' 'r' 'u' 'e') / ('f' 'a' 'l' 's' 'e'))> */
 		func() bool {
 			position162, tokenIndex162, depth162 := position, tokenIndex, depth
 			{
@@ -2154,7 +2353,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position162, tokenIndex162, depth162
 			return false
 		},
-		/* 41 Nil <- <(('n' 'i' 'l') / '~')> */
+		/* 42 Nil <- <(('n' 'i' 'l') / '~')> */
 		func() bool {
 			position166, tokenIndex166, depth166 := position, tokenIndex, depth
 			{
@@ -2191,7 +2390,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position166, tokenIndex166, depth166
 			return false
 		},
-		/* 42 Undefined <- <('~' '~')> */
+		/* 43 Undefined <- <('~' '~')> */
 		func() bool {
 			position170, tokenIndex170, depth170 := position, tokenIndex, depth
 			{
@@ -2213,7 +2412,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position170, tokenIndex170, depth170
 			return false
 		},
-		/* 43 List <- <('[' Contents? ']')> */
+		/* 44 List <- <('[' Contents? ']')> */
 		func() bool {
 			position172, tokenIndex172, depth172 := position, tokenIndex, depth
 			{
@@ -2245,7 +2444,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position172, tokenIndex172, depth172
 			return false
 		},
-		/* 44 Contents <- <(Expression NextExpression*)> */
+		/* 45 Contents <- <(Expression NextExpression*)> */
 		func() bool {
 			position176, tokenIndex176, depth176 := position, tokenIndex, depth
 			{
@@ -2272,7 +2471,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position176, tokenIndex176, depth176
 			return false
 		},
-		/* 45 Map <- <(CreateMap ws Assignments? '}')> */
+		/* 46 Map <- <(CreateMap ws Assignments? '}')> */
 		func() bool {
 			position180, tokenIndex180, depth180 := position, tokenIndex, depth
 			{
@@ -2306,7 +2505,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position180, tokenIndex180, depth180
 			return false
 		},
-		/* 46 CreateMap <- <'{'> */
+		/* 47 CreateMap <- <'{'> */
 		func() bool {
 			position184, tokenIndex184, depth184 := position, tokenIndex, depth
 			{
@@ -2324,7 +2523,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position184, tokenIndex184, depth184
 			return false
 		},
-		/* 47 Assignments <- <(Assignment (',' Assignment)*)> */
+		/* 48 Assignments <- <(Assignment (',' Assignment)*)> */
 		func() bool {
 			position186, tokenIndex186, depth186 := position, tokenIndex, depth
 			{
@@ -2355,7 +2554,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position186, tokenIndex186, depth186
 			return false
 		},
-		/* 48 Assignment <- <(Expression '=' Expression)> */
+		/* 49 Assignment <- <(Expression '=' Expression)> */
 		func() bool {
 			position190, tokenIndex190, depth190 := position, tokenIndex, depth
 			{
@@ -2379,7 +2578,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position190, tokenIndex190, depth190
 			return false
 		},
-		/* 49 Merge <- <(RefMerge / SimpleMerge)> */
+		/* 50 Merge <- <(RefMerge / SimpleMerge)> */
 		func() bool {
 			position192, tokenIndex192, depth192 := position, tokenIndex, depth
 			{
@@ -2406,7 +2605,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position192, tokenIndex192, depth192
 			return false
 		},
-		/* 50 RefMerge <- <('m' 'e' 'r' 'g' 'e' !(req_ws Required) (req_ws (Replace / On))? req_ws Reference)> */
+		/* 51 RefMerge <- <('m' 'e' 'r' 'g' 'e' !(req_ws Required) (req_ws (Replace / On))? req_ws Reference)> */
 		func() bool {
 			position196, tokenIndex196, depth196 := position, tokenIndex, depth
 			{
@@ -2481,7 +2680,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position196, tokenIndex196, depth196
 			return false
 		},
-		/* 51 SimpleMerge <- <('m' 'e' 'r' 'g' 'e' !'(' (req_ws (Replace / Required / On))?)> */
+		/* 52 SimpleMerge <- <('m' 'e' 'r' 'g' 'e' !'(' (req_ws (Replace / Required / On))?)> */
 		func() bool {
 			position203, tokenIndex203, depth203 := position, tokenIndex, depth
 			{
@@ -2554,7 +2753,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position203, tokenIndex203, depth203
 			return false
 		},
-		/* 52 Replace <- <('r' 'e' 'p' 'l' 'a' 'c' 'e')> */
+		/* 53 Replace <- <('r' 'e' 'p' 'l' 'a' 'c' 'e')> */
 		func() bool {
 			position211, tokenIndex211, depth211 := position, tokenIndex, depth
 			{
@@ -2596,7 +2795,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position211, tokenIndex211, depth211
 			return false
 		},
-		/* 53 Required <- <('r' 'e' 'q' 'u' 'i' 'r' 'e' 'd')> */
+		/* 54 Required <- <('r' 'e' 'q' 'u' 'i' 'r' 'e' 'd')> */
 		func() bool {
 			position213, tokenIndex213, depth213 := position, tokenIndex, depth
 			{
@@ -2642,7 +2841,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position213, tokenIndex213, depth213
 			return false
 		},
-		/* 54 On <- <('o' 'n' req_ws Name)> */
+		/* 55 On <- <('o' 'n' req_ws Name NextName*)> */
 		func() bool {
 			position215, tokenIndex215, depth215 := position, tokenIndex, depth
 			{
@@ -2662,6 +2861,16 @@ func (p *DynamlGrammar) Init() {
 				if !_rules[ruleName]() {
 					goto l215
 				}
+			l344:
+				{
+					position345, tokenIndex345, depth345 := position, tokenIndex, depth
+					if !_rules[ruleNextName]() {
+						goto l345
+					}
+					goto l344
+				l345:
+					position, tokenIndex, depth = position345, tokenIndex345, depth345
+				}
 				depth--
 				add(ruleOn, position216)
 			}
@@ -2670,7 +2879,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position215, tokenIndex215, depth215
 			return false
 		},
-		/* 55 Auto <- <('a' 'u' 't' 'o')> */
+		/* 56 Auto <- <('a' 'u' 't' 'o')> */
 		func() bool {
 			position217, tokenIndex217, depth217 := position, tokenIndex, depth
 			{
@@ -2700,7 +2909,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position217, tokenIndex217, depth217
 			return false
 		},
-		/* 56 Mapping <- <('m' 'a' 'p' '[' Level7 (LambdaExpr / ('|' Expression)) ']')> */
+		/* 57 Mapping <- <('m' 'a' 'p' '[' Level7 (LambdaExpr / ('|' Expression)) ']')> */
 		func() bool {
 			position219, tokenIndex219, depth219 := position, tokenIndex, depth
 			{
@@ -2754,7 +2963,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position219, tokenIndex219, depth219
 			return false
 		},
-		/* 57 Sum <- <('s' 'u' 'm' '[' Level7 '|' Level7 (LambdaExpr / ('|' Expression)) ']')> */
+		/* 58 Sum <- <('s' 'u' 'm' '[' Level7 '|' Level7 (LambdaExpr / ('|' Expression)) ']')> */
 		func() bool {
 			position223, tokenIndex223, depth223 := position, tokenIndex, depth
 			{
@@ -2815,7 +3024,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position223, tokenIndex223, depth223
 			return false
 		},
-		/* 58 Lambda <- <('l' 'a' 'm' 'b' 'd' 'a' (LambdaRef / LambdaExpr))> */
+		/* 59 Lambda <- <('l' 'a' 'm' 'b' 'd' 'a' (LambdaRef / LambdaExpr))> */
 		func() bool {
 			position227, tokenIndex227, depth227 := position, tokenIndex, depth
 			{
@@ -2866,7 +3075,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position227, tokenIndex227, depth227
 			return false
 		},
-		/* 59 LambdaRef <- <(req_ws Expression)> */
+		/* 60 LambdaRef <- <(req_ws Expression)> */
 		func() bool {
 			position231, tokenIndex231, depth231 := position, tokenIndex, depth
 			{
@@ -2886,7 +3095,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position231, tokenIndex231, depth231
 			return false
 		},
-		/* 60 LambdaExpr <- <(ws '|' ws Name NextName* ws '|' ws ('-' '>') Expression)> */
+		/* 61 LambdaExpr <- <(ws '|' ws Name NextName* ws '|' ws ('-' '>') Expression)> */
 		func() bool {
 			position233, tokenIndex233, depth233 := position, tokenIndex, depth
 			{
@@ -2944,7 +3153,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position233, tokenIndex233, depth233
 			return false
 		},
-		/* 61 NextName <- <(ws ',' ws Name)> */
+		/* 62 NextName <- <(ws ',' ws Name)> */
 		func() bool {
 			position237, tokenIndex237, depth237 := position, tokenIndex, depth
 			{
@@ -2971,7 +3180,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position237, tokenIndex237, depth237
 			return false
 		},
-		/* 62 Name <- <([a-z] / [A-Z] / [0-9] / '_')+> */
+		/* 63 Name <- <([a-z] / [A-Z] / [0-9] / '_')+> */
 		func() bool {
 			position239, tokenIndex239, depth239 := position, tokenIndex, depth
 			{
@@ -3050,7 +3259,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position239, tokenIndex239, depth239
 			return false
 		},
-		/* 63 Reference <- <('.'? Key FollowUpRef)> */
+		/* 64 Reference <- <('.'? Key FollowUpRef)> */
 		func() bool {
 			position251, tokenIndex251, depth251 := position, tokenIndex, depth
 			{
@@ -3081,7 +3290,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position251, tokenIndex251, depth251
 			return false
 		},
-		/* 64 FollowUpRef <- <('.' (Key / Index))*> */
+		/* 65 FollowUpRef <- <('.' (Key / Index))*> */
 		func() bool {
 			{
 				position256 := position
@@ -3115,7 +3324,7 @@ func (p *DynamlGrammar) Init() {
 			}
 			return true
 		},
-		/* 65 Key <- <(([a-z] / [A-Z] / [0-9] / '_') ([a-z] / [A-Z] / [0-9] / '_' / '-')* (':' ([a-z] / [A-Z] / [0-9] / '_') ([a-z] / [A-Z] / [0-9] / '_' / '-')*)?)> */
+		/* 66 Key <- <(([a-z] / [A-Z] / [0-9] / '_') ([a-z] / [A-Z] / [0-9] / '_' / '-')* (':' ([a-z] / [A-Z] / [0-9] / '_') ([a-z] / [A-Z] / [0-9] / '_' / '-')*)?)> */
 		func() bool {
 			position261, tokenIndex261, depth261 := position, tokenIndex, depth
 			{
@@ -3284,7 +3493,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position261, tokenIndex261, depth261
 			return false
 		},
-		/* 66 Index <- <('[' [0-9]+ ']')> */
+		/* 67 Index <- <('[' [0-9]+ ']')> */
 		func() bool {
 			position287, tokenIndex287, depth287 := position, tokenIndex, depth
 			{
@@ -3321,7 +3530,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position287, tokenIndex287, depth287
 			return false
 		},
-		/* 67 IP <- <([0-9]+ '.' [0-9]+ '.' [0-9]+ '.' [0-9]+)> */
+		/* 68 IP <- <([0-9]+ '.' [0-9]+ '.' [0-9]+ '.' [0-9]+)> */
 		func() bool {
 			position291, tokenIndex291, depth291 := position, tokenIndex, depth
 			{
@@ -3407,7 +3616,7 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position291, tokenIndex291, depth291
 			return false
 		},
-		/* 68 ws <- <(' ' / '\t' / '\n' / '\r')*> */
+		/* 69 ws <- <(' ' / '\t' / '\n' / '\r')*> */
 		func() bool {
 			{
 				position302 := position
@@ -3453,7 +3662,7 @@ func (p *DynamlGrammar) Init() {
 			}
 			return true
 		},
-		/* 69 req_ws <- <(' ' / '\t' / '\n' / '\r')+> */
+		/* 70 req_ws <- <(' ' / '\t' / '\n' / '\r')+> */
 		func() bool {
 			position309, tokenIndex309, depth309 := position, tokenIndex, depth
 			{
@@ -3532,6 +3741,262 @@ func (p *DynamlGrammar) Init() {
 			position, tokenIndex, depth = position309, tokenIndex309, depth309
 			return false
 		},
+		/* 71 MapMapping <- <('m' 'a' 'p' '{' Level7 (LambdaExpr / ('|' Expression)) '}')> */
+		func() bool {
+			position347, tokenIndex347, depth347 := position, tokenIndex, depth
+			{
+				position348 := position
+				depth++
+				if buffer[position] != rune('m') {
+					goto l347
+				}
+				position++
+				if buffer[position] != rune('a') {
+					goto l347
+				}
+				position++
+				if buffer[position] != rune('p') {
+					goto l347
+				}
+				position++
+				if buffer[position] != rune('{') {
+					goto l347
+				}
+				position++
+				if !_rules[ruleLevel7]() {
+					goto l347
+				}
+				{
+					position349, tokenIndex349, depth349 := position, tokenIndex, depth
+					if !_rules[ruleLambdaExpr]() {
+						goto l350
+					}
+					goto l349
+				l350:
+					position, tokenIndex, depth = position349, tokenIndex349, depth349
+					if buffer[position] != rune('|') {
+						goto l347
+					}
+					position++
+					if !_rules[ruleExpression]() {
+						goto l347
+					}
+				}
+			l349:
+				if buffer[position] != rune('}') {
+					goto l347
+				}
+				position++
+				depth--
+				add(ruleMapMapping, position348)
+			}
+			return true
+		l347:
+			position, tokenIndex, depth = position347, tokenIndex347, depth347
+			return false
+		},
+		/* 72 Selection <- <((('s' 'e' 'l' 'e' 'c' 't' '[') / ('f' 'i' 'l' 't' 'e' 'r' '[')) Level7 (LambdaExpr / ('|' Expression)) ']')> */
+		func() bool {
+			position351, tokenIndex351, depth351 := position, tokenIndex, depth
+			{
+				position352 := position
+				depth++
+				{
+					position353, tokenIndex353, depth353 := position, tokenIndex, depth
+					if buffer[position] != rune('s') {
+						goto l354
+					}
+					position++
+					if buffer[position] != rune('e') {
+						goto l354
+					}
+					position++
+					if buffer[position] != rune('l') {
+						goto l354
+					}
+					position++
+					if buffer[position] != rune('e') {
+						goto l354
+					}
+					position++
+					if buffer[position] != rune('c') {
+						goto l354
+					}
+					position++
+					if buffer[position] != rune('t') {
+						goto l354
+					}
+					position++
+					if buffer[position] != rune('[') {
+						goto l354
+					}
+					position++
+					goto l353
+				l354:
+					position, tokenIndex, depth = position353, tokenIndex353, depth353
+					if buffer[position] != rune('f') {
+						goto l351
+					}
+					position++
+					if buffer[position] != rune('i') {
+						goto l351
+					}
+					position++
+					if buffer[position] != rune('l') {
+						goto l351
+					}
+					position++
+					if buffer[position] != rune('t') {
+						goto l351
+					}
+					position++
+					if buffer[position] != rune('e') {
+						goto l351
+					}
+					position++
+					if buffer[position] != rune('r') {
+						goto l351
+					}
+					position++
+					if buffer[position] != rune('[') {
+						goto l351
+					}
+					position++
+				}
+			l353:
+				if !_rules[ruleLevel7]() {
+					goto l351
+				}
+				{
+					position355, tokenIndex355, depth355 := position, tokenIndex, depth
+					if !_rules[ruleLambdaExpr]() {
+						goto l356
+					}
+					goto l355
+				l356:
+					position, tokenIndex, depth = position355, tokenIndex355, depth355
+					if buffer[position] != rune('|') {
+						goto l351
+					}
+					position++
+					if !_rules[ruleExpression]() {
+						goto l351
+					}
+				}
+			l355:
+				if buffer[position] != rune(']') {
+					goto l351
+				}
+				position++
+				depth--
+				add(ruleSelection, position352)
+			}
+			return true
+		l351:
+			position, tokenIndex, depth = position351, tokenIndex351, depth351
+			return false
+		},
+		/* 73 MapSelection <- <((('s' 'e' 'l' 'e' 'c' 't' '{') / ('f' 'i' 'l' 't' 'e' 'r' '{')) Level7 (LambdaExpr / ('|' Expression)) '}')> */
+		func() bool {
+			position357, tokenIndex357, depth357 := position, tokenIndex, depth
+			{
+				position358 := position
+				depth++
+				{
+					position359, tokenIndex359, depth359 := position, tokenIndex, depth
+					if buffer[position] != rune('s') {
+						goto l360
+					}
+					position++
+					if buffer[position] != rune('e') {
+						goto l360
+					}
+					position++
+					if buffer[position] != rune('l') {
+						goto l360
+					}
+					position++
+					if buffer[position] != rune('e') {
+						goto l360
+					}
+					position++
+					if buffer[position] != rune('c') {
+						goto l360
+					}
+					position++
+					if buffer[position] != rune('t') {
+						goto l360
+					}
+					position++
+					if buffer[position] != rune('{') {
+						goto l360
+					}
+					position++
+					goto l359
+				l360:
+					position, tokenIndex, depth = position359, tokenIndex359, depth359
+					if buffer[position] != rune('f') {
+						goto l357
+					}
+					position++
+					if buffer[position] != rune('i') {
+						goto l357
+					}
+					position++
+					if buffer[position] != rune('l') {
+						goto l357
+					}
+					position++
+					if buffer[position] != rune('t') {
+						goto l357
+					}
+					position++
+					if buffer[position] != rune('e') {
+						goto l357
+					}
+					position++
+					if buffer[position] != rune('r') {
+						goto l357
+					}
+					position++
+					if buffer[position] != rune('{') {
+						goto l357
+					}
+					position++
+				}
+			l359:
+				if !_rules[ruleLevel7]() {
+					goto l357
+				}
+				{
+					position361, tokenIndex361, depth361 := position, tokenIndex, depth
+					if !_rules[ruleLambdaExpr]() {
+						goto l362
+					}
+					goto l361
+				l362:
+					position, tokenIndex, depth = position361, tokenIndex361, depth361
+					if buffer[position] != rune('|') {
+						goto l357
+					}
+					position++
+					if !_rules[ruleExpression]() {
+						goto l357
+					}
+				}
+			l361:
+				if buffer[position] != rune('}') {
+					goto l357
+				}
+				position++
+				depth--
+				add(ruleMapSelection, position358)
+			}
+			return true
+		l357:
+			position, tokenIndex, depth = position357, tokenIndex357, depth357
+			return false
+		},
 	}
 	p.rules = _rules
 }
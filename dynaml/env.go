@@ -1,29 +1,11 @@
 package dynaml
 
 import (
-	"os"
 	"strconv"
-	"strings"
 
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
-var environ []string = os.Environ()
-
-func ReloadEnv() {
-	environ = os.Environ()
-}
-
-func getenv(name string) (string, bool) {
-	name += "="
-	for _, s := range environ {
-		if strings.HasPrefix(s, name) {
-			return s[len(name):], true
-		}
-	}
-	return "", false
-}
-
 func func_env(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
 	info := DefaultInfo()
 
@@ -59,8 +41,14 @@ func func_env(arguments []interface{}, binding Binding) (interface{}, Evaluation
 		}
 	}
 
+	for _, n := range args {
+		if err := binding.GetState().GetSandbox().CheckEnvironment(n); err != nil {
+			return info.Error("%s", err)
+		}
+	}
+
 	if len(args) == 1 {
-		s, ok := getenv(args[0])
+		s, ok := binding.GetState().Getenv(args[0])
 		if ok {
 			return s, info, ok
 		} else {
@@ -69,7 +57,7 @@ func func_env(arguments []interface{}, binding Binding) (interface{}, Evaluation
 	} else {
 		m := make(map[string]yaml.Node)
 		for _, n := range args {
-			s, ok := getenv(n)
+			s, ok := binding.GetState().Getenv(n)
 			if ok {
 				m[n] = node(s, nil)
 			}
@@ -1,16 +1,12 @@
 package dynaml
 
 import (
-	"crypto/md5"
-	"fmt"
 	"log"
-	"os/exec"
 	"strconv"
 	"strings"
 
 	"github.com/cloudfoundry-incubator/candiedyaml"
 
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
@@ -21,15 +17,15 @@ func func_exec(arguments []interface{}, binding Binding) (interface{}, Evaluatio
 		return nil, info, false
 	}
 	args := []string{}
-	debug.Debug("exec: found %d arguments for call\n", len(arguments))
+	binding.GetState().Debug("exec: found %d arguments for call\n", len(arguments))
 	for i, arg := range arguments {
 		list, ok := arg.([]yaml.Node)
 		if i == 0 && ok {
-			debug.Debug("exec: found array as first argument\n")
+			binding.GetState().Debug("exec: found array as first argument\n")
 			if len(arguments) == 1 && len(list) > 0 {
 				// handle single list argument to gain command and argument
 				for j, arg := range list {
-					v, ok := getArg(j, arg.Value())
+					v, ok := getArg(j, arg.Value(), binding)
 					if !ok {
 						return info.Error("command argument must be string")
 					}
@@ -39,14 +35,17 @@ func func_exec(arguments []interface{}, binding Binding) (interface{}, Evaluatio
 				return info.Error("list not allowed for command argument")
 			}
 		} else {
-			v, ok := getArg(i, arg)
+			v, ok := getArg(i, arg, binding)
 			if !ok {
 				return info.Error("command argument must be string")
 			}
 			args = append(args, v)
 		}
 	}
-	result, err := cachedExecute(args)
+	if err := binding.GetState().GetSandbox().CheckCommand(args[0]); err != nil {
+		return info.Error("%s", err)
+	}
+	result, err := binding.GetState().Execute(args)
 	if err != nil {
 		return info.Error("execution '%s' failed", args[0])
 	}
@@ -54,7 +53,7 @@ func func_exec(arguments []interface{}, binding Binding) (interface{}, Evaluatio
 	str := string(result)
 	execYML, err := yaml.Parse("exec", result)
 	if strings.HasPrefix(str, "---\n") && err == nil {
-		debug.Debug("exec: found yaml result %+v\n", execYML)
+		binding.GetState().Debug("exec: found yaml result %+v\n", execYML)
 		return execYML.Value(), info, true
 	} else {
 		if strings.HasSuffix(str, "\n") {
@@ -62,16 +61,16 @@ func func_exec(arguments []interface{}, binding Binding) (interface{}, Evaluatio
 		}
 		int64YML, err := strconv.ParseInt(str, 10, 64)
 		if err == nil {
-			debug.Debug("exec: found integer result: %s\n", int64YML)
+			binding.GetState().Debug("exec: found integer result: %s\n", int64YML)
 			return int64YML, info, true
 		}
-		debug.Debug("exec: found string result: %s\n", string(result))
+		binding.GetState().Debug("exec: found string result: %s\n", string(result))
 		return str, info, true
 	}
 }
 
-func getArg(i int, value interface{}) (string, bool) {
-	debug.Debug("arg %d: %+v\n", i, value)
+func getArg(i int, value interface{}, binding Binding) (string, bool) {
+	binding.GetState().Debug("arg %d: %+v\n", i, value)
 	switch value.(type) {
 	case string:
 		return value.(string), true
@@ -88,23 +87,3 @@ func getArg(i int, value interface{}) (string, bool) {
 		return "---\n" + string(yaml), true
 	}
 }
-
-var cache = make(map[string][]byte)
-
-func cachedExecute(args []string) ([]byte, error) {
-	h := md5.New()
-	for _, arg := range args {
-		h.Write([]byte(arg))
-	}
-	hash := fmt.Sprintf("%x", h.Sum(nil))
-	result := cache[hash]
-	if result != nil {
-		debug.Debug("exec: reusing cache %s for %v\n", hash, args)
-		return result, nil
-	}
-	debug.Debug("exec: calling %v\n", args)
-	cmd := exec.Command(args[0], args[1:]...)
-	result, err := cmd.Output()
-	cache[hash] = result
-	return result, err
-}
@@ -0,0 +1,31 @@
+package dynaml
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+// FormatValue provides a short description of a value
+// for the explanation of evaluation steps.
+func FormatValue(value interface{}) string {
+	switch v := value.(type) {
+	case yaml.Node:
+		if v == nil {
+			return "~"
+		}
+		return FormatValue(v.Value())
+	case map[string]yaml.Node:
+		return fmt.Sprintf("map with %d entries", len(v))
+	case []yaml.Node:
+		return fmt.Sprintf("list with %d entries", len(v))
+	case Expression:
+		return fmt.Sprintf("(( %s ))", v)
+	case string:
+		return fmt.Sprintf("%q", v)
+	case nil:
+		return "~"
+	default:
+		return fmt.Sprintf("%v", v)
+	}
+}
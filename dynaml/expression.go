@@ -20,6 +20,9 @@ type Binding interface {
 	FindFromRoot([]string) (yaml.Node, bool)
 	FindReference([]string) (yaml.Node, bool)
 	FindInStubs([]string) (yaml.Node, bool)
+	// ListKey provides the key declared by the stubs for the list
+	// with the given path (empty if not declared).
+	ListKey(path []string) string
 
 	WithScope(step map[string]yaml.Node) Binding
 	WithLocalScope(step map[string]yaml.Node) Binding
@@ -30,6 +33,8 @@ type Binding interface {
 	Path() []string
 	StubPath() []string
 
+	GetState() State
+
 	Flow(source yaml.Node, shouldOverride bool) (yaml.Node, Status)
 	Cascade(template yaml.Node, partial bool, templates ...yaml.Node) (yaml.Node, error)
 }
@@ -1,6 +1,8 @@
 package dynaml
 
 import (
+	"os"
+	"os/exec"
 	"strings"
 
 	"github.com/cloudfoundry-incubator/spiff/yaml"
@@ -54,6 +56,10 @@ func (c FakeBinding) WithSource(source string) Binding {
 	return c
 }
 
+func (c FakeBinding) ListKey(path []string) string {
+	return ""
+}
+
 func (c FakeBinding) GetLocalBinding() map[string]yaml.Node {
 	return map[string]yaml.Node{}
 }
@@ -84,3 +90,39 @@ func (c FakeBinding) Flow(source yaml.Node, shouldOverride bool) (yaml.Node, Sta
 func (c FakeBinding) Cascade(template yaml.Node, partial bool, templates ...yaml.Node) (yaml.Node, error) {
 	return nil, nil
 }
+
+func (c FakeBinding) GetState() State {
+	return FakeState{}
+}
+
+type FakeState struct{}
+
+func (s FakeState) GetFileContent(file string) ([]byte, error) {
+	return OSFileSystem.ReadFile(file)
+}
+
+func (s FakeState) Getenv(name string) (string, bool) {
+	return os.LookupEnv(name)
+}
+
+func (s FakeState) Execute(args []string) ([]byte, error) {
+	return exec.Command(args[0], args[1:]...).Output()
+}
+
+func (s FakeState) FunctionAllowed(name string) bool {
+	return true
+}
+
+func (s FakeState) GetFunctions() Registry {
+	return NewRegistry()
+}
+
+func (s FakeState) GetSandbox() *Sandbox {
+	return nil
+}
+
+func (s FakeState) Debug(fmt string, args ...interface{}) {
+}
+
+func (s FakeState) Explain(path []string, fmt string, args ...interface{}) {
+}
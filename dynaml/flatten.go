@@ -0,0 +1,32 @@
+package dynaml
+
+import (
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+func func_flatten(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("flatten takes exactly one argument")
+	}
+
+	list, ok := arguments[0].([]yaml.Node)
+	if !ok {
+		return info.Error("argument for flatten must be a list")
+	}
+	return flatten(list), info, true
+}
+
+// flatten replaces nested lists by their (flattened) entries.
+func flatten(list []yaml.Node) []yaml.Node {
+	result := []yaml.Node{}
+	for _, n := range list {
+		if nested, ok := n.Value().([]yaml.Node); ok {
+			result = append(result, flatten(nested)...)
+		} else {
+			result = append(result, n)
+		}
+	}
+	return result
+}
@@ -0,0 +1,55 @@
+package dynaml
+
+import (
+	"strconv"
+	"strings"
+)
+
+type FloatExpr struct {
+	Value float64
+}
+
+func (e FloatExpr) Evaluate(binding Binding, locally bool) (interface{}, EvaluationInfo, bool) {
+	return e.Value, DefaultInfo(), true
+}
+
+func (e FloatExpr) String() string {
+	return FormatFloat(e.Value)
+}
+
+// FormatFloat formats a float value, such that it is
+// parsed as float again.
+func FormatFloat(v float64) string {
+	s := strconv.FormatFloat(v, 'f', -1, 64)
+	if !strings.ContainsAny(s, ".eEIN") {
+		s += ".0"
+	}
+	return s
+}
+
+func isFloat(v interface{}) bool {
+	_, ok := v.(float64)
+	return ok
+}
+
+// floatOperands provides the operands of an arithmetic operation
+// as float values, if at least one of them is a float. Integer
+// operands are converted.
+func floatOperands(a, b interface{}) (float64, float64, bool, bool) {
+	if !isFloat(a) && !isFloat(b) {
+		return 0, 0, false, true
+	}
+	fa, oka := toFloat(a)
+	fb, okb := toFloat(b)
+	return fa, fb, true, oka && okb
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int64:
+		return float64(n), true
+	}
+	return 0, false
+}
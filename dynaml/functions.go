@@ -0,0 +1,216 @@
+package dynaml
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+// Function is the implementation of a dynaml function called with
+// the values of its evaluated arguments.
+type Function func(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool)
+
+// expressionFunction is the implementation of a built-in function
+// that additionally requires access to the call expression.
+type expressionFunction func(e CallExpr, arguments []interface{}, binding Binding, locally bool) (interface{}, EvaluationInfo, bool)
+
+// ArgumentType describes the kind of value accepted for an argument.
+type ArgumentType string
+
+const (
+	TypeAny    ArgumentType = "any"
+	TypeString ArgumentType = "string"
+	TypeInt    ArgumentType = "int"
+	TypeFloat  ArgumentType = "float"
+	TypeNumber ArgumentType = "number"
+	TypeBool   ArgumentType = "bool"
+	TypeList   ArgumentType = "list"
+	TypeMap    ArgumentType = "map"
+	TypeLambda ArgumentType = "lambda"
+)
+
+// Signature describes the arguments accepted by a function.
+// A negative MaxArgs means an unlimited number of arguments.
+// The last entry of Types is used for all further arguments,
+// without Types arguments are not type checked.
+type Signature struct {
+	MinArgs int
+	MaxArgs int
+	Types   []ArgumentType
+}
+
+// Check validates the given arguments against the signature.
+func (s *Signature) Check(name string, arguments []interface{}) error {
+	if len(arguments) < s.MinArgs {
+		return fmt.Errorf("function '%s' requires at least %d argument(s)", name, s.MinArgs)
+	}
+	if s.MaxArgs >= 0 && len(arguments) > s.MaxArgs {
+		return fmt.Errorf("function '%s' takes a maximum of %d argument(s)", name, s.MaxArgs)
+	}
+	if len(s.Types) == 0 {
+		return nil
+	}
+	for i, arg := range arguments {
+		t := s.Types[len(s.Types)-1]
+		if i < len(s.Types) {
+			t = s.Types[i]
+		}
+		if !t.Matches(arg) {
+			return fmt.Errorf("argument %d for function '%s' must be of type %s", i+1, name, t)
+		}
+	}
+	return nil
+}
+
+// Matches checks whether a value is of the argument type.
+func (t ArgumentType) Matches(value interface{}) bool {
+	switch t {
+	case TypeString:
+		_, ok := value.(string)
+		return ok
+	case TypeInt:
+		_, ok := value.(int64)
+		return ok
+	case TypeFloat:
+		_, ok := value.(float64)
+		return ok
+	case TypeNumber:
+		_, ok := toFloat(value)
+		return ok
+	case TypeBool:
+		_, ok := value.(bool)
+		return ok
+	case TypeList:
+		_, ok := value.([]yaml.Node)
+		return ok
+	case TypeMap:
+		_, ok := value.(map[string]yaml.Node)
+		return ok
+	case TypeLambda:
+		_, ok := value.(LambdaValue)
+		return ok
+	case TypeTemplate:
+		_, ok := value.(TemplateValue)
+		return ok
+	case TypeNil:
+		return value == nil
+	}
+	return true
+}
+
+// FunctionDefinition describes a registered function.
+type FunctionDefinition struct {
+	Name      string
+	Function  Function
+	Signature *Signature
+
+	expression expressionFunction
+	// unevaluated functions get the unevaluated argument expressions
+	unevaluated bool
+}
+
+func (d *FunctionDefinition) call(e CallExpr, arguments []interface{}, binding Binding, locally bool) (interface{}, EvaluationInfo, bool) {
+	if d.Signature != nil {
+		if err := d.Signature.Check(d.Name, arguments); err != nil {
+			info := DefaultInfo()
+			return info.Error("%s", err)
+		}
+	}
+	if d.expression != nil {
+		return d.expression(e, arguments, binding, locally)
+	}
+	return d.Function(arguments, binding)
+}
+
+// Registry is a set of named functions callable by dynaml expressions.
+type Registry interface {
+	// RegisterFunction registers or overrides a function. An optional
+	// signature is used to check the arguments before calling the function.
+	RegisterFunction(name string, f Function, signature ...Signature)
+	// UnregisterFunction removes (disables) a function.
+	UnregisterFunction(name string)
+	LookupFunction(name string) *FunctionDefinition
+	// Names lists the names of all registered functions.
+	Names() []string
+	// Copy provides a modifiable copy of the registry.
+	Copy() Registry
+}
+
+type registry struct {
+	lock      sync.RWMutex
+	functions map[string]*FunctionDefinition
+}
+
+// NewRegistry creates a registry initially containing all built-in functions.
+func NewRegistry() Registry {
+	return builtins.Copy()
+}
+
+// NewEmptyRegistry creates a registry without any function.
+func NewEmptyRegistry() Registry {
+	return &registry{functions: map[string]*FunctionDefinition{}}
+}
+
+func (r *registry) RegisterFunction(name string, f Function, signature ...Signature) {
+	def := &FunctionDefinition{Name: name, Function: f}
+	if len(signature) > 0 {
+		def.Signature = &signature[0]
+	}
+	r.register(def)
+}
+
+func (r *registry) register(def *FunctionDefinition) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.functions[def.Name] = def
+}
+
+func (r *registry) UnregisterFunction(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.functions, name)
+}
+
+func (r *registry) LookupFunction(name string) *FunctionDefinition {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return r.functions[name]
+}
+
+func (r *registry) Names() []string {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	names := []string{}
+	for n := range r.functions {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (r *registry) Copy() Registry {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	n := &registry{functions: map[string]*FunctionDefinition{}}
+	for k, v := range r.functions {
+		n.functions[k] = v
+	}
+	return n
+}
+
+var builtins = NewEmptyRegistry().(*registry)
+
+// Builtins lists the names of the built-in functions.
+func Builtins() []string {
+	return builtins.Names()
+}
+
+func registerBuiltin(name string, f Function) {
+	builtins.register(&FunctionDefinition{Name: name, Function: f})
+}
+
+func registerExpressionBuiltin(name string, f expressionFunction, unevaluated bool) {
+	builtins.register(&FunctionDefinition{Name: name, expression: f, unevaluated: unevaluated})
+}
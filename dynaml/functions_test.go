@@ -0,0 +1,67 @@
+package dynaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+var _ = Describe("function registry", func() {
+	dummy := func(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+		return "dummy", DefaultInfo(), true
+	}
+
+	It("contains the built-in functions", func() {
+		registry := NewRegistry()
+		Expect(registry.Names()).To(ContainElement("join"))
+		Expect(registry.Names()).To(ContainElement("defined"))
+		Expect(registry.Names()).To(Equal(Builtins()))
+	})
+
+	It("registers, overrides and removes functions", func() {
+		registry := NewRegistry()
+		registry.RegisterFunction("dummy", dummy)
+		registry.RegisterFunction("join", dummy)
+		registry.UnregisterFunction("exec")
+
+		Expect(registry.LookupFunction("dummy")).NotTo(BeNil())
+		Expect(registry.LookupFunction("join").Name).To(Equal("join"))
+		Expect(registry.LookupFunction("exec")).To(BeNil())
+		Expect(Builtins()).NotTo(ContainElement("dummy"))
+		Expect(Builtins()).To(ContainElement("exec"))
+	})
+
+	It("copies registries", func() {
+		registry := NewEmptyRegistry()
+		registry.RegisterFunction("dummy", dummy)
+		copied := registry.Copy()
+		copied.UnregisterFunction("dummy")
+		Expect(registry.Names()).To(Equal([]string{"dummy"}))
+		Expect(copied.Names()).To(BeEmpty())
+	})
+
+	Describe("signatures", func() {
+		signature := Signature{MinArgs: 1, MaxArgs: 3, Types: []ArgumentType{TypeString, TypeInt}}
+
+		It("accepts matching arguments", func() {
+			Expect(signature.Check("f", []interface{}{"a"})).To(Succeed())
+			Expect(signature.Check("f", []interface{}{"a", int64(1), int64(2)})).To(Succeed())
+		})
+
+		It("checks the number of arguments", func() {
+			Expect(signature.Check("f", []interface{}{})).To(MatchError("function 'f' requires at least 1 argument(s)"))
+			Expect(signature.Check("f", []interface{}{"a", int64(1), int64(2), int64(3)})).To(MatchError("function 'f' takes a maximum of 3 argument(s)"))
+		})
+
+		It("checks the argument types", func() {
+			Expect(signature.Check("f", []interface{}{int64(1)})).To(MatchError("argument 1 for function 'f' must be of type string"))
+			Expect(signature.Check("f", []interface{}{"a", int64(1), "b"})).To(MatchError("argument 3 for function 'f' must be of type int"))
+		})
+
+		It("accepts unlimited arguments of any type", func() {
+			any := Signature{MaxArgs: -1}
+			Expect(any.Check("f", []interface{}{"a", true, []yaml.Node{}, nil})).To(Succeed())
+		})
+	})
+})
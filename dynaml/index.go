@@ -30,7 +30,7 @@ func _index(first bool, f func(string, string) int, arguments []interface{}, bin
 		elem := arguments[1]
 
 		for i, v := range val {
-			r, _, _ := compareEquals(v.Value(), elem)
+			r, _, _ := compareEquals(v.Value(), elem, binding)
 			if r {
 				found = int64(i)
 				if first {
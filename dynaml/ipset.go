@@ -5,7 +5,6 @@ import (
 	"net"
 	"strings"
 
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
@@ -24,13 +23,13 @@ type cidrrange struct {
 	net.IPNet
 }
 
-func map_ip_ranges(ranges []string) ([]IPRange, EvaluationInfo, bool) {
+func map_ip_ranges(ranges []string, binding Binding) ([]IPRange, EvaluationInfo, bool) {
 	ipPool := []IPRange{}
 
 	info := DefaultInfo()
 	for _, r := range ranges {
 		segments := strings.Split(r, "-")
-		debug.Debug("ipset: found range segments '%s': %d %+v", r, len(segments), segments)
+		binding.GetState().Debug("ipset: found range segments '%s': %d %+v\n", r, len(segments), segments)
 		if len(segments) == 0 {
 			info.SetError("empty range")
 			return nil, info, false
@@ -74,6 +73,7 @@ func map_ip_ranges(ranges []string) ([]IPRange, EvaluationInfo, bool) {
 			ipr = &iprange{start, end, int64(0)}
 		}
 
+		binding.GetState().Debug("ipset: size of range '%s': %d\n", r, ipr.GetSize())
 		ipPool = append(ipPool, ipr)
 	}
 
@@ -84,7 +84,6 @@ func (i *iprange) GetSize() int64 {
 	if i.size == 0 {
 		i.size = DiffIP(i.end, i.start) + 1
 	}
-	debug.Debug("sizeof(%s-%s)=%d", i.start, i.end, i.size)
 	return i.size
 }
 
@@ -120,7 +119,7 @@ func func_ipset(arguments []interface{}, binding Binding) (interface{}, Evaluati
 
 	s, ok := arguments[0].(string)
 	if ok {
-		ranges, info, ok = map_ip_ranges([]string{s})
+		ranges, info, ok = map_ip_ranges([]string{s}, binding)
 		if !ok {
 			return nil, info, false
 		}
@@ -136,7 +135,7 @@ func func_ipset(arguments []interface{}, binding Binding) (interface{}, Evaluati
 				return info.Error("string entry at ip range list index %d", i)
 			}
 		}
-		ranges, info, ok = map_ip_ranges(rlist)
+		ranges, info, ok = map_ip_ranges(rlist, binding)
 		if !ok {
 			return nil, info, false
 		}
@@ -174,7 +173,7 @@ func func_ipset(arguments []interface{}, binding Binding) (interface{}, Evaluati
 			num, len(indices))
 	}
 
-	debug.Debug("ipset: request %d IP(s)", num)
+	binding.GetState().Debug("ipset: request %d IP(s)", num)
 	result := make([]yaml.Node, num)
 
 	for i := 0; i < int(num); i++ {
@@ -188,12 +187,12 @@ func func_ipset(arguments []interface{}, binding Binding) (interface{}, Evaluati
 		for j, r := range ranges {
 			if int64(index) < offset+r.GetSize() {
 				ip := r.GetIP(int64(index) - offset).String()
-				debug.Debug("ipset: get %d from range %d: %s",
+				binding.GetState().Debug("ipset: get %d from range %d: %s",
 					int64(index)-offset, j, ip)
 				result[i] = node(ip, nil)
 				break
 			}
-			debug.Debug("ipset: skipping range %d: offset %d size %d",
+			binding.GetState().Debug("ipset: skipping range %d: offset %d size %d",
 				j, offset, r.GetSize())
 			offset += r.GetSize()
 		}
@@ -0,0 +1,41 @@
+package dynaml
+
+import (
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+func func_keys(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("keys takes exactly one argument")
+	}
+
+	m, ok := arguments[0].(map[string]yaml.Node)
+	if !ok {
+		return info.Error("argument for keys must be a map")
+	}
+	result := []yaml.Node{}
+	for _, k := range getSortedKeys(m) {
+		result = append(result, node(k, binding))
+	}
+	return result, info, true
+}
+
+func func_values(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("values takes exactly one argument")
+	}
+
+	m, ok := arguments[0].(map[string]yaml.Node)
+	if !ok {
+		return info.Error("argument for values must be a map")
+	}
+	result := []yaml.Node{}
+	for _, k := range getSortedKeys(m) {
+		result = append(result, m[k])
+	}
+	return result, info, true
+}
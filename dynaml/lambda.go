@@ -3,7 +3,6 @@ package dynaml
 import (
 	"fmt"
 
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
@@ -47,15 +46,15 @@ func (e LambdaRefExpr) Evaluate(binding Binding, locally bool) (interface{}, Eva
 		lambda = v
 
 	case string:
-		debug.Debug("LRef: parsing '%s'\n", v)
+		binding.GetState().Debug("LRef: parsing '%s'\n", v)
 		expr, err := Parse(v, e.Path, e.StubPath)
 		if err != nil {
-			debug.Debug("cannot parse: %s\n", err.Error())
+			binding.GetState().Debug("cannot parse: %s\n", err.Error())
 			return info.Error("cannot parse lamba expression '%s'")
 		}
 		lexpr, ok := expr.(LambdaExpr)
 		if !ok {
-			debug.Debug("no lambda expression: %T\n", expr)
+			binding.GetState().Debug("no lambda expression: %T\n", expr)
 			return info.Error("'%s' is no lambda expression", v)
 		}
 		lambda = LambdaValue{lexpr, binding.GetLocalBinding()}
@@ -63,7 +62,7 @@ func (e LambdaRefExpr) Evaluate(binding Binding, locally bool) (interface{}, Eva
 	default:
 		return info.Error("lambda reference must resolve to lambda value or string")
 	}
-	debug.Debug("found lambda: %s\n", lambda)
+	binding.GetState().Debug("found lambda: %s\n", lambda)
 	return lambda, info, true
 }
 
@@ -105,12 +104,12 @@ func (e LambdaValue) Evaluate(args []interface{}, binding Binding, locally bool)
 	for n, v := range e.binding {
 		inp[n] = v
 	}
-	debug.Debug("LAMBDA CALL: inherit binding %+v\n", inp)
+	binding.GetState().Debug("LAMBDA CALL: inherit binding %+v\n", inp)
 	inp["_"] = node(e, binding)
 	for i, v := range args {
 		inp[e.lambda.Names[i]] = node(v, binding)
 	}
-	debug.Debug("LAMBDA CALL: effective binding %+v\n", inp)
+	binding.GetState().Debug("LAMBDA CALL: effective binding %+v\n", inp)
 
 	if len(args) < len(e.lambda.Names) {
 		rest := e.lambda.Names[len(args):]
@@ -1,7 +1,6 @@
 package dynaml
 
 import (
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
@@ -35,7 +34,7 @@ func func_list_to_map(listexpr Expression, arguments []interface{}, binding Bind
 		}
 	}
 
-	debug.Debug("list to map with key field '%s'", key)
+	binding.GetState().Debug("list to map with key field '%s'", key)
 
 	result, err := listToMap(list, key)
 	if result == nil {
@@ -46,23 +45,26 @@ func func_list_to_map(listexpr Expression, arguments []interface{}, binding Bind
 
 func listToMap(list []yaml.Node, keyName string) (map[string]yaml.Node, string) {
 	toMap := make(map[string]yaml.Node)
+	fields := yaml.KeyFields(keyName)
 
 	for _, val := range list {
 		asMap, ok := val.Value().(map[string]yaml.Node)
 		if !ok {
 			return nil, "list entries must by maps"
 		}
-		keyValue, ok := asMap[keyName]
-		if !ok {
-			return nil, "key field '%s' not found"
-		}
-		key, ok := keyValue.Value().(string)
-		if !ok {
-			return nil, "key field '%s' contains no string value"
+		for _, field := range fields {
+			keyValue, ok := asMap[field]
+			if !ok {
+				return nil, "key field '%s' not found"
+			}
+			if _, ok := keyValue.Value().(string); !ok {
+				return nil, "key field '%s' contains no string value"
+			}
 		}
+		key, _ := yaml.ListEntryKey(true, val, keyName)
 		newMap := make(map[string]yaml.Node)
 		for key, val := range asMap {
-			if key != keyName {
+			if !yaml.IsKeyField(key, keyName) {
 				newMap[key] = val
 			}
 		}
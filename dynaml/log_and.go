@@ -2,8 +2,6 @@ package dynaml
 
 import (
 	"fmt"
-
-	"github.com/cloudfoundry-incubator/spiff/debug"
 )
 
 const (
@@ -23,7 +21,7 @@ func (e LogAndExpr) Evaluate(binding Binding, locally bool) (interface{}, Evalua
 	if !resolved {
 		return e, info, true
 	}
-	debug.Debug("AND: %#v, %#v\n", a, b)
+	binding.GetState().Debug("AND: %#v, %#v\n", a, b)
 	inta, ok := a.(int64)
 	if ok {
 		if !all_ok {
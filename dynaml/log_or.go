@@ -2,8 +2,6 @@ package dynaml
 
 import (
 	"fmt"
-
-	"github.com/cloudfoundry-incubator/spiff/debug"
 )
 
 const (
@@ -18,13 +16,13 @@ type LogOrExpr struct {
 func (e LogOrExpr) Evaluate(binding Binding, locally bool) (interface{}, EvaluationInfo, bool) {
 	a, b, info, resolved, first_ok, all_ok := resolveLOperands(e.A, e.B, binding)
 	if !first_ok {
-		debug.Debug("OR: failed %#v, %#v\n", e.A, e.B)
+		binding.GetState().Debug("OR: failed %#v, %#v\n", e.A, e.B)
 		return nil, info, false
 	}
 	if !resolved {
 		return e, info, true
 	}
-	debug.Debug("OR: %#v, %#v\n", a, b)
+	binding.GetState().Debug("OR: %#v, %#v\n", a, b)
 	inta, ok := a.(int64)
 	if ok {
 		if !all_ok {
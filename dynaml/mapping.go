@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"sort"
 
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
@@ -16,7 +15,7 @@ type MapExpr struct {
 func (e MapExpr) Evaluate(binding Binding, locally bool) (interface{}, EvaluationInfo, bool) {
 	resolved := true
 
-	debug.Debug("evaluate mapping\n")
+	binding.GetState().Debug("evaluate mapping\n")
 	value, info, ok := ResolveExpressionOrPushEvaluation(&e.A, &resolved, nil, binding, true)
 	if !ok {
 		return nil, info, false
@@ -35,7 +34,7 @@ func (e MapExpr) Evaluate(binding Binding, locally bool) (interface{}, Evaluatio
 		return infoe.Error("mapping requires a lambda value")
 	}
 
-	debug.Debug("map: using lambda %+v\n", lambda)
+	binding.GetState().Debug("map: using lambda %+v\n", lambda)
 	var result []yaml.Node
 	switch value.(type) {
 	case []yaml.Node:
@@ -53,7 +52,7 @@ func (e MapExpr) Evaluate(binding Binding, locally bool) (interface{}, Evaluatio
 	if result == nil {
 		return e, info, true
 	}
-	debug.Debug("map: --> %+v\n", result)
+	binding.GetState().Debug("map: --> %+v\n", result)
 	return result, info, true
 }
 
@@ -76,21 +75,21 @@ func mapList(source []yaml.Node, e LambdaValue, binding Binding) ([]yaml.Node, E
 		return nil, info, false
 	}
 	for i, n := range source {
-		debug.Debug("map:  mapping for %d: %+v\n", i, n)
+		binding.GetState().Debug("map:  mapping for %d: %+v\n", i, n)
 		inp[0] = i
 		inp[len(inp)-1] = n.Value()
 		mapped, info, ok := e.Evaluate(inp, binding, false)
 		if !ok {
-			debug.Debug("map:  %d %+v: failed\n", i, n)
+			binding.GetState().Debug("map:  %d %+v: failed\n", i, n)
 			return nil, info, false
 		}
 
 		_, ok = mapped.(Expression)
 		if ok {
-			debug.Debug("map:  %d unresolved  -> KEEP\n")
+			binding.GetState().Debug("map:  %d unresolved  -> KEEP\n")
 			return nil, info, true
 		}
-		debug.Debug("map:  %d --> %+v\n", i, mapped)
+		binding.GetState().Debug("map:  %d --> %+v\n", i, mapped)
 		if mapped != nil {
 			result = append(result, node(mapped, info))
 		}
@@ -106,21 +105,21 @@ func mapMap(source map[string]yaml.Node, e LambdaValue, binding Binding) ([]yaml
 	keys := getSortedKeys(source)
 	for _, k := range keys {
 		n := source[k]
-		debug.Debug("map:  mapping for %s: %+v\n", k, n)
+		binding.GetState().Debug("map:  mapping for %s: %+v\n", k, n)
 		inp[0] = k
 		inp[len(inp)-1] = n.Value()
 		mapped, info, ok := e.Evaluate(inp, binding, false)
 		if !ok {
-			debug.Debug("map:  %s %+v: failed\n", k, n)
+			binding.GetState().Debug("map:  %s %+v: failed\n", k, n)
 			return nil, info, false
 		}
 
 		_, ok = mapped.(Expression)
 		if ok {
-			debug.Debug("map:  %d unresolved  -> KEEP\n")
+			binding.GetState().Debug("map:  %d unresolved  -> KEEP\n")
 			return nil, info, true
 		}
-		debug.Debug("map:  %s --> %+v\n", k, mapped)
+		binding.GetState().Debug("map:  %s --> %+v\n", k, mapped)
 		if mapped != nil {
 			result = append(result, node(mapped, info))
 		}
@@ -128,6 +127,108 @@ func mapMap(source map[string]yaml.Node, e LambdaValue, binding Binding) ([]yaml
 	return result, info, true
 }
 
+// MapMapExpr maps the values of a map (map{...}) and provides
+// a map with the same keys.
+type MapMapExpr struct {
+	A      Expression
+	Lambda Expression
+}
+
+func (e MapMapExpr) Evaluate(binding Binding, locally bool) (interface{}, EvaluationInfo, bool) {
+	value, lambda, info, ok := resolveLambdaOperands(&e.A, &e.Lambda, "mapping", binding)
+	if !ok {
+		return nil, info, false
+	}
+	if lambda == nil {
+		return e, info, true
+	}
+
+	source, ok := value.(map[string]yaml.Node)
+	if !ok {
+		return info.Error("map required for mapping to map")
+	}
+
+	binding.GetState().Debug("map: using lambda %+v\n", lambda)
+	result, info, ok := mapMapToMap(source, *lambda, binding)
+	if !ok {
+		return nil, info, false
+	}
+	if result == nil {
+		return e, info, true
+	}
+	binding.GetState().Debug("map: --> %+v\n", result)
+	return result, info, true
+}
+
+func (e MapMapExpr) String() string {
+	lambda, ok := e.Lambda.(LambdaExpr)
+	if ok {
+		return fmt.Sprintf("map{%s%s}", e.A, fmt.Sprintf("%s", lambda)[len("lambda"):])
+	} else {
+		return fmt.Sprintf("map{%s|%s}", e.A, e.Lambda)
+	}
+}
+
+func mapMapToMap(source map[string]yaml.Node, e LambdaValue, binding Binding) (map[string]yaml.Node, EvaluationInfo, bool) {
+	inp := make([]interface{}, len(e.lambda.Names))
+	result := map[string]yaml.Node{}
+	info := DefaultInfo()
+
+	if len(e.lambda.Names) > 2 {
+		info.Error("mapping expression take a maximum of 2 arguments")
+		return nil, info, false
+	}
+	for _, k := range getSortedKeys(source) {
+		n := source[k]
+		binding.GetState().Debug("map:  mapping for %s: %+v\n", k, n)
+		inp[0] = k
+		inp[len(inp)-1] = n.Value()
+		mapped, info, ok := e.Evaluate(inp, binding, false)
+		if !ok {
+			binding.GetState().Debug("map:  %s %+v: failed\n", k, n)
+			return nil, info, false
+		}
+
+		_, ok = mapped.(Expression)
+		if ok {
+			binding.GetState().Debug("map:  %s unresolved  -> KEEP\n", k)
+			return nil, info, true
+		}
+		binding.GetState().Debug("map:  %s --> %+v\n", k, mapped)
+		if mapped != nil {
+			result[k] = node(mapped, info)
+		}
+	}
+	return result, info, true
+}
+
+// resolveLambdaOperands resolves the source value and the lambda
+// of a mapping like expression. For unresolved operands the lambda
+// is nil.
+func resolveLambdaOperands(a *Expression, l *Expression, kind string, binding Binding) (interface{}, *LambdaValue, EvaluationInfo, bool) {
+	resolved := true
+
+	value, info, ok := ResolveExpressionOrPushEvaluation(a, &resolved, nil, binding, true)
+	if !ok {
+		return nil, nil, info, false
+	}
+	lvalue, infoe, ok := ResolveExpressionOrPushEvaluation(l, &resolved, nil, binding, false)
+	if !ok {
+		return nil, nil, info, false
+	}
+
+	if !resolved {
+		return nil, nil, info.Join(infoe), true
+	}
+
+	lambda, ok := lvalue.(LambdaValue)
+	if !ok {
+		_, infoe, _ = infoe.Error("%s requires a lambda value", kind)
+		return nil, nil, infoe, false
+	}
+	return value, &lambda, info, true
+}
+
 func getSortedKeys(unsortedMap map[string]yaml.Node) []string {
 	keys := make([]string, len(unsortedMap))
 	i := 0
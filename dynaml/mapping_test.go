@@ -30,4 +30,14 @@ var _ = Describe("mapping expressions", func() {
 		}.String()
 		Expect(desc).To(Equal("map[list|x|->x \".*\"]"))
 	})
+
+	It("prints map mapping and selection expressions", func() {
+		lambda := LambdaExpr{
+			[]string{"x"},
+			ReferenceExpr{[]string{"x"}},
+		}
+		Expect(MapMapExpr{ReferenceExpr{[]string{"map"}}, lambda}.String()).To(Equal("map{map|x|->x}"))
+		Expect(SelectExpr{ReferenceExpr{[]string{"list"}}, lambda}.String()).To(Equal("select[list|x|->x]"))
+		Expect(SelectMapExpr{ReferenceExpr{[]string{"map"}}, lambda}.String()).To(Equal("select{map|x|->x}"))
+	})
 })
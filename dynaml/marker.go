@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"strings"
 
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
@@ -12,6 +11,7 @@ const (
 	TEMPORARY = "&temporary"
 	TEMPLATE  = "&template"
 	LOCAL     = "&local"
+	DELETE    = "&delete"
 )
 
 type MarkerExpr struct {
@@ -34,6 +34,8 @@ func (e MarkerExpr) GetFlags() yaml.NodeFlags {
 			flags.SetTemporary()
 		case LOCAL:
 			flags.SetLocal()
+		case DELETE:
+			flags.SetDeleted()
 		}
 	}
 	return flags
@@ -74,14 +76,14 @@ func (e MarkerExpr) add(m string) MarkerExpr {
 	return e
 }
 
-func (e MarkerExpr) TemplateExpression(orig yaml.Node) yaml.Node {
+func (e MarkerExpr) TemplateExpression(orig yaml.Node, binding Binding) yaml.Node {
 	nlist := []string{}
 	for _, m := range e.list {
 		if m != TEMPLATE {
-			debug.Debug(" preserving marker %s", m)
+			binding.GetState().Debug(" preserving marker %s\n", m)
 			nlist = append(nlist, m)
 		} else {
-			debug.Debug(" omitting marker %s", m)
+			binding.GetState().Debug(" omitting marker %s\n", m)
 		}
 	}
 	if len(nlist) > 0 {
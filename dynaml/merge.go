@@ -1,8 +1,9 @@
 package dynaml
 
 import (
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"strings"
+
+	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
 type MergeExpr struct {
@@ -19,15 +20,17 @@ func (e MergeExpr) Evaluate(binding Binding, locally bool) (interface{}, Evaluat
 		info.RedirectPath = e.Path
 	}
 	info.KeyName = e.KeyName
-	debug.Debug("/// lookup %v\n", e.Path)
+	binding.GetState().Debug("/// lookup %v\n", e.Path)
 	node, ok := binding.FindInStubs(e.Path)
 	if ok {
+		binding.GetState().Explain(binding.Path(), "merge %s: %s from %s", strings.Join(e.Path, "."), FormatValue(node), yaml.Location(node))
 		info.Replace = e.Replace
 		info.Merged = true
 		info.Source = node.SourceName()
 		info.NodeFlags = node.Flags()
 		return node.Value(), info, ok
 	} else {
+		binding.GetState().Explain(binding.Path(), "merge %s: not found in any stub", strings.Join(e.Path, "."))
 		return info.Error("'%s' not found in any stub", strings.Join(e.Path, "."))
 	}
 }
@@ -2,6 +2,7 @@ package dynaml
 
 import (
 	"fmt"
+	"math"
 )
 
 type ModuloExpr struct {
@@ -12,12 +13,12 @@ type ModuloExpr struct {
 func (e ModuloExpr) Evaluate(binding Binding, locally bool) (interface{}, EvaluationInfo, bool) {
 	resolved := true
 
-	aint, info, ok := ResolveIntegerExpressionOrPushEvaluation(&e.A, &resolved, nil, binding, false)
+	a, info, ok := ResolveExpressionOrPushEvaluation(&e.A, &resolved, nil, binding, false)
 	if !ok {
 		return nil, info, false
 	}
 
-	bint, info, ok := ResolveIntegerExpressionOrPushEvaluation(&e.B, &resolved, &info, binding, false)
+	b, info, ok := ResolveExpressionOrPushEvaluation(&e.B, &resolved, &info, binding, false)
 	if !ok {
 		return nil, info, false
 	}
@@ -26,6 +27,26 @@ func (e ModuloExpr) Evaluate(binding Binding, locally bool) (interface{}, Evalua
 		return e, info, true
 	}
 
+	fa, fb, float, ok := floatOperands(a, b)
+	if float {
+		if !ok {
+			return info.Error("numeric operands required for MODULO")
+		}
+		if fb == 0 {
+			return info.Error("division by zero")
+		}
+		return math.Mod(fa, fb), info, true
+	}
+
+	aint, ok := a.(int64)
+	if !ok {
+		return info.Error("integer operand required")
+	}
+	bint, ok := b.(int64)
+	if !ok {
+		return info.Error("integer operand required")
+	}
+
 	if bint == 0 {
 		return info.Error("division by zero")
 	}
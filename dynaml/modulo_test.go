@@ -36,4 +36,15 @@ var _ = Describe("modulo", func() {
 			Expect(expr).To(FailToEvaluate(FakeBinding{}))
 		})
 	})
+
+	Context("when one side is a float", func() {
+		It("calculates the modulo of both numbers as floats", func() {
+			expr := ModuloExpr{
+				FloatExpr{5.5},
+				IntegerExpr{2},
+			}
+
+			Expect(expr).To(EvaluateAs(1.5, FakeBinding{}))
+		})
+	})
 })
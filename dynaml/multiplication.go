@@ -18,7 +18,7 @@ func (e MultiplicationExpr) Evaluate(binding Binding, locally bool) (interface{}
 		return nil, info, false
 	}
 
-	bint, info, ok := ResolveIntegerExpressionOrPushEvaluation(&e.B, &resolved, &info, binding, false)
+	b, info, ok := ResolveExpressionOrPushEvaluation(&e.B, &resolved, &info, binding, false)
 	if !ok {
 		return nil, info, false
 	}
@@ -27,6 +27,19 @@ func (e MultiplicationExpr) Evaluate(binding Binding, locally bool) (interface{}
 		return e, info, true
 	}
 
+	fa, fb, float, ok := floatOperands(a, b)
+	if float {
+		if !ok {
+			return info.Error("numeric operands required for MULTIPLICATION")
+		}
+		return fa * fb, info, true
+	}
+
+	bint, ok := b.(int64)
+	if !ok {
+		return info.Error("integer operand required")
+	}
+
 	aint, ok := a.(int64)
 	if ok {
 		return aint * bint, info, true
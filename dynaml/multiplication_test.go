@@ -47,4 +47,15 @@ var _ = Describe("multiplication", func() {
 			Expect(expr).To(EvaluateAs("10.1.5.0/24", FakeBinding{}))
 		})
 	})
+
+	Context("when one side is a float", func() {
+		It("multiplies both numbers as floats", func() {
+			expr := MultiplicationExpr{
+				FloatExpr{1.5},
+				IntegerExpr{3},
+			}
+
+			Expect(expr).To(EvaluateAs(4.5, FakeBinding{}))
+		})
+	})
 })
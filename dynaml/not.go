@@ -2,8 +2,6 @@ package dynaml
 
 import (
 	"fmt"
-
-	"github.com/cloudfoundry-incubator/spiff/debug"
 )
 
 type NotExpr struct {
@@ -20,7 +18,7 @@ func (e NotExpr) Evaluate(binding Binding, locally bool) (interface{}, Evaluatio
 		return e, info, true
 	}
 
-	debug.Debug("NOT: %#v\n", v)
+	binding.GetState().Debug("NOT: %#v\n", v)
 	return !toBool(v), info, true
 }
 
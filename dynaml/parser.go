@@ -4,8 +4,6 @@ import (
 	"container/list"
 	"strconv"
 	"strings"
-
-	"github.com/cloudfoundry-incubator/spiff/debug"
 )
 
 type helperNode struct{}
@@ -82,11 +80,9 @@ func buildExpression(grammar *DynamlGrammar, path []string, stubPath []string) E
 			required = false
 			keyName = ""
 		case ruleSimpleMerge:
-			debug.Debug("*** rule simple merge\n")
 			redirect := !equals(path, stubPath)
 			tokens.Push(MergeExpr{stubPath, redirect, replace, replace || required || redirect, keyName})
 		case ruleRefMerge:
-			debug.Debug("*** rule ref merge\n")
 			rhs := tokens.Pop()
 			tokens.Push(MergeExpr{rhs.(ReferenceExpr).Path, true, replace, true, keyName})
 		case ruleReplace:
@@ -94,7 +90,12 @@ func buildExpression(grammar *DynamlGrammar, path []string, stubPath []string) E
 		case ruleRequired:
 			required = true
 		case ruleOn:
-			keyName = tokens.Pop().(nameHelper).name
+			switch names := tokens.Pop().(type) {
+			case nameHelper:
+				keyName = names.name
+			case nameListHelper:
+				keyName = strings.Join(names.list, ",")
+			}
 		case ruleFollowUpRef:
 		case ruleReference:
 			tokens.Push(ReferenceExpr{strings.Split(contents, ".")})
@@ -120,6 +121,13 @@ func buildExpression(grammar *DynamlGrammar, path []string, stubPath []string) E
 				Arguments: tokens.GetExpressionList(),
 			})
 
+		case ruleFloat:
+			val, err := strconv.ParseFloat(strings.Replace(contents, "_", "", -1), 64)
+			if err != nil {
+				panic(err)
+			}
+
+			tokens.Push(FloatExpr{val})
 		case ruleInteger:
 			val, err := strconv.ParseInt(contents, 10, 64)
 			if err != nil {
@@ -232,6 +240,21 @@ func buildExpression(grammar *DynamlGrammar, path []string, stubPath []string) E
 			lhs := tokens.Pop()
 			tokens.Push(MapExpr{Lambda: rhs, A: lhs})
 
+		case ruleMapMapping:
+			rhs := tokens.Pop()
+			lhs := tokens.Pop()
+			tokens.Push(MapMapExpr{Lambda: rhs, A: lhs})
+
+		case ruleSelection:
+			rhs := tokens.Pop()
+			lhs := tokens.Pop()
+			tokens.Push(SelectExpr{Lambda: rhs, A: lhs})
+
+		case ruleMapSelection:
+			rhs := tokens.Pop()
+			lhs := tokens.Pop()
+			tokens.Push(SelectMapExpr{Lambda: rhs, A: lhs})
+
 		case ruleSum:
 			rhs := tokens.Pop()
 			ini := tokens.Pop()
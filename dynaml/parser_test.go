@@ -16,6 +16,25 @@ var _ = Describe("parsing", func() {
 		})
 	})
 
+	Describe("floats", func() {
+		It("parses positive numbers", func() {
+			parsesAs("1.5", FloatExpr{1.5})
+		})
+
+		It("parses negative numbers", func() {
+			parsesAs("-1.5", FloatExpr{-1.5})
+		})
+
+		It("parses numbers with exponent", func() {
+			parsesAs("1.5e3", FloatExpr{1500})
+			parsesAs("1.5E-3", FloatExpr{0.0015})
+		})
+
+		It("parses numbers with separators", func() {
+			parsesAs("1_000.5", FloatExpr{1000.5})
+		})
+	})
+
 	Describe("strings", func() {
 		It("parses strings with escaped quotes", func() {
 			parsesAs(`"foo \"bar\" baz"`, StringExpr{`foo "bar" baz`})
@@ -63,6 +82,11 @@ var _ = Describe("parsing", func() {
 		It("parses as a merge require node", func() {
 			parsesAs("merge on key alice.bob", MergeExpr{[]string{"alice", "bob"}, true, false, true, "key"}, "foo", "bar")
 		})
+
+		It("parses as a merge node with a composite key", func() {
+			parsesAs("merge on name, az", MergeExpr{[]string{"foo", "bar"}, false, false, false, "name,az"}, "foo", "bar")
+			parsesAs("merge on name,az alice.bob", MergeExpr{[]string{"alice", "bob"}, true, false, true, "name,az"}, "foo", "bar")
+		})
 	})
 
 	Describe("auto", func() {
@@ -440,6 +464,59 @@ var _ = Describe("parsing", func() {
 		})
 	})
 
+	Describe("map mapping", func() {
+		It("parses mapping to map", func() {
+			parsesAs(
+				`map{map|k,v|->v}`,
+				MapMapExpr{
+					ReferenceExpr{[]string{"map"}},
+					LambdaExpr{
+						[]string{"k", "v"},
+						ReferenceExpr{[]string{"v"}},
+					},
+				},
+			)
+		})
+	})
+
+	Describe("selection", func() {
+		It("parses selection", func() {
+			parsesAs(
+				`select[list|x|->x]`,
+				SelectExpr{
+					ReferenceExpr{[]string{"list"}},
+					LambdaExpr{
+						[]string{"x"},
+						ReferenceExpr{[]string{"x"}},
+					},
+				},
+			)
+		})
+
+		It("parses filter as selection", func() {
+			parsesAs(
+				`filter[list|conditions.a]`,
+				SelectExpr{
+					ReferenceExpr{[]string{"list"}},
+					ReferenceExpr{[]string{"conditions", "a"}},
+				},
+			)
+		})
+
+		It("parses selection to map", func() {
+			parsesAs(
+				`filter{map|k,v|->v}`,
+				SelectMapExpr{
+					ReferenceExpr{[]string{"map"}},
+					LambdaExpr{
+						[]string{"k", "v"},
+						ReferenceExpr{[]string{"v"}},
+					},
+				},
+			)
+		})
+	})
+
 	Describe("lambda expressions", func() {
 		It("parses expression with one parameter", func() {
 			parsesAs(
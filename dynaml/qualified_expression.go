@@ -3,7 +3,6 @@ package dynaml
 import (
 	"fmt"
 
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
@@ -25,7 +24,7 @@ func (e QualifiedExpr) Evaluate(binding Binding, locally bool) (interface{}, Eva
 		return e, info, true
 	}
 
-	debug.Debug("qualified reference: %v\n", e.Reference.Path)
+	binding.GetState().Debug("qualified reference: %v\n", e.Reference.Path)
 	return e.Reference.find(func(end int, path []string) (yaml.Node, bool) {
 		return yaml.Find(node(root, nil), e.Reference.Path[0:end+1]...)
 	}, binding, locally)
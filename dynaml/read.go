@@ -1,16 +1,12 @@
 package dynaml
 
 import (
-	"io/ioutil"
 	"path"
 	"strings"
 
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
-var fileCache = map[string][]byte{}
-
 func func_read(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
 	info := DefaultInfo()
 
@@ -27,6 +23,9 @@ func func_read(arguments []interface{}, binding Binding) (interface{}, Evaluatio
 	if strings.HasSuffix(file, ".yml") {
 		t = "yaml"
 	}
+	if strings.HasSuffix(file, ".json") {
+		t = "json"
+	}
 	if len(arguments) > 1 {
 		t, ok = arguments[1].(string)
 		if !ok {
@@ -35,31 +34,33 @@ func func_read(arguments []interface{}, binding Binding) (interface{}, Evaluatio
 
 	}
 
-	var err error
-
-	data := fileCache[file]
-	if data == nil {
-		debug.Debug("reading %s file %s\n", t, file)
-		data, err = ioutil.ReadFile(file)
-		if err != nil {
-			return info.Error("error reading [%s]: %s", path.Clean(file), err)
-		}
-		fileCache[file] = data
+	if err := binding.GetState().GetSandbox().CheckFile(file); err != nil {
+		return info.Error("%s", err)
+	}
+	binding.GetState().Debug("reading %s file %s\n", t, file)
+	data, err := binding.GetState().GetFileContent(file)
+	if err != nil {
+		return info.Error("error reading [%s]: %s", path.Clean(file), err)
 	}
 
 	switch t {
-	case "yaml":
-		node, err := yaml.Parse(file, data)
+	case "yaml", "json":
+		var node yaml.Node
+		if t == "json" {
+			node, err = yaml.ParseJSON(file, data)
+		} else {
+			node, err = yaml.Parse(file, data)
+		}
 		if err != nil {
 			return info.Error("error parsing stub [%s]: %s", path.Clean(file), err)
 		}
-		debug.Debug("resolving yaml file\n")
+		binding.GetState().Debug("resolving yaml file\n")
 		result, state := binding.Flow(node, false)
 		if state != nil {
-			debug.Debug("resolving yaml file failed: " + state.Error())
+			binding.GetState().Debug("resolving yaml file failed: " + state.Error())
 			return info.Error("yaml file resolution failed")
 		}
-		debug.Debug("resolving yaml file succeeded")
+		binding.GetState().Debug("resolving yaml file succeeded")
 		info.Source = file
 		return result.Value(), info, true
 
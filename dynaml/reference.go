@@ -1,9 +1,9 @@
 package dynaml
 
 import (
+	"fmt"
 	"strings"
 
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
@@ -14,7 +14,7 @@ type ReferenceExpr struct {
 func (e ReferenceExpr) Evaluate(binding Binding, locally bool) (interface{}, EvaluationInfo, bool) {
 	fromRoot := e.Path[0] == ""
 
-	debug.Debug("reference: %v\n", e.Path)
+	binding.GetState().Debug("reference: %v\n", e.Path)
 	return e.find(func(end int, path []string) (yaml.Node, bool) {
 		if fromRoot {
 			return binding.FindFromRoot(path[1 : end+1])
@@ -34,33 +34,75 @@ func (e ReferenceExpr) find(f func(int, []string) (node yaml.Node, x bool), bind
 
 	info := DefaultInfo()
 	for i := 0; i < len(e.Path); i++ {
+		prev := step
 		step, ok = f(i, e.Path)
 
-		debug.Debug("  %d: %v %+v\n", i, ok, step)
-		if !ok {
-			return info.Error("'%s' not found", strings.Join(e.Path[0:i+1], "."))
+		binding.GetState().Debug("  %d: %v %+v\n", i, ok, step)
+		if !ok || step.Undefined() {
+			msg := fmt.Sprintf("'%s' not found%s", strings.Join(e.Path[0:i+1], "."), e.suggest(i, prev, binding))
+			binding.GetState().Explain(binding.Path(), "reference %s: %s", e, msg)
+			return info.Error("%s", msg)
 		}
 
 		if !isLocallyResolved(step) {
-			debug.Debug("  locally unresolved\n")
+			binding.GetState().Debug("  locally unresolved\n")
 			if _, ok := step.Value().(Expression); ok {
 				info.Issue = yaml.NewIssue("'%s' unresolved", strings.Join(e.Path[0:i+1], "."))
 			} else {
 				info.Issue = yaml.NewIssue("'%s' not complete", strings.Join(e.Path[0:i+1], "."))
 			}
 			info.Failed = step.Failed() || step.HasError()
+			binding.GetState().Explain(binding.Path(), "reference %s: %s", e, info.Issue.Issue)
 			return e, info, true
 		}
 	}
 
 	if !locally && !isResolvedValue(step.Value()) {
-		debug.Debug("  unresolved\n")
+		binding.GetState().Debug("  unresolved\n")
 		info.Issue = yaml.NewIssue("'%s' unresolved", strings.Join(e.Path, "."))
 		info.Failed = step.Failed() || step.HasError()
+		binding.GetState().Explain(binding.Path(), "reference %s: %s", e, info.Issue.Issue)
 		return e, info, true
 	}
 
-	debug.Debug("reference %v -> %+v\n", e.Path, step)
+	binding.GetState().Debug("reference %v -> %+v\n", e.Path, step)
+	binding.GetState().Explain(binding.Path(), "reference %s: %s from %s", e, FormatValue(step), yaml.Location(step))
 	info.KeyName = step.KeyName()
 	return value(yaml.ReferencedNode(step)), info, true
 }
+
+// suggest proposes existing paths for a reference failing at the
+// given step. The preceding part of the path could be resolved to
+// the given node (nil for the first step).
+func (e ReferenceExpr) suggest(i int, prev yaml.Node, binding Binding) string {
+	var candidates []string
+	if i == 0 {
+		if root, ok := binding.FindFromRoot([]string{}); ok {
+			candidates = stepCandidates(root, e.Path[0])
+		}
+		for k := range binding.GetLocalBinding() {
+			candidates = append(candidates, k)
+		}
+	} else {
+		candidates = stepCandidates(prev, e.Path[i])
+	}
+
+	// the node under evaluation would be a cyclic reference
+	self := strings.Join(binding.Path(), ".")
+	filtered := []string{}
+	for _, c := range candidates {
+		if strings.Join(append(e.Path[0:i:i], c), ".") != self {
+			filtered = append(filtered, c)
+		}
+	}
+
+	names := suggestNames(e.Path[i], filtered)
+	if len(names) == 0 {
+		return ""
+	}
+	paths := []string{}
+	for _, n := range names {
+		paths = append(paths, "'"+strings.Join(append(e.Path[0:i:i], n), ".")+"'")
+	}
+	return " (did you mean " + strings.Join(paths, " or ") + "?)"
+}
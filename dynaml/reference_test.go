@@ -45,5 +45,72 @@ var _ = Describe("references", func() {
 
 			Expect(expr).To(FailToEvaluate(binding))
 		})
+
+		It("suggests close names of map fields", func() {
+			expr := ReferenceExpr{[]string{"foo", "propertes", "baz"}}
+
+			binding := FakeBinding{
+				FoundReferences: map[string]yaml.Node{
+					"foo": node(map[string]yaml.Node{
+						"properties": node(1, nil),
+						"providers":  node(2, nil),
+						"others":     node(3, nil),
+					}, nil),
+				},
+			}
+
+			_, info, ok := expr.Evaluate(binding, false)
+			Expect(ok).To(BeFalse())
+			Expect(info.Issue.Issue).To(Equal("'foo.propertes' not found (did you mean 'foo.properties'?)"))
+		})
+
+		It("suggests close names of list entries", func() {
+			expr := ReferenceExpr{[]string{"jobs", "name:wbe"}}
+
+			binding := FakeBinding{
+				FoundReferences: map[string]yaml.Node{
+					"jobs": node([]yaml.Node{
+						node(map[string]yaml.Node{"name": node("web", nil)}, nil),
+						node(map[string]yaml.Node{"name": node("db", nil)}, nil),
+					}, nil),
+				},
+			}
+
+			_, info, ok := expr.Evaluate(binding, false)
+			Expect(ok).To(BeFalse())
+			Expect(info.Issue.Issue).To(Equal("'jobs.name:wbe' not found (did you mean 'jobs.name:web'?)"))
+		})
+
+		It("does not suggest the node under evaluation", func() {
+			expr := ReferenceExpr{[]string{"props", "b"}}
+
+			binding := FakeBinding{
+				FoundReferences: map[string]yaml.Node{
+					"props": node(map[string]yaml.Node{
+						"a": node(1, nil),
+						"c": node(expr, nil),
+					}, nil),
+				},
+				path: []string{"props", "c"},
+			}
+
+			_, info, ok := expr.Evaluate(binding, false)
+			Expect(ok).To(BeFalse())
+			Expect(info.Issue.Issue).To(Equal("'props.b' not found (did you mean 'props.a'?)"))
+		})
+
+		It("suggests nothing for unrelated names", func() {
+			expr := ReferenceExpr{[]string{"foo", "zzz"}}
+
+			binding := FakeBinding{
+				FoundReferences: map[string]yaml.Node{
+					"foo": node(map[string]yaml.Node{"bar": node(1, nil)}, nil),
+				},
+			}
+
+			_, info, ok := expr.Evaluate(binding, false)
+			Expect(ok).To(BeFalse())
+			Expect(info.Issue.Issue).To(Equal("'foo.zzz' not found"))
+		})
 	})
 })
@@ -0,0 +1,23 @@
+package dynaml
+
+import (
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+func func_reverse(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("reverse takes exactly one argument")
+	}
+
+	list, ok := arguments[0].([]yaml.Node)
+	if !ok {
+		return info.Error("argument for reverse must be a list")
+	}
+	result := make([]yaml.Node, len(list))
+	for i, n := range list {
+		result[len(list)-1-i] = n
+	}
+	return result, info, true
+}
@@ -0,0 +1,71 @@
+package dynaml
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Sandbox restricts the access of dynaml functions to the host
+// (safe mode). Commands lists the commands allowed for exec,
+// Environment the allowed prefixes of environment variable names
+// and Directories the directories files may be read from.
+// Without a sandbox (nil) there are no restrictions.
+type Sandbox struct {
+	Commands    []string
+	Environment []string
+	Directories []string
+}
+
+func (s *Sandbox) CheckCommand(cmd string) error {
+	if s == nil {
+		return nil
+	}
+	for _, c := range s.Commands {
+		if c == cmd {
+			return nil
+		}
+	}
+	return fmt.Errorf("command '%s' not allowed in safe mode", cmd)
+}
+
+func (s *Sandbox) CheckEnvironment(name string) error {
+	if s == nil {
+		return nil
+	}
+	for _, p := range s.Environment {
+		if strings.HasPrefix(name, p) {
+			return nil
+		}
+	}
+	return fmt.Errorf("environment variable '%s' not allowed in safe mode", name)
+}
+
+func (s *Sandbox) CheckFile(file string) error {
+	if s == nil {
+		return nil
+	}
+	path := canonicalPath(file)
+	for _, d := range s.Directories {
+		dir := canonicalPath(d)
+		if path == dir || strings.HasPrefix(path, dir+string(os.PathSeparator)) || dir == string(os.PathSeparator) {
+			return nil
+		}
+	}
+	return fmt.Errorf("file '%s' not allowed in safe mode", file)
+}
+
+// canonicalPath provides an absolute path with symbolic links
+// resolved, so links cannot be used to leave allowed directories.
+func canonicalPath(path string) string {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		abs = filepath.Clean(path)
+	}
+	resolved, err := filepath.EvalSymlinks(abs)
+	if err != nil {
+		return abs
+	}
+	return resolved
+}
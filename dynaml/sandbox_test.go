@@ -0,0 +1,62 @@
+package dynaml
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("sandbox", func() {
+	It("allows everything without sandbox", func() {
+		var sandbox *Sandbox
+		Expect(sandbox.CheckCommand("rm")).To(Succeed())
+		Expect(sandbox.CheckEnvironment("HOME")).To(Succeed())
+		Expect(sandbox.CheckFile("/etc/passwd")).To(Succeed())
+	})
+
+	It("denies everything for an empty sandbox", func() {
+		sandbox := &Sandbox{}
+		Expect(sandbox.CheckCommand("rm")).To(MatchError("command 'rm' not allowed in safe mode"))
+		Expect(sandbox.CheckEnvironment("HOME")).To(MatchError("environment variable 'HOME' not allowed in safe mode"))
+		Expect(sandbox.CheckFile("/etc/passwd")).To(MatchError("file '/etc/passwd' not allowed in safe mode"))
+	})
+
+	It("allows commands and environment variable prefixes", func() {
+		sandbox := &Sandbox{Commands: []string{"echo"}, Environment: []string{"CI_"}}
+		Expect(sandbox.CheckCommand("echo")).To(Succeed())
+		Expect(sandbox.CheckCommand("/bin/echo")).NotTo(Succeed())
+		Expect(sandbox.CheckEnvironment("CI_JOB")).To(Succeed())
+		Expect(sandbox.CheckEnvironment("HOME")).NotTo(Succeed())
+	})
+
+	Context("with directories", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+			dir, err = ioutil.TempDir(os.TempDir(), "sandbox")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(os.Mkdir(filepath.Join(dir, "allowed"), 0755)).To(Succeed())
+			Expect(os.Symlink("/etc", filepath.Join(dir, "allowed", "link"))).To(Succeed())
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("allows files below the directories only", func() {
+			sandbox := &Sandbox{Directories: []string{filepath.Join(dir, "allowed")}}
+			Expect(sandbox.CheckFile(filepath.Join(dir, "allowed", "file.yml"))).To(Succeed())
+			Expect(sandbox.CheckFile(filepath.Join(dir, "allowed", "..", "file.yml"))).NotTo(Succeed())
+			Expect(sandbox.CheckFile(filepath.Join(dir, "allowedfile.yml"))).NotTo(Succeed())
+		})
+
+		It("does not follow symbolic links out of the directories", func() {
+			sandbox := &Sandbox{Directories: []string{filepath.Join(dir, "allowed")}}
+			Expect(sandbox.CheckFile(filepath.Join(dir, "allowed", "link", "passwd"))).NotTo(Succeed())
+		})
+	})
+})
@@ -0,0 +1,169 @@
+package dynaml
+
+import (
+	"fmt"
+
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+// SelectExpr selects the entries of a list or the values of a map
+// (select[...] or filter[...]) matching a condition and provides
+// them as list.
+type SelectExpr struct {
+	A      Expression
+	Lambda Expression
+}
+
+func (e SelectExpr) Evaluate(binding Binding, locally bool) (interface{}, EvaluationInfo, bool) {
+	value, lambda, info, ok := resolveLambdaOperands(&e.A, &e.Lambda, "selection", binding)
+	if !ok {
+		return nil, info, false
+	}
+	if lambda == nil {
+		return e, info, true
+	}
+
+	binding.GetState().Debug("select: using lambda %+v\n", lambda)
+	var result []yaml.Node
+	switch v := value.(type) {
+	case []yaml.Node:
+		result, info, ok = selectList(v, *lambda, binding)
+
+	case map[string]yaml.Node:
+		var selected map[string]yaml.Node
+		selected, info, ok = selectMap(v, *lambda, binding)
+		if selected != nil {
+			result = []yaml.Node{}
+			for _, k := range getSortedKeys(selected) {
+				result = append(result, selected[k])
+			}
+		}
+
+	default:
+		return info.Error("map or list required for selection")
+	}
+	if !ok {
+		return nil, info, false
+	}
+	if result == nil {
+		return e, info, true
+	}
+	binding.GetState().Debug("select: --> %+v\n", result)
+	return result, info, true
+}
+
+func (e SelectExpr) String() string {
+	lambda, ok := e.Lambda.(LambdaExpr)
+	if ok {
+		return fmt.Sprintf("select[%s%s]", e.A, fmt.Sprintf("%s", lambda)[len("lambda"):])
+	} else {
+		return fmt.Sprintf("select[%s|%s]", e.A, e.Lambda)
+	}
+}
+
+// SelectMapExpr selects the entries of a map (select{...} or
+// filter{...}) matching a condition and provides them as map.
+type SelectMapExpr struct {
+	A      Expression
+	Lambda Expression
+}
+
+func (e SelectMapExpr) Evaluate(binding Binding, locally bool) (interface{}, EvaluationInfo, bool) {
+	value, lambda, info, ok := resolveLambdaOperands(&e.A, &e.Lambda, "selection", binding)
+	if !ok {
+		return nil, info, false
+	}
+	if lambda == nil {
+		return e, info, true
+	}
+
+	source, ok := value.(map[string]yaml.Node)
+	if !ok {
+		return info.Error("map required for selection to map")
+	}
+
+	binding.GetState().Debug("select: using lambda %+v\n", lambda)
+	result, info, ok := selectMap(source, *lambda, binding)
+	if !ok {
+		return nil, info, false
+	}
+	if result == nil {
+		return e, info, true
+	}
+	binding.GetState().Debug("select: --> %+v\n", result)
+	return result, info, true
+}
+
+func (e SelectMapExpr) String() string {
+	lambda, ok := e.Lambda.(LambdaExpr)
+	if ok {
+		return fmt.Sprintf("select{%s%s}", e.A, fmt.Sprintf("%s", lambda)[len("lambda"):])
+	} else {
+		return fmt.Sprintf("select{%s|%s}", e.A, e.Lambda)
+	}
+}
+
+func selectList(source []yaml.Node, e LambdaValue, binding Binding) ([]yaml.Node, EvaluationInfo, bool) {
+	inp := make([]interface{}, len(e.lambda.Names))
+	result := []yaml.Node{}
+	info := DefaultInfo()
+
+	if len(e.lambda.Names) > 2 {
+		info.Error("selection expression take a maximum of 2 arguments")
+		return nil, info, false
+	}
+	for i, n := range source {
+		binding.GetState().Debug("select:  checking %d: %+v\n", i, n)
+		inp[0] = i
+		inp[len(inp)-1] = n.Value()
+		cond, info, ok := e.Evaluate(inp, binding, false)
+		if !ok {
+			binding.GetState().Debug("select:  %d %+v: failed\n", i, n)
+			return nil, info, false
+		}
+
+		_, ok = cond.(Expression)
+		if ok {
+			binding.GetState().Debug("select:  %d unresolved  -> KEEP\n", i)
+			return nil, info, true
+		}
+		binding.GetState().Debug("select:  %d --> %+v\n", i, cond)
+		if toBool(cond) {
+			result = append(result, n)
+		}
+	}
+	return result, info, true
+}
+
+func selectMap(source map[string]yaml.Node, e LambdaValue, binding Binding) (map[string]yaml.Node, EvaluationInfo, bool) {
+	inp := make([]interface{}, len(e.lambda.Names))
+	result := map[string]yaml.Node{}
+	info := DefaultInfo()
+
+	if len(e.lambda.Names) > 2 {
+		info.Error("selection expression take a maximum of 2 arguments")
+		return nil, info, false
+	}
+	for _, k := range getSortedKeys(source) {
+		n := source[k]
+		binding.GetState().Debug("select:  checking %s: %+v\n", k, n)
+		inp[0] = k
+		inp[len(inp)-1] = n.Value()
+		cond, info, ok := e.Evaluate(inp, binding, false)
+		if !ok {
+			binding.GetState().Debug("select:  %s %+v: failed\n", k, n)
+			return nil, info, false
+		}
+
+		_, ok = cond.(Expression)
+		if ok {
+			binding.GetState().Debug("select:  %s unresolved  -> KEEP\n", k)
+			return nil, info, true
+		}
+		binding.GetState().Debug("select:  %s --> %+v\n", k, cond)
+		if toBool(cond) {
+			result[k] = n
+		}
+	}
+	return result, info, true
+}
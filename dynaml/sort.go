@@ -0,0 +1,111 @@
+package dynaml
+
+import (
+	"sort"
+
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+func func_sort(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) < 1 || len(arguments) > 2 {
+		return info.Error("sort takes one or two arguments")
+	}
+
+	list, ok := arguments[0].([]yaml.Node)
+	if !ok {
+		return info.Error("first argument for sort must be a list")
+	}
+	result := make([]yaml.Node, len(list))
+	copy(result, list)
+
+	if len(arguments) == 2 {
+		lambda, ok := arguments[1].(LambdaValue)
+		if !ok {
+			return info.Error("comparator for sort must be a lambda value")
+		}
+		if len(lambda.lambda.Names) != 2 {
+			return info.Error("comparator for sort must take 2 arguments")
+		}
+		sorter := &lambdaSorter{nodes: result, lambda: lambda, binding: binding, info: info}
+		sort.Stable(sorter)
+		if sorter.failed {
+			return nil, sorter.info, false
+		}
+		if sorter.unresolved {
+			return nil, sorter.info, true
+		}
+		return result, info, true
+	}
+
+	numbers, strings := true, true
+	for _, n := range result {
+		_, isNumber := toFloat(n.Value())
+		_, isString := n.Value().(string)
+		numbers = numbers && isNumber
+		strings = strings && isString
+	}
+	switch {
+	case numbers:
+		sort.Stable(valueSorter{result, func(a, b interface{}) bool {
+			fa, _ := toFloat(a)
+			fb, _ := toFloat(b)
+			return fa < fb
+		}})
+	case strings:
+		sort.Stable(valueSorter{result, func(a, b interface{}) bool {
+			return a.(string) < b.(string)
+		}})
+	default:
+		return info.Error("sort without comparator requires a list of numbers or strings")
+	}
+	return result, info, true
+}
+
+type valueSorter struct {
+	nodes []yaml.Node
+	less  func(a, b interface{}) bool
+}
+
+func (s valueSorter) Len() int           { return len(s.nodes) }
+func (s valueSorter) Swap(i, j int)      { s.nodes[i], s.nodes[j] = s.nodes[j], s.nodes[i] }
+func (s valueSorter) Less(i, j int) bool { return s.less(s.nodes[i].Value(), s.nodes[j].Value()) }
+
+// lambdaSorter sorts nodes using a lambda comparator returning
+// whether its first argument is less than the second one.
+// Evaluation problems stop the comparison.
+type lambdaSorter struct {
+	nodes   []yaml.Node
+	lambda  LambdaValue
+	binding Binding
+
+	info       EvaluationInfo
+	failed     bool
+	unresolved bool
+}
+
+func (s *lambdaSorter) Len() int      { return len(s.nodes) }
+func (s *lambdaSorter) Swap(i, j int) { s.nodes[i], s.nodes[j] = s.nodes[j], s.nodes[i] }
+
+func (s *lambdaSorter) Less(i, j int) bool {
+	if s.failed || s.unresolved {
+		return false
+	}
+	result, info, ok := s.lambda.Evaluate([]interface{}{s.nodes[i].Value(), s.nodes[j].Value()}, s.binding, false)
+	if !ok {
+		s.info, s.failed = info, true
+		return false
+	}
+	if _, ok := result.(Expression); ok {
+		s.unresolved = true
+		return false
+	}
+	less, ok := result.(bool)
+	if !ok {
+		s.info.Error("comparator for sort must return a boolean value")
+		s.failed = true
+		return false
+	}
+	return less
+}
@@ -0,0 +1,43 @@
+package dynaml
+
+import (
+	"io/ioutil"
+)
+
+// FileSystem is used by dynaml functions to access files.
+type FileSystem interface {
+	ReadFile(path string) ([]byte, error)
+}
+
+type osFileSystem struct{}
+
+func (osFileSystem) ReadFile(path string) ([]byte, error) {
+	return ioutil.ReadFile(path)
+}
+
+// OSFileSystem provides access to the file system of the operating system.
+var OSFileSystem FileSystem = osFileSystem{}
+
+// State describes the processing state shared by all bindings
+// used to process a document and its stubs. It replaces any
+// process global state, so independent processing runs don't
+// interfere with each other.
+type State interface {
+	// GetFileContent reads a file once per processing run.
+	GetFileContent(file string) ([]byte, error)
+	// Getenv looks up an environment variable.
+	Getenv(name string) (string, bool)
+	// Execute executes a command once per set of arguments.
+	Execute(args []string) ([]byte, error)
+	// FunctionAllowed checks whether a function may be called.
+	FunctionAllowed(name string) bool
+	// GetFunctions provides the registry of callable functions.
+	GetFunctions() Registry
+	// GetSandbox provides the access restrictions (nil for none).
+	GetSandbox() *Sandbox
+
+	Debug(fmt string, args ...interface{})
+	// Explain records an evaluation step of the node with the given
+	// path, if the evaluation of this node should be explained.
+	Explain(path []string, fmt string, args ...interface{})
+}
@@ -27,6 +27,14 @@ func (e SubtractionExpr) Evaluate(binding Binding, locally bool) (interface{}, E
 		return e, info, true
 	}
 
+	fa, fb, float, ok := floatOperands(a, b)
+	if float {
+		if !ok {
+			return info.Error("numeric operands required for MINUS")
+		}
+		return fa - fb, info, true
+	}
+
 	aint, ok := a.(int64)
 	bint, bok := b.(int64)
 	if ok {
@@ -58,7 +66,7 @@ func (e SubtractionExpr) Evaluate(binding Binding, locally bool) (interface{}, E
 		}
 		return info.Error("string argument for MINUS must be an IP address")
 	}
-	return info.Error("first argument of MINUS must be IP address or integer")
+	return info.Error("first argument of MINUS must be IP address or number")
 }
 
 func (e SubtractionExpr) String() string {
@@ -56,4 +56,15 @@ var _ = Describe("subtraction", func() {
 			Expect(expr).To(EvaluateAs("10.10.9.9", FakeBinding{}))
 		})
 	})
+
+	Context("when one side is a float", func() {
+		It("subtracts both numbers as floats", func() {
+			expr := SubtractionExpr{
+				IntegerExpr{3},
+				FloatExpr{0.5},
+			}
+
+			Expect(expr).To(EvaluateAs(2.5, FakeBinding{}))
+		})
+	})
 })
@@ -0,0 +1,124 @@
+package dynaml
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+// maxSuggestions limits the number of proposed alternatives
+const maxSuggestions = 3
+
+// suggestNames proposes candidates close to the given name,
+// ordered by their edit distance. Key names of list entries
+// are not compared.
+func suggestNames(name string, candidates []string) []string {
+	name = yaml.PathComponent(name)
+	limit := len(name) / 3
+	if limit < 1 {
+		limit = 1
+	}
+	type match struct {
+		name     string
+		distance int
+	}
+	matches := []match{}
+	seen := map[string]bool{}
+	for _, c := range candidates {
+		if seen[c] {
+			continue
+		}
+		seen[c] = true
+		if d := editDistance(name, yaml.PathComponent(c)); d <= limit {
+			matches = append(matches, match{c, d})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].distance != matches[j].distance {
+			return matches[i].distance < matches[j].distance
+		}
+		return matches[i].name < matches[j].name
+	})
+
+	result := []string{}
+	for i := 0; i < len(matches) && i < maxSuggestions; i++ {
+		result = append(result, matches[i].name)
+	}
+	return result
+}
+
+// stepCandidates lists the steps usable to step into a node
+// instead of the given unresolvable one. Map entries are addressed
+// by their keys, list entries by the value of their key field.
+func stepCandidates(node yaml.Node, step string) []string {
+	candidates := []string{}
+	if node == nil {
+		return candidates
+	}
+	switch v := node.Value().(type) {
+	case map[string]yaml.Node:
+		for k := range v {
+			if k != "<<" {
+				candidates = append(candidates, k)
+			}
+		}
+	case []yaml.Node:
+		key := node.KeyName()
+		if key == "" {
+			key = "name"
+		}
+		prefix := ""
+		if i := strings.Index(step, ":"); i > 0 {
+			key = step[:i]
+			prefix = step[:i+1]
+		}
+		for _, e := range v {
+			if m, ok := e.Value().(map[string]yaml.Node); ok {
+				if name, ok := m[key]; ok {
+					if s, ok := name.Value().(string); ok {
+						candidates = append(candidates, prefix+s)
+					}
+				}
+			}
+		}
+	}
+	return candidates
+}
+
+// editDistance is the optimal string alignment distance of two
+// strings (Levenshtein distance counting transpositions as one edit).
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	d := make([][]int, len(ra)+1)
+	for i := range d {
+		d[i] = make([]int, len(rb)+1)
+		d[i][0] = i
+	}
+	for j := range d[0] {
+		d[0][j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			d[i][j] = min3(d[i-1][j]+1, d[i][j-1]+1, d[i-1][j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] && d[i-2][j-2]+1 < d[i][j] {
+				d[i][j] = d[i-2][j-2] + 1
+			}
+		}
+	}
+	return d[len(ra)][len(rb)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+	return a
+}
@@ -3,7 +3,6 @@ package dynaml
 import (
 	"fmt"
 
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
@@ -16,7 +15,7 @@ type SumExpr struct {
 func (e SumExpr) Evaluate(binding Binding, locally bool) (interface{}, EvaluationInfo, bool) {
 	resolved := true
 
-	debug.Debug("evaluate sum")
+	binding.GetState().Debug("evaluate sum")
 	value, info, ok := ResolveExpressionOrPushEvaluation(&e.A, &resolved, nil, binding, true)
 	if !ok {
 		return nil, info, false
@@ -39,7 +38,7 @@ func (e SumExpr) Evaluate(binding Binding, locally bool) (interface{}, Evaluatio
 		return infoe.Error("sum requires a lambda value")
 	}
 
-	debug.Debug("map: using lambda %+v\n", lambda)
+	binding.GetState().Debug("map: using lambda %+v\n", lambda)
 	var result interface{}
 	switch value.(type) {
 	case []yaml.Node:
@@ -57,7 +56,7 @@ func (e SumExpr) Evaluate(binding Binding, locally bool) (interface{}, Evaluatio
 	if result == nil {
 		return e, info, true
 	}
-	debug.Debug("sum: --> %+v\n", result)
+	binding.GetState().Debug("sum: --> %+v\n", result)
 	return result, info, true
 }
 
@@ -83,22 +82,22 @@ func sumList(source []yaml.Node, e LambdaValue, initial interface{}, binding Bin
 	}
 
 	for i, n := range source {
-		debug.Debug("map:  mapping for %d: %+v\n", i, n)
+		binding.GetState().Debug("map:  mapping for %d: %+v\n", i, n)
 		inp[0] = result
 		inp[1] = i
 		inp[len(inp)-1] = n.Value()
 		mapped, info, ok := e.Evaluate(inp, binding, false)
 		if !ok {
-			debug.Debug("map:  %d %+v: failed\n", i, n)
+			binding.GetState().Debug("map:  %d %+v: failed\n", i, n)
 			return nil, info, false
 		}
 
 		_, ok = mapped.(Expression)
 		if ok {
-			debug.Debug("map:  %d unresolved  -> KEEP\n")
+			binding.GetState().Debug("map:  %d unresolved  -> KEEP\n")
 			return nil, info, true
 		}
-		debug.Debug("map:  %d --> %+v\n", i, mapped)
+		binding.GetState().Debug("map:  %d --> %+v\n", i, mapped)
 		result = mapped
 	}
 	return result, info, true
@@ -112,22 +111,22 @@ func sumMap(source map[string]yaml.Node, e LambdaValue, initial interface{}, bin
 	keys := getSortedKeys(source)
 	for _, k := range keys {
 		n := source[k]
-		debug.Debug("map:  mapping for %s: %+v\n", k, n)
+		binding.GetState().Debug("map:  mapping for %s: %+v\n", k, n)
 		inp[0] = result
 		inp[1] = k
 		inp[len(inp)-1] = n.Value()
 		mapped, info, ok := e.Evaluate(inp, binding, false)
 		if !ok {
-			debug.Debug("map:  %s %+v: failed\n", k, n)
+			binding.GetState().Debug("map:  %s %+v: failed\n", k, n)
 			return nil, info, false
 		}
 
 		_, ok = mapped.(Expression)
 		if ok {
-			debug.Debug("map:  %d unresolved  -> KEEP\n")
+			binding.GetState().Debug("map:  %d unresolved  -> KEEP\n")
 			return nil, info, true
 		}
-		debug.Debug("map:  %s --> %+v\n", k, mapped)
+		binding.GetState().Debug("map:  %s --> %+v\n", k, mapped)
 		result = mapped
 	}
 	return result, info, true
@@ -2,9 +2,9 @@ package dynaml
 
 import (
 	"fmt"
+	"reflect"
 	"strings"
 
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
@@ -16,7 +16,7 @@ type SubstitutionExpr struct {
 
 func (e SubstitutionExpr) Evaluate(binding Binding, locally bool) (interface{}, EvaluationInfo, bool) {
 	if e.Node == nil {
-		debug.Debug("evaluating expression to determine template\n")
+		binding.GetState().Debug("evaluating expression to determine template\n")
 		n, info, ok := e.Template.Evaluate(binding, false)
 		if !ok || isExpression(n) {
 			return e, info, ok
@@ -28,19 +28,19 @@ func (e SubstitutionExpr) Evaluate(binding Binding, locally bool) (interface{},
 			e.Node = node_copy(e.Val.Prepared)
 		}
 	}
-	debug.Debug("resolving template '%s'\n", strings.Join(e.Val.Path, "."))
+	binding.GetState().Debug("resolving template '%s'\n", strings.Join(e.Val.Path, "."))
 	result, state := binding.Flow(e.Node, false)
 	info := DefaultInfo()
 	if state != nil {
 		if state.HasError() {
-			debug.Debug("resolving template failed: " + state.Error())
+			binding.GetState().Debug("resolving template failed: " + state.Error())
 			return info.PropagateError(e, state, "resolution of template '%s' failed", strings.Join(e.Val.Path, "."))
 		} else {
-			debug.Debug("resolving template delayed: " + state.Error())
+			binding.GetState().Debug("resolving template delayed: " + state.Error())
 			return e, info, true
 		}
 	}
-	debug.Debug("resolving template succeeded")
+	binding.GetState().Debug("resolving template succeeded")
 	info.Source = result.SourceName()
 	return result.Value(), info, true
 }
@@ -59,6 +59,21 @@ func (e TemplateValue) MarshalYAML() (tag string, value interface{}, err error)
 	return e.Orig.MarshalYAML()
 }
 
+func (e TemplateValue) EquivalentToValue(o interface{}) bool {
+	ov, ok := o.(TemplateValue)
+	if !ok || !reflect.DeepEqual(e.Path, ov.Path) {
+		return false
+	}
+	return equivalentNodes(e.Prepared, ov.Prepared) && equivalentNodes(e.Orig, ov.Orig)
+}
+
+func equivalentNodes(a, b yaml.Node) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.EquivalentToNode(b)
+}
+
 func node_copy(node yaml.Node) yaml.Node {
 	if node == nil {
 		return nil
@@ -69,13 +84,13 @@ func node_copy(node yaml.Node) yaml.Node {
 		for i, v := range val {
 			list[i] = node_copy(v)
 		}
-		return yaml.NewNode(list, node.SourceName())
+		return yaml.NewPositionedNode(list, node.SourceName(), node.Position())
 	case map[string]yaml.Node:
 		m := make(map[string]yaml.Node)
 		for k, v := range val {
 			m[k] = node_copy(v)
 		}
-		return yaml.NewNode(m, node.SourceName())
+		return yaml.NewPositionedNode(m, node.SourceName(), node.Position())
 	}
-	return yaml.NewNode(node.Value(), node.SourceName())
+	return yaml.NewPositionedNode(node.Value(), node.SourceName(), node.Position())
 }
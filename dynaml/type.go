@@ -0,0 +1,58 @@
+package dynaml
+
+import (
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+// additional type names provided by the function type
+const (
+	TypeTemplate ArgumentType = "template"
+	TypeNil      ArgumentType = "nil"
+)
+
+// TypeName provides the dynaml type name of a value.
+func TypeName(value interface{}) ArgumentType {
+	switch value.(type) {
+	case nil:
+		return TypeNil
+	case string:
+		return TypeString
+	case int64:
+		return TypeInt
+	case float64:
+		return TypeFloat
+	case bool:
+		return TypeBool
+	case []yaml.Node:
+		return TypeList
+	case map[string]yaml.Node:
+		return TypeMap
+	case LambdaValue:
+		return TypeLambda
+	case TemplateValue:
+		return TypeTemplate
+	}
+	return TypeAny
+}
+
+func func_type(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 1 {
+		return info.Error("type takes exactly one argument")
+	}
+	return string(TypeName(arguments[0])), info, true
+}
+
+// typePredicate provides a function checking whether its argument
+// is of the given type.
+func typePredicate(name string, t ArgumentType) Function {
+	return func(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+		info := DefaultInfo()
+
+		if len(arguments) != 1 {
+			return info.Error("%s takes exactly one argument", name)
+		}
+		return TypeName(arguments[0]) == t, info, true
+	}
+}
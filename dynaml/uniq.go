@@ -21,7 +21,7 @@ func func_uniq(arguments []interface{}, binding Binding) (interface{}, Evaluatio
 	for _, v := range list {
 		found := false
 		for _, n := range newList {
-			r, _, _ := compareEquals(v.Value(), n.Value())
+			r, _, _ := compareEquals(v.Value(), n.Value(), binding)
 			if r {
 				found = true
 				break
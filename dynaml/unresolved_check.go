@@ -8,7 +8,8 @@ import (
 )
 
 type UnresolvedNodes struct {
-	Nodes []UnresolvedNode
+	Nodes  []UnresolvedNode
+	Cycles []Cycle
 }
 
 type UnresolvedNode struct {
@@ -16,6 +17,35 @@ type UnresolvedNode struct {
 
 	Context []string
 	Path    []string
+	// Cyclic marks nodes involved in a cycle
+	Cyclic bool
+}
+
+// Cycle describes a chain of references among unresolved nodes
+// leading back to its first node.
+type Cycle []UnresolvedNode
+
+func (c Cycle) String() string {
+	steps := []string{}
+	for i, node := range c {
+		step := strings.Join(node.Context, ".")
+		if i < len(c)-1 {
+			step += " (" + yaml.Location(node) + ")"
+		}
+		steps = append(steps, step)
+	}
+	return strings.Join(steps, " -> ")
+}
+
+func (n UnresolvedNode) message() string {
+	if n.Cyclic {
+		return "\t" + tag(n) + "involved in cycle"
+	}
+	msg := n.Issue().Issue
+	if msg != "" {
+		msg = "\t" + tag(n) + msg
+	}
+	return msg
 }
 
 func (e UnresolvedNodes) Issue(msgfmt string, args ...interface{}) (result yaml.Issue, localError bool, failed bool) {
@@ -26,10 +56,7 @@ func (e UnresolvedNodes) Issue(msgfmt string, args ...interface{}) (result yaml.
 
 	for _, node := range e.Nodes {
 		issue := node.Issue()
-		msg := issue.Issue
-		if msg != "" {
-			msg = "\t" + tag(node) + msg
-		}
+		msg := node.message()
 		if node.HasError() {
 			localError = true
 		}
@@ -45,7 +72,7 @@ func (e UnresolvedNodes) Issue(msgfmt string, args ...interface{}) (result yaml.
 		message := fmt.Sprintf(
 			format,
 			node.Value(),
-			node.SourceName(),
+			yaml.Location(node),
 			strings.Join(node.Context, "."),
 			strings.Join(node.Path, "."),
 			msg,
@@ -53,6 +80,9 @@ func (e UnresolvedNodes) Issue(msgfmt string, args ...interface{}) (result yaml.
 		issue.Issue = message
 		result.Nested = append(result.Nested, issue)
 	}
+	for _, cycle := range e.Cycles {
+		result.Nested = append(result.Nested, yaml.NewIssue("\tcycle: %s", cycle))
+	}
 	return
 }
 
@@ -73,10 +103,7 @@ func (e UnresolvedNodes) Error() string {
 
 	for _, node := range e.Nodes {
 		issue := node.Issue()
-		msg := issue.Issue
-		if msg != "" {
-			msg = "\t" + tag(node) + msg
-		}
+		msg := node.message()
 		switch node.Value().(type) {
 		case Expression:
 			format = "%s\n\t(( %s ))\tin %s\t%s\t(%s)%s"
@@ -87,7 +114,7 @@ func (e UnresolvedNodes) Error() string {
 			format,
 			message,
 			node.Value(),
-			node.SourceName(),
+			yaml.Location(node),
 			strings.Join(node.Context, "."),
 			strings.Join(node.Path, "."),
 			msg,
@@ -95,11 +122,20 @@ func (e UnresolvedNodes) Error() string {
 		message += nestedIssues("\t", issue)
 	}
 
+	if len(e.Cycles) > 0 {
+		message += "\ncycles:"
+		for _, cycle := range e.Cycles {
+			message += "\n\t" + cycle.String()
+		}
+	}
 	return message
 }
 
-func tag(node yaml.Node) string {
+func tag(node UnresolvedNode) string {
 	tag := " "
+	if node.Cyclic {
+		return "%"
+	}
 	if !node.Failed() {
 		tag = "@"
 	} else {
@@ -262,6 +298,12 @@ func isLocallyResolvedValue(value interface{}) bool {
 	return true
 }
 
+// IsResolvedNode checks whether a node is completely resolved,
+// meaning it does not contain any expression to evaluate.
+func IsResolvedNode(node yaml.Node) bool {
+	return isResolved(node)
+}
+
 func isResolved(node yaml.Node) bool {
 	return node == nil || isResolvedValue(node.Value())
 }
@@ -35,4 +35,88 @@ var _ = Describe("Reporting unresolved nodes", func() {
 	(( auto ))	in some-file.yml	foo.bar	(foo.bar)
 	(( merge ))	in some-other-file.yml	fizz.[2].buzz	(fizz.fizzbuzz.buzz)`))
 	})
+
+	It("formats the detected cycles", func() {
+		err := UnresolvedNodes{
+			Nodes: []UnresolvedNode{
+				{
+					Node: yaml.NewPositionedNode(
+						ReferenceExpr{[]string{"b"}},
+						"some-file.yml", yaml.Position{Line: 1, Column: 4},
+					),
+					Context: []string{"a"},
+					Cyclic:  true,
+				},
+				{
+					Node: yaml.NewPositionedNode(
+						ReferenceExpr{[]string{"a"}},
+						"some-file.yml", yaml.Position{Line: 2, Column: 4},
+					),
+					Context: []string{"b"},
+					Cyclic:  true,
+				},
+			},
+		}
+		err.Cycles = []Cycle{{err.Nodes[0], err.Nodes[1], err.Nodes[0]}}
+
+		Expect(err.Error()).To(Equal(
+			`unresolved nodes:
+	(( b ))	in some-file.yml:1:4	a	()	%involved in cycle
+	(( a ))	in some-file.yml:2:4	b	()	%involved in cycle
+cycles:
+	a (some-file.yml:1:4) -> b (some-file.yml:2:4) -> a`))
+	})
+	It("provides a machine readable report", func() {
+		err := UnresolvedNodes{
+			Nodes: []UnresolvedNode{
+				{
+					Node: yaml.IssueNode(yaml.NewPositionedNode(
+						CallExpr{ReferenceExpr{[]string{"join"}}, nil},
+						"some-file.yml", yaml.Position{Line: 3, Column: 6},
+					), true, true, yaml.Issue{
+						Issue:  "invalid arguments",
+						Nested: []yaml.Issue{yaml.NewIssue("\targument 1 missing")},
+					}),
+					Context: []string{"foo", "bar"},
+					Path:    []string{"foo", "bar"},
+				},
+				{
+					Node: yaml.NewPositionedNode(
+						ReferenceExpr{[]string{"a"}},
+						"some-file.yml", yaml.Position{Line: 1, Column: 4},
+					),
+					Context: []string{"b"},
+					Cyclic:  true,
+				},
+			},
+		}
+		err.Cycles = []Cycle{{err.Nodes[1], err.Nodes[1]}}
+
+		Expect(err.Report()).To(Equal(ErrorReport{
+			Message: "unresolved nodes",
+			Nodes: []NodeReport{
+				{
+					Path:           "foo.bar",
+					StubPath:       "foo.bar",
+					Source:         "some-file.yml",
+					Line:           3,
+					Column:         6,
+					Expression:     "(( join() ))",
+					Classification: LocalError,
+					Message:        "invalid arguments",
+					Issues:         []IssueReport{{Message: "argument 1 missing"}},
+				},
+				{
+					Path:           "b",
+					Source:         "some-file.yml",
+					Line:           1,
+					Column:         4,
+					Expression:     "(( a ))",
+					Classification: CycleMember,
+					Message:        "involved in cycle",
+				},
+			},
+			Cycles: [][]CycleNodeReport{{{Path: "b", Source: "some-file.yml", Line: 1, Column: 4}}},
+		}))
+	})
 })
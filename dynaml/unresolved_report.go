@@ -0,0 +1,131 @@
+package dynaml
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+// classifications of unresolved nodes
+const (
+	// the node's own expression failed
+	LocalError = "error"
+	// the node is involved in a cycle
+	CycleMember = "cycle"
+	// the node depends on a cycle or an unresolved node
+	Dependent = "dependent"
+	// the node depends on a node with an error
+	ErrorDependent = "error-dependent"
+)
+
+// ErrorReport is the machine readable description of
+// a processing error.
+type ErrorReport struct {
+	Message  string              `json:"message"`
+	Document int                 `json:"document,omitempty"`
+	Nodes    []NodeReport        `json:"nodes,omitempty"`
+	Cycles   [][]CycleNodeReport `json:"cycles,omitempty"`
+}
+
+// CycleNodeReport describes a member of a cycle.
+type CycleNodeReport struct {
+	Path   string `json:"path"`
+	Source string `json:"source,omitempty"`
+	Line   int    `json:"line,omitempty"`
+	Column int    `json:"column,omitempty"`
+}
+
+// NodeReport describes an unresolved node.
+type NodeReport struct {
+	Path           string        `json:"path"`
+	StubPath       string        `json:"stubPath,omitempty"`
+	Source         string        `json:"source,omitempty"`
+	Line           int           `json:"line,omitempty"`
+	Column         int           `json:"column,omitempty"`
+	Expression     string        `json:"expression"`
+	Classification string        `json:"classification"`
+	Message        string        `json:"message,omitempty"`
+	Issues         []IssueReport `json:"issues,omitempty"`
+}
+
+// IssueReport describes a nested issue of an unresolved node.
+type IssueReport struct {
+	Message string        `json:"message"`
+	Issues  []IssueReport `json:"issues,omitempty"`
+}
+
+// Report provides the machine readable description of the
+// unresolved nodes. Cycles are given by their members with
+// path and source location in reference order.
+func (e UnresolvedNodes) Report() ErrorReport {
+	report := ErrorReport{Message: "unresolved nodes"}
+	for _, node := range e.Nodes {
+		report.Nodes = append(report.Nodes, node.Report())
+	}
+	for _, cycle := range e.Cycles {
+		members := []CycleNodeReport{}
+		for i, node := range cycle {
+			if i < len(cycle)-1 {
+				members = append(members, CycleNodeReport{
+					Path:   strings.Join(node.Context, "."),
+					Source: node.SourceName(),
+					Line:   node.Position().Line,
+					Column: node.Position().Column,
+				})
+			}
+		}
+		report.Cycles = append(report.Cycles, members)
+	}
+	return report
+}
+
+// Report provides the machine readable description of the
+// unresolved node.
+func (n UnresolvedNode) Report() NodeReport {
+	report := NodeReport{
+		Path:           strings.Join(n.Context, "."),
+		StubPath:       strings.Join(n.Path, "."),
+		Source:         n.SourceName(),
+		Line:           n.Position().Line,
+		Column:         n.Position().Column,
+		Classification: classification(n),
+	}
+	switch v := n.Value().(type) {
+	case Expression:
+		report.Expression = fmt.Sprintf("(( %s ))", v)
+	default:
+		report.Expression = fmt.Sprintf("%v", v)
+	}
+	if n.Cyclic {
+		report.Message = "involved in cycle"
+	} else {
+		report.Message = n.Issue().Issue
+	}
+	report.Issues = issueReports(n.Issue().Nested)
+	return report
+}
+
+func classification(node UnresolvedNode) string {
+	switch tag(node) {
+	case "*":
+		return LocalError
+	case "%":
+		return CycleMember
+	case "-":
+		return ErrorDependent
+	default:
+		return Dependent
+	}
+}
+
+func issueReports(issues []yaml.Issue) []IssueReport {
+	var reports []IssueReport
+	for _, issue := range issues {
+		reports = append(reports, IssueReport{
+			Message: strings.TrimSpace(issue.Issue),
+			Issues:  issueReports(issue.Nested),
+		})
+	}
+	return reports
+}
@@ -0,0 +1,27 @@
+package dynaml
+
+import (
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+func func_validate(arguments []interface{}, binding Binding) (interface{}, EvaluationInfo, bool) {
+	info := DefaultInfo()
+
+	if len(arguments) != 2 {
+		return info.Error("validate takes exactly 2 arguments")
+	}
+	if _, ok := arguments[1].(map[string]yaml.Node); !ok {
+		return info.Error("schema for validate must be a map")
+	}
+
+	violations, err := yaml.ValidateSchema(yaml.NewNode(arguments[0], ""), yaml.NewNode(arguments[1], ""))
+	if err != nil {
+		return info.Error("%s", err)
+	}
+	if len(violations) > 0 {
+		info.LocalError = true
+		info.Issue = violations.Issue("schema validation failed")
+		return nil, info, false
+	}
+	return arguments[0], info, true
+}
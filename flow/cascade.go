@@ -5,8 +5,14 @@ import (
 )
 
 func Cascade(template yaml.Node, partial bool, templates ...yaml.Node) (yaml.Node, error) {
+	return CascadeWithState(NewState(), template, partial, templates...)
+}
+
+// CascadeWithState cascades the template with the given stubs
+// sharing the given processing state.
+func CascadeWithState(state *State, template yaml.Node, partial bool, templates ...yaml.Node) (yaml.Node, error) {
 	for i := len(templates) - 1; i >= 0; i-- {
-		flowed, err := Flow(templates[i], templates[i+1:]...)
+		flowed, err := FlowWithState(state, templates[i], templates[i+1:]...)
 		if !partial && err != nil {
 			return nil, err
 		}
@@ -14,7 +20,7 @@ func Cascade(template yaml.Node, partial bool, templates ...yaml.Node) (yaml.Nod
 		templates[i] = Cleanup(flowed, testLocal)
 	}
 
-	result, err := Flow(template, templates...)
+	result, err := FlowWithState(state, template, templates...)
 	if err == nil {
 		result = Cleanup(result, testTemporary)
 	}
@@ -22,7 +28,7 @@ func Cascade(template yaml.Node, partial bool, templates ...yaml.Node) (yaml.Nod
 }
 
 func testTemporary(node yaml.Node) bool {
-	return node.Temporary() || node.Local()
+	return node.Temporary() || node.Local() || node.Deleted()
 }
 func testLocal(node yaml.Node) bool {
 	return node.Local()
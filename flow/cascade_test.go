@@ -679,4 +679,170 @@ peter: 26
 			Expect(source).To(CascadeAs(resolved, stub1, stub2))
 		})
 	})
+
+	Describe("deleting nodes", func() {
+		It("removes map fields", func() {
+			source := parseYAML(`
+---
+props:
+  alice: 25
+  bob: 26
+`)
+			stub := parseYAML(`
+---
+props:
+  bob: (( &delete ))
+`)
+			resolved := parseYAML(`
+---
+props:
+  alice: 25
+`)
+			Expect(source).To(CascadeAs(resolved, stub))
+		})
+
+		It("removes named list entries", func() {
+			source := parseYAML(`
+---
+jobs:
+  - name: web
+    size: 1
+  - name: db
+    size: 2
+`)
+			stub := parseYAML(`
+---
+jobs:
+  - name: db
+    <<: (( &delete ))
+`)
+			resolved := parseYAML(`
+---
+jobs:
+  - name: web
+    size: 1
+`)
+			Expect(source).To(CascadeAs(resolved, stub))
+		})
+
+		It("removes entries provided by merges", func() {
+			source := parseYAML(`
+---
+props:
+  <<: (( merge ))
+  alice: 25
+list:
+  - <<: (( merge ))
+  - name: alice
+`)
+			stub := parseYAML(`
+---
+props:
+  alice: (( &delete ))
+  bob: 26
+  peter: (( &delete ))
+list:
+  - name: bob
+    <<: (( &delete ))
+  - name: peter
+`)
+			resolved := parseYAML(`
+---
+props:
+  bob: 26
+list:
+  - name: peter
+  - name: alice
+`)
+			Expect(source).To(CascadeAs(resolved, stub))
+		})
+
+		It("is overridden by deletions of later stubs", func() {
+			source := parseYAML(`
+---
+alice: 25
+`)
+			stub1 := parseYAML(`
+---
+alice: 26
+`)
+			stub2 := parseYAML(`
+---
+alice: (( &delete ))
+`)
+			resolved := parseYAML(`
+---
+{}
+`)
+			Expect(source).To(CascadeAs(resolved, stub1, stub2))
+		})
+
+		It("fails for references to deleted nodes", func() {
+			source := parseYAML(`
+---
+alice: 25
+bob: (( alice ))
+`)
+			stub := parseYAML(`
+---
+alice: (( &delete ))
+`)
+			_, err := Cascade(source, false, stub)
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("'alice' not found"))
+		})
+
+		It("provides defaults for references to deleted nodes", func() {
+			source := parseYAML(`
+---
+alice: 25
+bob: (( alice || 0 ))
+`)
+			stub := parseYAML(`
+---
+alice: (( &delete ))
+`)
+			resolved := parseYAML(`
+---
+bob: 0
+`)
+			Expect(source).To(CascadeAs(resolved, stub))
+		})
+
+		It("hides deleted entries from expressions on the collection", func() {
+			source := parseYAML(`
+---
+jobs:
+  - name: web
+  - name: db
+props:
+  a: 1
+  b: 2
+count: (( length(jobs) ))
+keys: (( keys(props) ))
+names: (( map[jobs|j|->j.name] ))
+size: (( length(props) ))
+`)
+			stub := parseYAML(`
+---
+jobs:
+  - name: db
+    <<: (( &delete ))
+props:
+  b: (( &delete ))
+`)
+			resolved := parseYAML(`
+---
+jobs:
+  - name: web
+props:
+  a: 1
+count: 1
+keys: [ a ]
+names: [ web ]
+size: 1
+`)
+			Expect(source).To(CascadeAs(resolved, stub))
+		})
+	})
 })
@@ -0,0 +1,518 @@
+package flow
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/spiff/dynaml"
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+/*
+ * Before the fixed point iteration of an environment the leaf nodes of a
+ * document are evaluated in the order given by their static references.
+ * Every node is evaluated once after all nodes it depends on are final,
+ * so typically a single iteration step is left to confirm the result.
+ *
+ * Only nodes with statically known dependencies are evaluated this way.
+ * Nodes depending on merges, templates, dynamic references, unknown
+ * nodes or cycles are left to the iteration.
+ */
+
+const (
+	unvisited = iota
+	visiting
+	visited
+)
+
+// vertex is a leaf node of the dependency graph.
+type vertex struct {
+	node     yaml.Node
+	context  []string
+	order    int
+	env      dynaml.Binding
+	override bool
+	set      func(yaml.Node)
+
+	scopes  []*docNode
+	refs    [][]string
+	deps    []*vertex
+	blocked bool
+	final   bool
+	mark    int
+}
+
+// docNode describes a node of the (copied) document. Nodes whose
+// structure is modified by the evaluation (for example by merges)
+// are represented by a blocked vertex.
+type docNode struct {
+	leaf    *vertex
+	fields  map[string]*docNode
+	entries []*docNode
+	keyName string
+	leaves  []*vertex
+}
+
+type graph struct {
+	root     *docNode
+	vertices []*vertex
+	outer    bool
+}
+
+func newBlockedNode() *docNode {
+	return &docNode{leaf: &vertex{blocked: true, mark: visited}}
+}
+
+// evaluateOrdered evaluates the nodes of a document in dependency order.
+// It works on a copy of the document and returns the modified copy.
+func evaluateOrdered(source yaml.Node, env DefaultEnvironment, shouldOverride bool) yaml.Node {
+	g, result := newGraph(source, env, shouldOverride)
+	count := 0
+	for _, v := range g.vertices {
+		if g.evaluate(v) {
+			count++
+		}
+	}
+	env.state.Debug("@@@ ordered evaluation: %d of %d nodes final\n", count, len(g.vertices))
+	return result
+}
+
+// newGraph builds the dependency graph for a document. It returns the
+// graph together with the copy of the document used for the evaluation.
+func newGraph(source yaml.Node, env DefaultEnvironment, shouldOverride bool) (*graph, yaml.Node) {
+	result := source
+	g := &graph{outer: env.scope != nil}
+	g.root = g.build(source, env, shouldOverride, nil, nil, func(n yaml.Node) { result = n })
+
+	for _, v := range g.vertices {
+		if !v.blocked {
+			v.deps = g.dependencies(v)
+		}
+	}
+	return g, result
+}
+
+func (g *graph) build(node yaml.Node, env dynaml.Binding, override bool, scopes []*docNode, context []string, set func(yaml.Node)) *docNode {
+	if node.RedirectPath() != nil || node.ReplaceFlag() || node.Merged() || node.Preferred() || node.Undefined() {
+		return newBlockedNode()
+	}
+
+	switch v := node.Value().(type) {
+	case map[string]yaml.Node:
+		if !yaml.IsMapResolved(v) {
+			return newBlockedNode()
+		}
+		copied := make(map[string]yaml.Node, len(v))
+		for key, val := range v {
+			copied[key] = val
+		}
+		set(yaml.SubstituteNode(copied, node))
+
+		d := &docNode{fields: map[string]*docNode{}}
+		scopes = append([]*docNode{d}, scopes...)
+		env = env.WithScope(copied)
+		for _, key := range getSortedKeys(v) {
+			val := v[key]
+			if val == nil {
+				continue
+			}
+			k := key
+			d.fields[key] = g.build(val, env.WithPath(key), true, scopes, addContext(context, key), func(n yaml.Node) { copied[k] = n })
+		}
+		return d
+
+	case []yaml.Node:
+		keyName := node.KeyName()
+		if keyName == "" {
+			keyName = env.ListKey(env.Path())
+		}
+		if !plainList(v) {
+			return newBlockedNode()
+		}
+		copied := make([]yaml.Node, len(v))
+		copy(copied, v)
+		list := yaml.SubstituteNode(copied, node)
+		if node.KeyName() == "" && keyName != "" {
+			list = yaml.KeyNameNode(list, keyName)
+		}
+		set(list)
+
+		d := &docNode{keyName: keyName}
+		for idx, val := range v {
+			i := idx
+			if !plainName(val, keyName) {
+				d.entries = append(d.entries, newBlockedNode())
+				continue
+			}
+			step, _ := stepName(idx, val, keyName, env)
+			d.entries = append(d.entries, g.build(val, env.WithPath(step), false, scopes, addContext(context, fmt.Sprintf("[%d]", idx)), func(n yaml.Node) { copied[i] = n }))
+		}
+		return d
+
+	case string:
+		parsed := flowString(node, env)
+		if _, ok := parsed.Value().(dynaml.Expression); ok {
+			node = parsed
+			set(node)
+		}
+	}
+
+	v := &vertex{node: node, context: context, order: len(g.vertices), env: env, override: override, set: set, scopes: scopes}
+	if expr, ok := node.Value().(dynaml.Expression); ok {
+		v.refs, ok = dynaml.References(expr)
+		v.blocked = !ok
+	}
+	g.vertices = append(g.vertices, v)
+	return &docNode{leaf: v}
+}
+
+// plainList checks whether a list is processed without merges
+// or key tags, which modify the structure of the list.
+func plainList(list []yaml.Node) bool {
+	for _, val := range list {
+		if val == nil {
+			return false
+		}
+		if _, ok := yaml.UnresolvedListEntryMerge(val); ok {
+			return false
+		}
+		if _, key := ProcessKeyTag(val); key != "" {
+			return false
+		}
+	}
+	return true
+}
+
+// plainName checks whether the name of a list entry is known
+// without evaluation.
+func plainName(entry yaml.Node, keyName string) bool {
+	for _, field := range yaml.KeyFields(keyName) {
+		name, ok := yaml.FindR(true, entry, field)
+		if !ok || name.Value() == nil {
+			continue
+		}
+		if _, ok := name.Value().(string); !ok {
+			return false
+		}
+		if yaml.EmbeddedDynaml(name) != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// dependencies determines the vertices a vertex depends on. If a
+// reference cannot be resolved in the document, the vertex is blocked.
+func (g *graph) dependencies(v *vertex) []*vertex {
+	deps := []*vertex{}
+	for _, ref := range v.refs {
+		var cur *docNode
+		path := ref
+		if path[0] == "" {
+			if g.outer {
+				v.blocked = true
+				continue
+			}
+			cur = g.root
+			path = path[1:]
+		} else {
+			for _, s := range v.scopes {
+				if f := s.fields[path[0]]; f != nil {
+					cur = f
+					break
+				}
+			}
+			path = path[1:]
+		}
+
+		for {
+			if cur == nil {
+				v.blocked = true
+				break
+			}
+			if cur.leaf != nil {
+				deps = append(deps, cur.leaf)
+				break
+			}
+			if len(path) == 0 {
+				deps = append(deps, cur.allLeaves()...)
+				break
+			}
+			cur = cur.step(path[0])
+			path = path[1:]
+		}
+	}
+	return deps
+}
+
+// step mirrors the lookup of a path step in a map or list.
+func (d *docNode) step(step string) *docNode {
+	if d.fields != nil {
+		return d.fields[step]
+	}
+
+	if strings.HasPrefix(step, "[") && strings.HasSuffix(step, "]") {
+		index, err := strconv.Atoi(step[1 : len(step)-1])
+		if err == nil && index >= 0 && index < len(d.entries) {
+			return d.entries[index]
+		}
+	}
+
+	key := d.keyName
+	if split := strings.Index(step, ":"); split > 0 {
+		key = step[:split]
+		step = step[split+1:]
+	}
+	fields := yaml.KeyFields(key)
+	values := []string{step}
+	if len(fields) > 1 {
+		values = strings.SplitN(step, ",", len(fields))
+	}
+	for _, e := range d.entries {
+		if e.leaf != nil {
+			if e.leaf.blocked {
+				// name of entry unknown
+				return nil
+			}
+			continue
+		}
+		if e.matches(fields, values) {
+			return e
+		}
+	}
+	return nil
+}
+
+// matches checks whether the key fields of an entry have the given values.
+func (d *docNode) matches(fields []string, values []string) bool {
+	if len(fields) != len(values) {
+		return false
+	}
+	for i, field := range fields {
+		name := d.fields[field]
+		if name == nil || name.leaf == nil {
+			return false
+		}
+		if s, ok := name.leaf.node.Value().(string); !ok || s != values[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func (d *docNode) allLeaves() []*vertex {
+	if d.leaves == nil {
+		if d.leaf != nil {
+			d.leaves = []*vertex{d.leaf}
+		} else {
+			d.leaves = []*vertex{}
+			for _, f := range d.fields {
+				d.leaves = append(d.leaves, f.allLeaves()...)
+			}
+			for _, e := range d.entries {
+				d.leaves = append(d.leaves, e.allLeaves()...)
+			}
+		}
+	}
+	return d.leaves
+}
+
+// evaluate evaluates a vertex after all its dependencies. It reports
+// whether the vertex is final. Vertices on a cycle are left unevaluated.
+func (g *graph) evaluate(v *vertex) bool {
+	switch v.mark {
+	case visiting:
+		return false
+	case visited:
+		return v.final
+	}
+	v.mark = visiting
+	ok := !v.blocked
+	for _, d := range v.deps {
+		if !g.evaluate(d) {
+			ok = false
+		}
+	}
+	if ok {
+		result := flow(v.node, v.env, v.override)
+		if isFinal(result) {
+			v.set(result)
+			v.final = true
+		}
+	}
+	v.mark = visited
+	return v.final
+}
+
+// isFinal checks whether an evaluation result is stable. Undefined
+// (removed) nodes are final, references to them fail.
+func isFinal(node yaml.Node) bool {
+	return node != nil && !node.Failed() && !node.HasError() &&
+		node.Issue().Issue == "" && (node.Undefined() || dynaml.IsResolvedNode(node))
+}
+
+func addContext(context []string, step string) []string {
+	dup := make([]string, len(context), len(context)+1)
+	copy(dup, context)
+	return append(dup, step)
+}
+
+func unresolved(v *vertex) bool {
+	if v.node == nil {
+		return false
+	}
+	_, ok := v.node.Value().(dynaml.Expression)
+	return ok
+}
+
+func (v *vertex) unresolvedDeps() []*vertex {
+	deps := []*vertex{}
+	for _, d := range v.deps {
+		if unresolved(d) {
+			deps = append(deps, d)
+		}
+	}
+	return deps
+}
+
+// cycles determines the cycles of references among unresolved
+// expressions based on the strongly connected components of the
+// graph (Tarjan). Every cycle is described by the chain of its
+// members, ending with the starting member.
+func (g *graph) cycles() [][]*vertex {
+	index := map[*vertex]int{}
+	low := map[*vertex]int{}
+	onStack := map[*vertex]bool{}
+	stack := []*vertex{}
+	result := [][]*vertex{}
+
+	var connect func(v *vertex)
+	connect = func(v *vertex) {
+		index[v] = len(index)
+		low[v] = index[v]
+		stack = append(stack, v)
+		onStack[v] = true
+
+		for _, d := range v.unresolvedDeps() {
+			if _, ok := index[d]; !ok {
+				connect(d)
+				if low[d] < low[v] {
+					low[v] = low[d]
+				}
+			} else if onStack[d] && index[d] < low[v] {
+				low[v] = index[d]
+			}
+		}
+
+		if low[v] == index[v] {
+			members := map[*vertex]bool{}
+			for {
+				m := stack[len(stack)-1]
+				stack = stack[:len(stack)-1]
+				onStack[m] = false
+				members[m] = true
+				if m == v {
+					break
+				}
+			}
+			if chain := cycleChain(members); chain != nil {
+				result = append(result, chain)
+			}
+		}
+	}
+
+	for _, v := range g.vertices {
+		if _, ok := index[v]; !ok && unresolved(v) {
+			connect(v)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i][0].order < result[j][0].order })
+	return result
+}
+
+// cycleChain determines the shortest chain of references from the first
+// member (in document order) of a strongly connected component back
+// to itself. It returns nil if the component is no cycle.
+func cycleChain(members map[*vertex]bool) []*vertex {
+	var start *vertex
+	for m := range members {
+		if start == nil || m.order < start.order {
+			start = m
+		}
+	}
+
+	prev := map[*vertex]*vertex{}
+	queue := []*vertex{start}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, d := range cur.unresolvedDeps() {
+			if d == start {
+				chain := []*vertex{start}
+				for v := cur; v != start; v = prev[v] {
+					chain = append(chain, v)
+				}
+				for i, j := 1, len(chain)-1; i < j; i, j = i+1, j-1 {
+					chain[i], chain[j] = chain[j], chain[i]
+				}
+				return append(chain, start)
+			}
+			if members[d] && prev[d] == nil && d != start {
+				prev[d] = cur
+				queue = append(queue, d)
+			}
+		}
+	}
+	return nil
+}
+
+// unresolvedNodes reports the unresolved nodes of a document. Nodes
+// involved in a cycle are marked and reported after the nodes with
+// errors, together with the chains of the detected cycles.
+func unresolvedNodes(result yaml.Node, env DefaultEnvironment, shouldOverride bool, nodes []dynaml.UnresolvedNode) dynaml.UnresolvedNodes {
+	g, _ := newGraph(result, env, shouldOverride)
+	cycles := g.cycles()
+	if len(cycles) == 0 {
+		return dynaml.UnresolvedNodes{Nodes: nodes}
+	}
+
+	members := map[string]bool{}
+	report := dynaml.UnresolvedNodes{}
+	for _, chain := range cycles {
+		cycle := dynaml.Cycle{}
+		for _, v := range chain {
+			members[strings.Join(v.context, ".")] = true
+			cycle = append(cycle, dynaml.UnresolvedNode{Node: v.node, Context: v.context, Cyclic: true})
+		}
+		report.Cycles = append(report.Cycles, cycle)
+	}
+
+	cyclic := map[string]dynaml.UnresolvedNode{}
+	others := []dynaml.UnresolvedNode{}
+	for _, n := range nodes {
+		key := strings.Join(n.Context, ".")
+		switch {
+		case n.HasError():
+			report.Nodes = append(report.Nodes, n)
+		case members[key]:
+			n.Cyclic = true
+			cyclic[key] = n
+		default:
+			others = append(others, n)
+		}
+	}
+	// cycle members are reported in the order of the cycles
+	for _, cycle := range report.Cycles {
+		for _, m := range cycle {
+			key := strings.Join(m.Context, ".")
+			if n, ok := cyclic[key]; ok {
+				report.Nodes = append(report.Nodes, n)
+				delete(cyclic, key)
+			}
+		}
+	}
+	report.Nodes = append(report.Nodes, others...)
+	return report
+}
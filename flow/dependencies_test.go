@@ -0,0 +1,225 @@
+package flow
+
+import (
+	"bytes"
+	"fmt"
+	"testing"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+// generateChain generates a document with a chain of n references,
+// which requires n iteration steps without ordered evaluation.
+func generateChain(n int) yaml.Node {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "v%05d: 0\n", 0)
+	for i := 1; i < n; i++ {
+		fmt.Fprintf(buf, "v%05d: (( v%05d + 1 ))\n", i, i-1)
+	}
+	return parseYAML(buf.String())
+}
+
+// generateManifest generates a deployment manifest with the
+// given number of jobs referring to each other and to global
+// settings.
+func generateManifest(jobs int) yaml.Node {
+	buf := &bytes.Buffer{}
+	fmt.Fprintf(buf, "meta:\n  domain: (( \"system.\" base ))\n  base: example.com\n  size: (( length(jobs) ))\n")
+	fmt.Fprintf(buf, "jobs:\n")
+	for i := 0; i < jobs; i++ {
+		fmt.Fprintf(buf, "- name: job%d\n", i)
+		fmt.Fprintf(buf, "  instances: (( %d %% 3 + 1 ))\n", i)
+		fmt.Fprintf(buf, "  properties:\n")
+		fmt.Fprintf(buf, "    url: (( \"https://job%d.\" meta.domain ))\n", i)
+		if i > 0 {
+			fmt.Fprintf(buf, "    previous: (( jobs.job%d.properties.url ))\n", i-1)
+			fmt.Fprintf(buf, "    total: (( jobs.job%d.properties.total + instances ))\n", i-1)
+		} else {
+			fmt.Fprintf(buf, "    total: (( instances ))\n")
+		}
+	}
+	return parseYAML(buf.String())
+}
+
+func flowSteps(source yaml.Node, iterationOnly bool, stubs ...yaml.Node) (yaml.Node, int) {
+	state := NewState()
+	state.iterationOnly = iterationOnly
+	result, err := FlowWithState(state, source, stubs...)
+	Expect(err).NotTo(HaveOccurred())
+	return result, state.Iterations()
+}
+
+var _ = Describe("evaluation in dependency order", func() {
+	It("evaluates a reference chain with a single iteration step", func() {
+		result, steps := flowSteps(generateChain(50), false)
+		Expect(steps).To(Equal(1))
+
+		value, _ := yaml.FindInt(result, "v00049")
+		Expect(value).To(Equal(int64(49)))
+	})
+
+	It("resolves references across lists with a single iteration step", func() {
+		result, steps := flowSteps(generateManifest(20), false)
+		Expect(steps).To(Equal(1))
+
+		size, _ := yaml.FindInt(result, "meta", "size")
+		Expect(size).To(Equal(int64(20)))
+		total := int64(0)
+		for i := 0; i < 20; i++ {
+			job := fmt.Sprintf("job%d", i)
+			total += int64(i%3 + 1)
+			value, _ := yaml.FindInt(result, "jobs", job, "properties", "total")
+			Expect(value).To(Equal(total))
+			url, _ := yaml.FindString(result, "jobs", job, "properties", "url")
+			Expect(url).To(Equal("https://" + job + ".system.example.com"))
+			if i > 0 {
+				previous, _ := yaml.FindString(result, "jobs", job, "properties", "previous")
+				Expect(previous).To(Equal(fmt.Sprintf("https://job%d.system.example.com", i-1)))
+			}
+		}
+	})
+
+	It("needs fewer iteration steps than the iteration alone", func() {
+		_, ordered := flowSteps(generateChain(50), false)
+		_, iterated := flowSteps(generateChain(50), true)
+		Expect(ordered).To(BeNumerically("<", iterated))
+	})
+
+	It("leaves nodes with dynamic references to the iteration", func() {
+		source := parseYAML(`
+---
+idx: 1
+list: [ a, b ]
+copy: (( dyn ))
+dyn: (( list.[idx] ))
+next: (( idx + 1 ))
+chained: (( next * 2 ))
+`)
+		resolved := parseYAML(`
+---
+idx: 1
+list: [ a, b ]
+copy: b
+dyn: b
+next: 2
+chained: 4
+`)
+		ordered, steps := flowSteps(source, false)
+		Expect(ordered).To(FlowAs(resolved))
+		Expect(steps).To(BeNumerically(">", 1))
+
+		iterated, _ := flowSteps(source, true)
+		Expect(ordered.EquivalentToNode(iterated)).To(BeTrue())
+	})
+
+	It("counts the iteration steps of all flowed documents", func() {
+		state := NewState()
+		_, err := CascadeWithState(state, parseYAML("a: (( merge ))"), false, parseYAML("a: (( b ))\nb: 1"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(state.Iterations()).To(Equal(2))
+	})
+
+	It("uses overridden values for references", func() {
+		source := parseYAML(`
+---
+a: 1
+b: (( a + 1 ))
+`)
+		stub := parseYAML(`
+---
+a: 2
+`)
+		resolved := parseYAML(`
+---
+a: 2
+b: 3
+`)
+		Expect(source).To(FlowAs(resolved, stub))
+	})
+
+	It("does not evaluate nodes depending on merged content", func() {
+		source := parseYAML(`
+---
+foo:
+  <<: (( merge ))
+  bar: 1
+alice: (( defined(foo.bob) ))
+peter: (( foo.bar ))
+`)
+		stub := parseYAML(`
+---
+foo:
+  bar: 2
+  bob: 3
+`)
+		resolved := parseYAML(`
+---
+foo:
+  bar: 2
+  bob: 3
+alice: true
+peter: 2
+`)
+		Expect(source).To(FlowAs(resolved, stub))
+	})
+
+	It("does not evaluate lambda calls in advance", func() {
+		source := parseYAML(`
+---
+func: (( |x|->x + a ))
+a: (( b ))
+b: 1
+c: (( .func(1) ))
+`)
+		stub := parseYAML(`
+---
+b: 2
+`)
+		result, err := Flow(source, stub)
+		Expect(err).NotTo(HaveOccurred())
+
+		value, _ := yaml.FindInt(result, "c")
+		Expect(value).To(Equal(int64(3)))
+	})
+
+	It("leaves cycles to the iteration", func() {
+		source := parseYAML(`
+---
+a: (( b ))
+b: (( a ))
+c: 1
+d: (( c ))
+`)
+		_, err := Flow(source)
+		Expect(err).To(HaveOccurred())
+	})
+})
+
+func benchmarkFlow(b *testing.B, source yaml.Node, iterationOnly bool) {
+	for i := 0; i < b.N; i++ {
+		state := NewState()
+		state.iterationOnly = iterationOnly
+		if _, err := FlowWithState(state, source); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkChainOrdered(b *testing.B) {
+	benchmarkFlow(b, generateChain(200), false)
+}
+
+func BenchmarkChainIterative(b *testing.B) {
+	benchmarkFlow(b, generateChain(200), true)
+}
+
+func BenchmarkManifestOrdered(b *testing.B) {
+	benchmarkFlow(b, generateManifest(200), false)
+}
+
+func BenchmarkManifestIterative(b *testing.B) {
+	benchmarkFlow(b, generateManifest(200), true)
+}
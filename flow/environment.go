@@ -3,9 +3,9 @@ package flow
 import (
 	"path/filepath"
 	"reflect"
+	"sort"
 	"strings"
 
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/dynaml"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
@@ -33,10 +33,13 @@ type DefaultEnvironment struct {
 	stubs      []yaml.Node
 	stubPath   []string
 	sourceName string
+	keys       yaml.ListKeys
 
 	currentSourceName string
 
 	local map[string]yaml.Node
+
+	state *State
 }
 
 func (e DefaultEnvironment) Path() []string {
@@ -55,6 +58,10 @@ func (e DefaultEnvironment) CurrentSourceName() string {
 	return e.currentSourceName
 }
 
+func (e DefaultEnvironment) GetState() dynaml.State {
+	return e.state
+}
+
 func (e DefaultEnvironment) GetLocalBinding() map[string]yaml.Node {
 	return e.local
 }
@@ -76,6 +83,10 @@ func (e DefaultEnvironment) FindReference(path []string) (yaml.Node, bool) {
 	return yaml.FindR(true, root, path[1:]...)
 }
 
+func (e DefaultEnvironment) ListKey(path []string) string {
+	return e.keys.Lookup(path)
+}
+
 func (e DefaultEnvironment) FindInStubs(path []string) (yaml.Node, bool) {
 	for _, stub := range e.stubs {
 		val, found := yaml.Find(stub, path...)
@@ -123,12 +134,16 @@ func (e DefaultEnvironment) RedirectOverwrite(path []string) dynaml.Binding {
 }
 
 func (e DefaultEnvironment) Flow(source yaml.Node, shouldOverride bool) (yaml.Node, dynaml.Status) {
-	result := source
+	result, _ := dropDeleted(source, e)
+	if !e.state.iterationOnly {
+		result = evaluateOrdered(result, e, shouldOverride)
+	}
 
 	for {
-		debug.Debug("@@@ loop:  %+v\n", result)
+		e.state.iterated()
+		e.state.Debug("@@@ loop:  %+v\n", result)
 		next := flow(result, e, shouldOverride)
-		debug.Debug("@@@ --->   %+v\n", next)
+		e.state.Debug("@@@ --->   %+v\n", next)
 
 		if reflect.DeepEqual(result, next) {
 			break
@@ -136,21 +151,48 @@ func (e DefaultEnvironment) Flow(source yaml.Node, shouldOverride bool) (yaml.No
 
 		result = next
 	}
-	debug.Debug("@@@ Done\n")
+	e.state.Debug("@@@ Done\n")
 	unresolved := dynaml.FindUnresolvedNodes(result)
 	if len(unresolved) > 0 {
-		return result, dynaml.UnresolvedNodes{unresolved}
+		return result, unresolvedNodes(result, e, shouldOverride, unresolved)
 	}
 
 	return result, nil
 }
 
 func (e DefaultEnvironment) Cascade(template yaml.Node, partial bool, templates ...yaml.Node) (yaml.Node, error) {
-	return Cascade(template, partial, templates...)
+	return CascadeWithState(e.state, template, partial, templates...)
 }
 
-func NewEnvironment(stubs []yaml.Node, source string) dynaml.Binding {
-	return DefaultEnvironment{stubs: stubs, sourceName: source, currentSourceName: source}
+// NewEnvironment creates an environment for processing a document.
+// If no state is given, a new state is created.
+func NewEnvironment(stubs []yaml.Node, source string, optstate ...*State) dynaml.Binding {
+	var state *State
+	if len(optstate) > 0 && optstate[0] != nil {
+		state = optstate[0]
+	} else {
+		state = NewState()
+	}
+	return DefaultEnvironment{stubs: stubs, sourceName: source, currentSourceName: source, state: state, keys: listKeys(stubs, state)}
+}
+
+// listKeys collects the list keys declared by the stubs. Declarations
+// of earlier stubs take precedence for the same path.
+func listKeys(stubs []yaml.Node, state *State) yaml.ListKeys {
+	keys := yaml.ListKeys{}
+	for _, stub := range stubs {
+		node, ok := yaml.FindR(true, stub, yaml.ListKeysField)
+		if !ok {
+			continue
+		}
+		declared, err := yaml.ParseListKeys(node)
+		if err != nil {
+			state.Debug("%s: %s\n", stub.SourceName(), err)
+		}
+		keys = append(keys, declared...)
+	}
+	sort.Stable(keys)
+	return keys
 }
 
 func resolveSymbol(env *DefaultEnvironment, name string, scope *Scope) (yaml.Node, bool) {
@@ -13,7 +13,21 @@ var _ = Describe("Reporting issues for unresolved nodes", func() {
 node: (( ref ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( ref ))	in test	node	()	*'ref' not found`,
+			`	(( ref ))	in test:3:7	node	()	*'ref' not found`,
+		))
+	})
+
+	It("suggests close names for unknown nodes", func() {
+		source := parseYAML(`
+---
+jobs:
+  - name: web
+    properties:
+      port: 80
+node: (( jobs.web.propertes.port ))
+`)
+		Expect(source).To(FlowToErr(
+			`	(( jobs.web.propertes.port ))	in test:7:7	node	()	*'jobs.web.propertes' not found (did you mean 'jobs.web.properties'?)`,
 		))
 	})
 
@@ -24,7 +38,7 @@ a: true
 node: (( a + 1 ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( a + 1 ))	in test	node	()	*first argument of PLUS must be IP address or integer`,
+			`	(( a + 1 ))	in test:4:7	node	()	*first argument of PLUS must be IP address or number`,
 		))
 	})
 
@@ -35,7 +49,7 @@ a: true
 node: (( a - 1 ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( a - 1 ))	in test	node	()	*first argument of MINUS must be IP address or integer`,
+			`	(( a - 1 ))	in test:4:7	node	()	*first argument of MINUS must be IP address or number`,
 		))
 	})
 
@@ -46,7 +60,7 @@ a: 1
 node: (( a / 0 ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( a / 0 ))	in test	node	()	*division by zero`,
+			`	(( a / 0 ))	in test:4:7	node	()	*division by zero`,
 		))
 	})
 
@@ -57,7 +71,7 @@ a: 1
 node: (( a / true ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( a / true ))	in test	node	()	*integer operand required`,
+			`	(( a / true ))	in test:4:7	node	()	*integer operand required`,
 		))
 	})
 
@@ -67,7 +81,7 @@ node: (( a / true ))
 node: (( merge ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( merge ))	in test	node	(node)	*'node' not found in any stub`,
+			`	(( merge ))	in test:3:7	node	(node)	*'node' not found in any stub`,
 		))
 	})
 
@@ -77,7 +91,7 @@ node: (( merge ))
 node: (( merge other.node))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( merge other.node ))	in test	node	(other.node)	*'other.node' not found in any stub`,
+			`	(( merge other.node ))	in test:3:7	node	(other.node)	*'other.node' not found in any stub`,
 		))
 	})
 
@@ -89,7 +103,7 @@ list:
 node: (( join( ",", list.[0] ) ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( join(",", list.[0]) ))	in test	node	()	*argument 1 to join must be simple value or list`,
+			`	(( join(",", list.[0]) ))	in test:5:7	node	()	*argument 1 to join must be simple value or list`,
 		))
 	})
 
@@ -101,7 +115,7 @@ list:
 node: (( join( [], "a" ) ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( join([], "a") ))	in test	node	()	*first argument for join must be a string`,
+			`	(( join([], "a") ))	in test:5:7	node	()	*first argument for join must be a string`,
 		))
 	})
 
@@ -113,7 +127,7 @@ list:
 node: (( join( ",", list ) ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( join(",", list) ))	in test	node	()	*elements of list(arg 1) to join must be simple values`,
+			`	(( join(",", list) ))	in test:5:7	node	()	*elements of list(arg 1) to join must be simple values`,
 		))
 	})
 
@@ -123,7 +137,7 @@ node: (( join( ",", list ) ))
 node: (( min_ip( "10" ) ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( min_ip("10") ))	in test	node	()	*CIDR argument required`,
+			`	(( min_ip("10") ))	in test:3:7	node	()	*CIDR argument required`,
 		))
 	})
 
@@ -135,7 +149,7 @@ a:
 node: (( "." a ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( "." a ))	in test	node	()	*simple value can only be concatenated with simple values`,
+			`	(( "." a ))	in test:5:7	node	()	*simple value can only be concatenated with simple values`,
 		))
 	})
 
@@ -146,7 +160,54 @@ node: (( "." a ))
 node: (( length( 5 ) ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( length(5) ))	in test	node	()	*invalid type for function length`,
+			`	(( length(5) ))	in test:4:7	node	()	*invalid type for function length`,
+		))
+	})
+
+	It("reports cycles", func() {
+		source := parseYAML(`
+---
+a:
+  b: (( c.d ))
+c:
+  d: (( a.b ))
+e: (( a.b ))
+`)
+		Expect(source).To(FlowToErr(
+			`	(( c.d ))	in test:4:6	a.b	()	%involved in cycle
+	(( a.b ))	in test:6:6	c.d	()	%involved in cycle
+	(( a.b ))	in test:7:4	e	()	@'a.b' unresolved
+cycles:
+	a.b (test:4:6) -> c.d (test:6:6) -> a.b`,
+		))
+	})
+
+	It("reports cycle members in the order of the cycle", func() {
+		source := parseYAML(`
+---
+a: (( c ))
+b: (( a ))
+c: (( b ))
+`)
+		Expect(source).To(FlowToErr(
+			`	(( c ))	in test:3:4	a	()	%involved in cycle
+	(( b ))	in test:5:4	c	()	%involved in cycle
+	(( a ))	in test:4:4	b	()	%involved in cycle
+cycles:
+	a (test:3:4) -> c (test:5:4) -> b (test:4:4) -> a`,
+		))
+	})
+
+	It("reports self references as cycle", func() {
+		source := parseYAML(`
+---
+a: 1
+b: (( b + a ))
+`)
+		Expect(source).To(FlowToErr(
+			`	(( b + a ))	in test:4:4	b	()	%involved in cycle
+cycles:
+	b (test:4:4) -> b`,
 		))
 	})
 
@@ -156,7 +217,7 @@ node: (( length( 5 ) ))
 node: (( a "." ) ))
 `)
 		Expect(source).To(FlowToErr(
-			`	(( a "." ) ))	in test	node	()	*unparseable expression`,
+			`	(( a "." ) ))	in test:3:7	node	()	*unparseable expression`,
 		))
 	})
 })
@@ -5,13 +5,110 @@ import (
 	"sort"
 	"strings"
 
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/dynaml"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
 func Flow(source yaml.Node, stubs ...yaml.Node) (yaml.Node, error) {
-	return NewEnvironment(stubs, source.SourceName()).Flow(source, true)
+	return FlowWithState(NewState(), source, stubs...)
+}
+
+// FlowWithState flows a document using the given processing state.
+func FlowWithState(state *State, source yaml.Node, stubs ...yaml.Node) (yaml.Node, error) {
+	return NewEnvironment(stubs, source.SourceName(), state).Flow(source, true)
+}
+
+// Evaluate evaluates a dynaml expression in the root scope of a
+// document. The expression may be given with or without the
+// surrounding (( )).
+func Evaluate(root yaml.Node, expression string) (yaml.Node, error) {
+	return EvaluateWithState(NewState(), root, expression)
+}
+
+// EvaluateWithState evaluates a dynaml expression in the root scope
+// of a document using the given processing state.
+func EvaluateWithState(state *State, root yaml.Node, expression string) (yaml.Node, error) {
+	if sub := yaml.EmbeddedDynaml(yaml.NewNode(expression, "expression")); sub != nil {
+		expression = *sub
+	}
+	expr, err := dynaml.Parse(expression, []string{}, []string{})
+	if err != nil {
+		return nil, err
+	}
+
+	env := NewEnvironment(nil, root.SourceName(), state)
+	if m, ok := root.Value().(map[string]yaml.Node); ok {
+		env = env.WithScope(m)
+	}
+	result, status := env.Flow(yaml.NewNode(expr, "expression"), false)
+	if status != nil {
+		return nil, status
+	}
+	return result, nil
+}
+
+// isDeleted checks whether a stub requests the removal
+// of the node for the actual path.
+func isDeleted(env dynaml.Binding) bool {
+	overridden, found := env.FindInStubs(env.StubPath())
+	return found && overridden.Deleted()
+}
+
+// dropDeleted removes the map fields and list entries deleted by
+// a stub from a document before it is flowed, so that expressions
+// handle them as undefined. It reports whether nodes were removed.
+func dropDeleted(root yaml.Node, env dynaml.Binding) (yaml.Node, bool) {
+	changed := false
+	switch v := root.Value().(type) {
+	case map[string]yaml.Node:
+		newMap := make(map[string]yaml.Node, len(v))
+		for key, val := range v {
+			if key != "<<" {
+				child := env.WithPath(key)
+				if isDeleted(child) {
+					env.GetState().Explain(child.Path(), "deleted by stub")
+					changed = true
+					continue
+				}
+				if n, ok := dropDeleted(val, child); ok {
+					val = n
+					changed = true
+				}
+			}
+			newMap[key] = val
+		}
+		if changed {
+			return yaml.SubstituteNode(newMap, root), true
+		}
+
+	case []yaml.Node:
+		keyName := root.KeyName()
+		if keyName == "" {
+			keyName = env.ListKey(env.Path())
+		}
+		newList := []yaml.Node{}
+		for idx, val := range v {
+			// indices may change by merges, so only entries with
+			// a key are handled here
+			if step, resolved := stepName(idx, val, keyName, env); resolved && !strings.HasPrefix(step, "[") {
+				child := env.WithPath(step)
+				if isDeleted(child) {
+					env.GetState().Explain(child.Path(), "deleted by stub")
+					changed = true
+					continue
+				}
+				if n, ok := dropDeleted(val, child); ok {
+					val = n
+					changed = true
+				}
+			}
+			newList = append(newList, val)
+		}
+		if changed {
+			return yaml.SubstituteNode(newList, root), true
+		}
+	}
+	return root, false
 }
 
 func get_inherited_flags(env dynaml.Binding) yaml.NodeFlags {
@@ -39,10 +136,15 @@ func flow(root yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
 		env = env.RedirectOverwrite(redirect)
 	}
 
-	debug.Debug("/// FLOW %v: %+v\n", env.Path(), root)
+	env.GetState().Debug("/// FLOW %v (%s): %+v\n", env.Path(), yaml.Location(root), root)
+	if isDeleted(env) {
+		env.GetState().Debug("  deleted by stub")
+		env.GetState().Explain(env.Path(), "deleted by stub")
+		return yaml.UndefinedNode(root)
+	}
 	if !replace {
 		if _, ok := root.Value().(dynaml.Expression); !ok && merged {
-			debug.Debug("  skip handling of merged node")
+			env.GetState().Debug("  skip handling of merged node")
 			return root
 		}
 		switch val := root.Value().(type) {
@@ -53,7 +155,7 @@ func flow(root yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
 			return flowList(root, env)
 
 		case dynaml.Expression:
-			debug.Debug("??? eval %T: %+v\n", val, val)
+			env.GetState().Debug("??? eval %T: %+v\n", val, val)
 			env := env
 			if root.SourceName() != env.SourceName() {
 				env = env.WithSource(root.SourceName())
@@ -62,26 +164,28 @@ func flow(root yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
 			var eval interface{} = nil
 			m, ok := val.(dynaml.MarkerExpr)
 			if ok && m.Has(dynaml.TEMPLATE) {
-				debug.Debug("found template declaration\n")
-				val := m.TemplateExpression(root)
+				env.GetState().Debug("found template declaration\n")
+				val := m.TemplateExpression(root, env)
 				if val == nil {
 					root = yaml.IssueNode(root, true, false, yaml.NewIssue("empty template value"))
-					debug.Debug("??? failed ---> KEEP\n")
+					env.GetState().Debug("??? failed ---> KEEP\n")
 					if !shouldOverride {
 						return root
 					}
 				}
-				debug.Debug("  value template %s", val)
+				env.GetState().Debug("  value template %s", val)
 				eval = dynaml.TemplateValue{env.Path(), val, root}
 			} else {
+				env.GetState().Explain(env.Path(), "evaluating (( %s )) (%s)", val, yaml.Location(root))
 				eval, info, ok = val.Evaluate(env, false)
 			}
 			replace = replace || info.Replace
 			flags |= info.NodeFlags
-			debug.Debug("??? ---> %+v\n", eval)
+			env.GetState().Debug("??? ---> %+v\n", eval)
 			if !ok {
+				env.GetState().Explain(env.Path(), "failed: %s", info.Issue.Issue)
 				root = yaml.IssueNode(root, true, false, info.Issue)
-				debug.Debug("??? failed ---> KEEP\n")
+				env.GetState().Debug("??? failed ---> KEEP\n")
 				if !shouldOverride {
 					return root
 				}
@@ -89,7 +193,12 @@ func flow(root yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
 				if info.SourceName() != "" {
 					source = info.SourceName()
 				}
-				result := yaml.NewNode(eval, source)
+				var result yaml.Node
+				if source == root.SourceName() {
+					result = yaml.NewPositionedNode(eval, source, root.Position())
+				} else {
+					result = yaml.NewNode(eval, source)
+				}
 				_, ok = eval.(string)
 				if ok {
 					// map result to potential expression
@@ -101,12 +210,12 @@ func flow(root yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
 					result = yaml.IssueNode(result, false, info.Failed, info.Issue)
 				}
 				if info.Undefined {
-					debug.Debug("   UNDEFINED")
+					env.GetState().Debug("   UNDEFINED")
 					result = yaml.UndefinedNode(result)
 				}
 				// preserve accumulated node attributes
 				if preferred || info.Preferred {
-					debug.Debug("   PREFERRED")
+					env.GetState().Debug("   PREFERRED")
 					result = yaml.PreferredNode(result)
 				}
 
@@ -118,28 +227,29 @@ func flow(root yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
 					redirect = info.RedirectPath
 				}
 				if len(redirect) > 0 {
-					debug.Debug("   REDIRECT -> %v\n", redirect)
+					env.GetState().Debug("   REDIRECT -> %v\n", redirect)
 					result = yaml.RedirectNode(result.Value(), result, redirect)
 				}
 
 				if replace {
-					debug.Debug("   REPLACE\n")
+					env.GetState().Debug("   REPLACE\n")
 					result = yaml.ReplaceNode(result.Value(), result, redirect)
 				} else {
 					if merged || info.Merged {
-						debug.Debug("   MERGED\n")
+						env.GetState().Debug("   MERGED\n")
 						result = yaml.MergedNode(result)
 					}
 				}
 				if (flags | result.Flags()) != result.Flags() {
 					result = yaml.AddFlags(result, flags)
 				}
+				explainResult(env, result, expr, info)
 				if expr || result.Merged() || !shouldOverride || result.Preferred() {
-					debug.Debug("   prefer expression over override")
-					debug.Debug("??? ---> %+v\n", result)
+					env.GetState().Debug("   prefer expression over override")
+					env.GetState().Debug("??? ---> %+v\n", result)
 					return result
 				}
-				debug.Debug("???   try override\n")
+				env.GetState().Debug("???   try override\n")
 				replace = result.ReplaceFlag()
 				root = result
 			}
@@ -157,9 +267,10 @@ func flow(root yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
 	}
 
 	if !merged && root.StandardOverride() && shouldOverride {
-		debug.Debug("/// lookup stub %v -> %v\n", env.Path(), env.StubPath())
+		env.GetState().Debug("/// lookup stub %v -> %v\n", env.Path(), env.StubPath())
 		overridden, found := env.FindInStubs(env.StubPath())
 		if found {
+			explainOverride(env, overridden)
 			root = overridden
 			if keyName != "" {
 				root = yaml.KeyNameNode(root, keyName)
@@ -182,6 +293,56 @@ func flow(root yaml.Node, env dynaml.Binding, shouldOverride bool) yaml.Node {
 	return root
 }
 
+func explainResult(env dynaml.Binding, result yaml.Node, expr bool, info dynaml.EvaluationInfo) {
+	state := env.GetState()
+	if expr {
+		if info.Issue.Issue != "" {
+			state.Explain(env.Path(), "unresolved: %s", info.Issue.Issue)
+		} else {
+			state.Explain(env.Path(), "unresolved")
+		}
+		return
+	}
+	state.Explain(env.Path(), "result: %s", dynaml.FormatValue(result.Value()))
+
+	annotations := []string{}
+	if result.Merged() {
+		annotations = append(annotations, "merged")
+	}
+	if result.ReplaceFlag() {
+		annotations = append(annotations, "replace")
+	}
+	if path := result.RedirectPath(); len(path) > 0 {
+		annotations = append(annotations, "redirect to "+strings.Join(path, "."))
+	}
+	if result.Preferred() {
+		annotations = append(annotations, "preferred")
+	}
+	if result.KeyName() != "" {
+		annotations = append(annotations, "key name "+result.KeyName())
+	}
+	if result.Temporary() {
+		annotations = append(annotations, "temporary")
+	}
+	if result.Local() {
+		annotations = append(annotations, "local")
+	}
+	if len(annotations) > 0 {
+		state.Explain(env.Path(), "annotations: %s", strings.Join(annotations, ", "))
+	}
+}
+
+func explainOverride(env dynaml.Binding, overridden yaml.Node) {
+	stubPath := strings.Join(env.StubPath(), ".")
+	if stubPath == strings.Join(env.Path(), ".") {
+		env.GetState().Explain(env.Path(), "overridden by %s from %s",
+			dynaml.FormatValue(overridden.Value()), yaml.Location(overridden))
+	} else {
+		env.GetState().Explain(env.Path(), "overridden by %s from %s (stub path %s)",
+			dynaml.FormatValue(overridden.Value()), yaml.Location(overridden), stubPath)
+	}
+}
+
 /*
  * compatibility issue. A single merge node was always optional
  * means: <<: (( merge )) == <<: (( merge || nil ))
@@ -211,7 +372,7 @@ func flowMap(root yaml.Node, env dynaml.Binding) yaml.Node {
 
 	sortedKeys := getSortedKeys(rootMap)
 
-	debug.Debug("HANDLE MAP %v\n", env.Path())
+	env.GetState().Debug("HANDLE MAP %v\n", env.Path())
 
 	// iteration order matters for the "<<" operator, it must be the first key in the map that is handled
 	for i := range sortedKeys {
@@ -221,29 +382,29 @@ func flowMap(root yaml.Node, env dynaml.Binding) yaml.Node {
 		if key == "<<" {
 			_, initial := val.Value().(string)
 			base := flow(val, env, false)
-			debug.Debug("flow to %#v\n", base.Value())
+			env.GetState().Debug("flow to %#v\n", base.Value())
 			_, ok := base.Value().(dynaml.Expression)
 			if ok {
 				m, ok := base.Value().(dynaml.MarkerExpr)
 				if ok {
-					debug.Debug("found marker\n")
+					env.GetState().Debug("found marker\n")
 					flags |= m.GetFlags()
 					if flags.Temporary() {
-						debug.Debug("found temporary declaration\n")
+						env.GetState().Debug("found temporary declaration\n")
 					}
 					if flags.Local() {
-						debug.Debug("found local declaration\n")
+						env.GetState().Debug("found local declaration\n")
 					}
 				}
 				if ok && m.Has(dynaml.TEMPLATE) {
-					debug.Debug("found template declaration\n")
+					env.GetState().Debug("found template declaration\n")
 					processed = false
 					template = true
-					val = m.TemplateExpression(root)
+					val = m.TemplateExpression(root, env)
 					if val == nil {
 						continue
 					}
-					debug.Debug("  insert expression: %v\n", val)
+					env.GetState().Debug("  insert expression: %v\n", val)
 				} else {
 					if simpleMergeCompatibilityCheck(initial, base) {
 						continue
@@ -259,7 +420,9 @@ func flowMap(root yaml.Node, env dynaml.Binding) yaml.Node {
 				}
 				if ok {
 					for k, v := range baseMap {
-						newMap[k] = v
+						if !v.Deleted() {
+							newMap[k] = v
+						}
 					}
 				}
 				replace = base.ReplaceFlag()
@@ -274,16 +437,16 @@ func flowMap(root yaml.Node, env dynaml.Binding) yaml.Node {
 			}
 		}
 
-		debug.Debug("MAP %v (%s)%s\n", env.Path(), val.KeyName(), key)
+		env.GetState().Debug("MAP %v (%s)%s\n", env.Path(), val.KeyName(), key)
 		if !val.Undefined() {
 			newMap[key] = val
 		}
 	}
 
-	debug.Debug("MAP DONE %v\n", env.Path())
+	env.GetState().Debug("MAP DONE %v\n", env.Path())
 	var result interface{}
 	if template {
-		debug.Debug(" as template\n")
+		env.GetState().Debug(" as template\n")
 		result = dynaml.TemplateValue{env.Path(), yaml.NewNode(newMap, root.SourceName()), root}
 	} else {
 		result = newMap
@@ -304,18 +467,23 @@ func flowMap(root yaml.Node, env dynaml.Binding) yaml.Node {
 func flowList(root yaml.Node, env dynaml.Binding) yaml.Node {
 	rootList := root.Value().([]yaml.Node)
 
-	debug.Debug("HANDLE LIST %v\n", env.Path())
+	env.GetState().Debug("HANDLE LIST %v\n", env.Path())
+	if root.KeyName() == "" {
+		if key := env.ListKey(env.Path()); key != "" {
+			root = yaml.KeyNameNode(root, key)
+		}
+	}
 	merged, process, replaced, redirectPath, keyName, flags := processMerges(root, rootList, env)
 
 	if process {
-		debug.Debug("process list (key: %s) %v\n", keyName, env.Path())
+		env.GetState().Debug("process list (key: %s) %v\n", keyName, env.Path())
 		newList := []yaml.Node{}
 		if len(redirectPath) > 0 {
 			env = env.RedirectOverwrite(redirectPath)
 		}
 		for idx, val := range merged.([]yaml.Node) {
 			step, resolved := stepName(idx, val, keyName, env)
-			debug.Debug("  step %s\n", step)
+			env.GetState().Debug("  step %s\n", step)
 			if resolved {
 				val = flow(val, env.WithPath(step), false)
 			}
@@ -330,7 +498,7 @@ func flowList(root yaml.Node, env dynaml.Binding) yaml.Node {
 	if keyName != "" {
 		root = yaml.KeyNameNode(root, keyName)
 	}
-	debug.Debug("LIST DONE (%s)%v\n", root.KeyName(), env.Path())
+	env.GetState().Debug("LIST DONE (%s)%v\n", root.KeyName(), env.Path())
 
 	if replaced {
 		root = yaml.ReplaceNode(merged, root, redirectPath)
@@ -353,7 +521,7 @@ func flowString(root yaml.Node, env dynaml.Binding) yaml.Node {
 	if sub == nil {
 		return root
 	}
-	debug.Debug("dynaml: %v: %s\n", env.Path(), *sub)
+	env.GetState().Debug("dynaml: %v: %s\n", env.Path(), *sub)
 	expr, err := dynaml.Parse(*sub, env.Path(), env.StubPath())
 	if err != nil {
 		return root
@@ -366,31 +534,49 @@ func stepName(index int, value yaml.Node, keyName string, env dynaml.Binding) (s
 	if keyName == "" {
 		keyName = "name"
 	}
-	name, ok := yaml.FindString(value, keyName)
+	step := fmt.Sprintf("[%d]", index)
+	names := []string{}
+	for _, field := range yaml.KeyFields(keyName) {
+		name, found, resolved := keyValue(value, field, step, env)
+		if !resolved {
+			return step, false
+		}
+		if !found {
+			return step, true
+		}
+		names = append(names, name)
+	}
+	return keyName + ":" + strings.Join(names, ","), true
+}
+
+// keyValue determines the value of a key field of a list entry.
+// An expression is evaluated to get the value. It reports whether
+// a string value is found and whether the field could be resolved.
+func keyValue(value yaml.Node, field string, step string, env dynaml.Binding) (string, bool, bool) {
+	name, ok := yaml.FindString(value, field)
 	if ok {
-		return keyName + ":" + name, true
+		return name, true, true
 	}
 
-	step := fmt.Sprintf("[%d]", index)
-	v, ok := yaml.FindR(true, value, keyName)
+	v, ok := yaml.FindR(true, value, field)
 	if ok && v.Value() != nil {
-		debug.Debug("found raw %s", keyName)
+		env.GetState().Debug("found raw %s", field)
 		_, ok := v.Value().(dynaml.Expression)
 		if ok {
 			v = flow(v, env.WithPath(step), false)
 			_, ok := v.Value().(dynaml.Expression)
 			if ok {
-				return step, false
+				return "", false, false
 			}
 		}
 		name, ok = v.Value().(string)
 		if ok {
-			return keyName + ":" + name, true
+			return name, true, true
 		}
 	} else {
-		debug.Debug("raw %s not found", keyName)
+		env.GetState().Debug("raw %s not found", field)
 	}
-	return step, true
+	return "", false, true
 }
 
 func processMerges(orig yaml.Node, root []yaml.Node, env dynaml.Binding) (interface{}, bool, bool, []string, string, yaml.NodeFlags) {
@@ -410,13 +596,13 @@ func processMerges(orig yaml.Node, root []yaml.Node, env dynaml.Binding) (interf
 
 		inlineNode, ok := yaml.UnresolvedListEntryMerge(val)
 		if ok {
-			debug.Debug("*** %+v\n", inlineNode.Value())
+			env.GetState().Debug("*** %+v\n", inlineNode.Value())
 			_, initial := inlineNode.Value().(string)
 			result := flow(inlineNode, env, false)
 			if result.KeyName() != "" {
 				keyName = result.KeyName()
 			}
-			debug.Debug("=== (%s)%+v\n", keyName, result)
+			env.GetState().Debug("=== (%s)%+v\n", keyName, result)
 			_, ok := result.Value().(dynaml.Expression)
 			if ok {
 				if simpleMergeCompatibilityCheck(initial, inlineNode) {
@@ -426,14 +612,14 @@ func processMerges(orig yaml.Node, root []yaml.Node, env dynaml.Binding) (interf
 				if ok {
 					flags |= m.GetFlags()
 					if ok && m.Has(dynaml.TEMPLATE) {
-						debug.Debug("found template declaration\n")
+						env.GetState().Debug("found template declaration\n")
 						template = true
 						process = false
-						result = m.TemplateExpression(orig)
+						result = m.TemplateExpression(orig, env)
 						if result == nil {
 							continue
 						}
-						debug.Debug("  insert expression: %v\n", result)
+						env.GetState().Debug("  insert expression: %v\n", result)
 					}
 				}
 				newMap := make(map[string]yaml.Node)
@@ -468,13 +654,13 @@ func processMerges(orig yaml.Node, root []yaml.Node, env dynaml.Binding) (interf
 
 	var result interface{}
 	if template {
-		debug.Debug(" as template\n")
+		env.GetState().Debug(" as template\n")
 		result = dynaml.TemplateValue{env.Path(), yaml.NewNode(spliced, orig.SourceName()), orig}
 	} else {
 		result = spliced
 	}
 
-	debug.Debug("--> %+v  proc=%v replaced=%v redirect=%v key=%s\n", result, process, replaced, redirectPath, keyName)
+	env.GetState().Debug("--> %+v  proc=%v replaced=%v redirect=%v key=%s\n", result, process, replaced, redirectPath, keyName)
 	return result, process, replaced, redirectPath, keyName, flags
 }
 
@@ -484,11 +670,15 @@ func ProcessKeyTag(val yaml.Node) (yaml.Node, string) {
 	m, ok := val.Value().(map[string]yaml.Node)
 	if ok {
 		found := false
-		for key, _ := range m {
+		// several tagged fields form a composite key
+		for _, key := range getSortedKeys(m) {
 			split := strings.Index(key, ":")
 			if split > 0 {
 				if key[:split] == "key" {
-					keyName = key[split+1:]
+					if found {
+						keyName += ","
+					}
+					keyName += key[split+1:]
 					found = true
 				}
 			}
@@ -518,7 +708,10 @@ func newEntries(a []yaml.Node, b []yaml.Node, keyName string) []yaml.Node {
 	added := []yaml.Node{}
 
 	for _, val := range a {
-		name, ok := yaml.FindStringR(true, val, keyName)
+		if val.Deleted() {
+			continue
+		}
+		name, ok := yaml.ListEntryKey(true, val, keyName)
 		if ok {
 			_, found := yaml.FindR(true, old, name) // TODO
 			if found {
@@ -1,6 +1,7 @@
 package flow
 
 import (
+	"bytes"
 	"os"
 
 	. "github.com/onsi/ginkgo"
@@ -263,9 +264,9 @@ foo: (( auto ))
 			Expect(err).To(Equal(dynaml.UnresolvedNodes{
 				Nodes: []dynaml.UnresolvedNode{
 					{
-						Node: yaml.IssueNode(yaml.NewNode(
+						Node: yaml.IssueNode(yaml.NewPositionedNode(
 							dynaml.AutoExpr{Path: []string{"foo"}},
-							"test",
+							"test", yaml.Position{Line: 3, Column: 6},
 						), true, false, yaml.NewIssue("auto only allowed for size entry in resource pools")),
 						Context: []string{"foo"},
 						Path:    []string{"foo"},
@@ -2221,6 +2222,40 @@ foo: failed
 			})
 		})
 
+		Context("floats", func() {
+			It("evaluates mixed integer and float operands", func() {
+				source := parseYAML(`
+---
+a: 1.5
+b: 2
+foo: (( a * b + 0.25 ))
+bar: (( 7 / 2.0 ))
+`)
+				resolved := parseYAML(`
+---
+a: 1.5
+b: 2
+foo: 3.25
+bar: 3.5
+`)
+				Expect(source).To(FlowAs(resolved))
+			})
+
+			It("converts numbers", func() {
+				source := parseYAML(`
+---
+foo: (( integer(3.7) ))
+bar: (( float(3) ))
+`)
+				resolved := parseYAML(`
+---
+foo: 3
+bar: 3.0
+`)
+				Expect(source).To(FlowAs(resolved))
+			})
+		})
+
 		Context("mixed levels", func() {
 			It("evaluates multiplication first", func() {
 				source := parseYAML(`
@@ -3659,7 +3694,6 @@ status: failed
 	Describe("when resing from the environment", func() {
 		os.Setenv("TEST1", "alice")
 		os.Setenv("TEST2", "bob")
-		dynaml.ReloadEnv()
 
 		It("resolves a single variable", func() {
 			source := parseYAML(`
@@ -4224,6 +4258,132 @@ mapped:
 				Expect(source).To(FlowAs(resolved))
 			})
 		})
+
+		Context("to a map", func() {
+			It("keeps the keys", func() {
+				source := parseYAML(`
+---
+map:
+  alice: 25
+  bob: 24
+  peter: ~
+mapped: (( map{map|k,v|->v ? k v :~} ))
+`)
+				resolved := parseYAML(`
+---
+map:
+  alice: 25
+  bob: 24
+  peter: ~
+mapped:
+  alice: alice25
+  bob: bob24
+`)
+				Expect(source).To(FlowAs(resolved))
+			})
+
+			It("fails for lists", func() {
+				source := parseYAML(`
+---
+list: [ 1 ]
+mapped: (( map{list|x|->x} ))
+`)
+				Expect(source).To(FlowToErr(
+					`	(( map{list|x|->x} ))	in test:4:9	mapped	()	*map required for mapping to map`,
+				))
+			})
+		})
+	})
+
+	Describe("when doing a selection", func() {
+		It("selects list entries", func() {
+			source := parseYAML(`
+---
+jobs:
+  - name: web
+    instances: 2
+  - name: db
+    instances: 0
+selected: (( select[jobs|j|->j.instances > 0] ))
+filtered: (( filter[jobs|i,j|->i > 0] ))
+`)
+			resolved := parseYAML(`
+---
+jobs:
+  - name: web
+    instances: 2
+  - name: db
+    instances: 0
+selected:
+  - name: web
+    instances: 2
+filtered:
+  - name: db
+    instances: 0
+`)
+			Expect(source).To(FlowAs(resolved))
+		})
+
+		It("selects map values as list or map", func() {
+			source := parseYAML(`
+---
+ports:
+  admin: 8080
+  http: 80
+  https: 443
+list: (( select[ports|p|->p > 100] ))
+map: (( select{ports|k,p|->k != "admin" -and p > 100} ))
+`)
+			resolved := parseYAML(`
+---
+ports:
+  admin: 8080
+  http: 80
+  https: 443
+list:
+  - 8080
+  - 443
+map:
+  https: 443
+`)
+			Expect(source).To(FlowAs(resolved))
+		})
+	})
+
+	Describe("when sorting lists", func() {
+		It("sorts with a comparator", func() {
+			source := parseYAML(`
+---
+jobs:
+  - name: web
+    instances: 2
+  - name: db
+    instances: 1
+  - name: worker
+    instances: 2
+order: (( map[sort(jobs, |a,b|->a.instances < b.instances)|j|->j.name] ))
+desc: (( reverse(sort([ 2, 10, 1 ])) ))
+`)
+			resolved := parseYAML(`
+---
+jobs:
+  - name: web
+    instances: 2
+  - name: db
+    instances: 1
+  - name: worker
+    instances: 2
+order:
+  - db
+  - web
+  - worker
+desc:
+  - 10
+  - 2
+  - 1
+`)
+			Expect(source).To(FlowAs(resolved))
+		})
 	})
 
 	Describe("when doing a sum", func() {
@@ -5098,6 +5258,151 @@ list:
     attr: b
   - address: c
     attr: stub
+`)
+				Expect(source).To(FlowAs(resolved, stub))
+			})
+		})
+
+		Context("explicit merge with composite key", func() {
+			It("identifies entries by all key fields", func() {
+				source := parseYAML(`
+---
+list:
+  - <<: (( merge on name,az ))
+  - name: web
+    az: z1
+    size: 1
+  - name: web
+    az: z2
+    size: 2
+`)
+				stub := parseYAML(`
+---
+list:
+  - name: web
+    az: z2
+    size: 5
+  - name: web
+    az: z3
+    size: 3
+`)
+				resolved := parseYAML(`
+---
+list:
+  - name: web
+    az: z3
+    size: 3
+  - name: web
+    az: z1
+    size: 1
+  - name: web
+    az: z2
+    size: 5
+`)
+				Expect(source).To(FlowAs(resolved, stub))
+			})
+		})
+
+		Context("composite key tags", func() {
+			It("identifies entries by all tagged fields", func() {
+				source := parseYAML(`
+---
+list:
+  - key:name: web
+    key:az: z1
+    size: 1
+  - name: web
+    az: z2
+    size: 2
+`)
+				stub := parseYAML(`
+---
+list:
+  - name: web
+    az: z2
+    size: 5
+`)
+				resolved := parseYAML(`
+---
+list:
+  - name: web
+    az: z1
+    size: 1
+  - name: web
+    az: z2
+    size: 5
+`)
+				Expect(source).To(FlowAs(resolved, stub))
+			})
+		})
+
+		Context("keys declared by a stub", func() {
+			stub := parseYAML(`
+---
+__keys:
+  list: address
+  groups.*.instances: name,az
+list:
+  - address: c
+    attr: stub
+groups:
+  - name: a
+    instances:
+      - name: web
+        az: z2
+        size: 5
+`)
+
+			It("uses the declared key for the list path", func() {
+				source := parseYAML(`
+---
+list:
+  - address: a
+    attr: b
+  - address: c
+    attr: d
+`)
+				resolved := parseYAML(`
+---
+list:
+  - address: a
+    attr: b
+  - address: c
+    attr: stub
+`)
+				Expect(source).To(FlowAs(resolved, stub))
+			})
+
+			It("matches wildcard steps and composite keys", func() {
+				source := parseYAML(`
+---
+groups:
+  - name: a
+    instances:
+      - name: web
+        az: z1
+        size: 1
+      - name: web
+        az: z2
+        size: 2
+    sizes: (( list_to_map(instances) ))
+`)
+				resolved := parseYAML(`
+---
+groups:
+  - name: a
+    instances:
+      - name: web
+        az: z1
+        size: 1
+      - name: web
+        az: z2
+        size: 5
+    sizes:
+      web,z1:
+        size: 1
+      web,z2:
+        size: 5
 `)
 				Expect(source).To(FlowAs(resolved, stub))
 			})
@@ -5632,4 +5937,91 @@ next: 10.1.2.32/28
 			})
 		})
 	})
+
+	Describe("evaluating an expression", func() {
+		It("evaluates in the root scope of the document", func() {
+			source := parseYAML(`
+---
+jobs:
+  - name: web
+    networks:
+      - name: default
+        static_ips: [ 10.0.0.1 ]
+`)
+			result, err := Evaluate(source, "(( jobs.web.networks.[0].static_ips ))")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.EquivalentToNode(parseYAML(`[ 10.0.0.1 ]`))).To(BeTrue())
+		})
+
+		It("accepts expressions without brackets", func() {
+			result, err := Evaluate(parseYAML(`foo: 2`), "foo * 3")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Value()).To(Equal(int64(6)))
+		})
+
+		It("fails for unparseable expressions", func() {
+			_, err := Evaluate(parseYAML(`foo: 2`), "foo *")
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("explaining the evaluation", func() {
+		source := parseYAML(`
+---
+meta:
+  base: example.com
+  port: 80
+jobs:
+  - name: web
+    url: (( "http://" meta.base ":" meta.port ))
+  - name: db
+    size: (( merge || 3 ))
+    host: (( missing.host ))
+`)
+		stub := yaml.NewNode(map[string]yaml.Node{
+			"meta": yaml.NewNode(map[string]yaml.Node{
+				"port": yaml.NewNode(int64(8080), "stub"),
+			}, "stub"),
+			"jobs": yaml.NewNode([]yaml.Node{
+				yaml.NewNode(map[string]yaml.Node{
+					"name": yaml.NewNode("db", "stub"),
+					"size": yaml.NewNode(int64(5), "stub"),
+				}, "stub"),
+			}, "stub"),
+		}, "stub")
+
+		explain := func(paths ...string) string {
+			buf := &bytes.Buffer{}
+			FlowWithState(NewState().WithExplain(buf, paths...), source, stub)
+			return buf.String()
+		}
+
+		It("records the references and the result of an expression", func() {
+			Expect(explain("jobs.web.url")).To(Equal(
+				`jobs.name:web.url: evaluating (( "http://" meta.base ":" meta.port )) (test:8:10)
+jobs.name:web.url: reference meta.base: "example.com" from test:4:9
+jobs.name:web.url: reference meta.port: 8080 from stub
+jobs.name:web.url: result: "http://example.com:8080"
+`))
+		})
+
+		It("records values taken from stubs", func() {
+			Expect(explain("meta.port", "jobs.db.size")).To(Equal(
+				`meta.port: overridden by 8080 from stub
+jobs.name:db.size: evaluating (( merge || 3 )) (test:10:11)
+jobs.name:db.size: merge jobs.name:db.size: 5 from stub
+jobs.name:db.size: result: 5
+jobs.name:db.size: annotations: merged
+`))
+		})
+
+		It("records unresolved references", func() {
+			Expect(explain("jobs.db.host")).To(ContainSubstring(
+				"jobs.name:db.host: reference missing.host: 'missing' not found\n"))
+		})
+
+		It("records nothing for other nodes", func() {
+			Expect(explain("meta.base")).To(Equal(""))
+		})
+	})
 })
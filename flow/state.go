@@ -0,0 +1,230 @@
+package flow
+
+import (
+	"crypto/md5"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/cloudfoundry-incubator/spiff/dynaml"
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+// State is the default implementation of the processing state
+// shared by all environments of a processing run. It caches the
+// content of read files and the output of executed commands.
+type State struct {
+	lock sync.Mutex
+
+	fileSystem dynaml.FileSystem
+	environ    []string
+	registry   dynaml.Registry
+	allowed    map[string]bool
+	sandbox    *dynaml.Sandbox
+	debug      io.Writer
+	explain    [][]string
+	explainOut io.Writer
+	explained  map[string]bool
+
+	iterations int
+	// disables the evaluation in dependency order (for benchmarks)
+	iterationOnly bool
+
+	files map[string][]byte
+	execs map[string][]byte
+}
+
+var _ dynaml.State = &State{}
+
+// NewState creates a state using the file system and the
+// current environment of the operating system.
+func NewState() *State {
+	return &State{
+		fileSystem: dynaml.OSFileSystem,
+		environ:    os.Environ(),
+		registry:   dynaml.NewRegistry(),
+		files:      map[string][]byte{},
+		execs:      map[string][]byte{},
+	}
+}
+
+// WithFileSystem sets the file system used to read files.
+func (s *State) WithFileSystem(fs dynaml.FileSystem) *State {
+	s.fileSystem = fs
+	return s
+}
+
+// WithEnvironment sets the environment (list of name=value
+// settings) used by the env function.
+func (s *State) WithEnvironment(environ []string) *State {
+	s.environ = environ
+	return s
+}
+
+// WithRegistry sets the registry of functions callable
+// by dynaml expressions.
+func (s *State) WithRegistry(registry dynaml.Registry) *State {
+	s.registry = registry
+	return s
+}
+
+// WithFunctions restricts the callable functions to the given
+// names. Without a restriction all functions are allowed.
+func (s *State) WithFunctions(names ...string) *State {
+	s.allowed = map[string]bool{}
+	for _, n := range names {
+		s.allowed[n] = true
+	}
+	return s
+}
+
+// WithSandbox restricts the access to the host (safe mode).
+func (s *State) WithSandbox(sandbox *dynaml.Sandbox) *State {
+	s.sandbox = sandbox
+	return s
+}
+
+// WithDebug sets the writer for debug output. Without a writer
+// the global debug settings are used.
+func (s *State) WithDebug(w io.Writer) *State {
+	s.debug = w
+	return s
+}
+
+// WithExplain enables recording the evaluation steps of the nodes
+// with the given paths (dot separated) to the given writer.
+func (s *State) WithExplain(w io.Writer, paths ...string) *State {
+	s.explainOut = w
+	s.explained = map[string]bool{}
+	s.explain = nil
+	for _, p := range paths {
+		s.explain = append(s.explain, strings.Split(p, "."))
+	}
+	return s
+}
+
+// Iterations provides the number of iteration steps used to flow
+// the documents processed with this state.
+func (s *State) Iterations() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.iterations
+}
+
+func (s *State) iterated() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.iterations++
+}
+
+func (s *State) GetFileContent(file string) ([]byte, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	data := s.files[file]
+	if data == nil {
+		err := s.sandbox.CheckFile(file)
+		if err != nil {
+			return nil, err
+		}
+		data, err = s.fileSystem.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		s.files[file] = data
+	}
+	return data, nil
+}
+
+func (s *State) Getenv(name string) (string, bool) {
+	if s.sandbox.CheckEnvironment(name) != nil {
+		return "", false
+	}
+	name += "="
+	for _, e := range s.environ {
+		if strings.HasPrefix(e, name) {
+			return e[len(name):], true
+		}
+	}
+	return "", false
+}
+
+func (s *State) Execute(args []string) ([]byte, error) {
+	if err := s.sandbox.CheckCommand(args[0]); err != nil {
+		return nil, err
+	}
+
+	h := md5.New()
+	for _, arg := range args {
+		h.Write([]byte(arg))
+	}
+	hash := fmt.Sprintf("%x", h.Sum(nil))
+	s.lock.Lock()
+	result := s.execs[hash]
+	s.lock.Unlock()
+	if result != nil {
+		s.Debug("exec: reusing cache %s for %v\n", hash, args)
+		return result, nil
+	}
+	s.Debug("exec: calling %v\n", args)
+	cmd := exec.Command(args[0], args[1:]...)
+	result, err := cmd.Output()
+	s.lock.Lock()
+	s.execs[hash] = result
+	s.lock.Unlock()
+	return result, err
+}
+
+func (s *State) FunctionAllowed(name string) bool {
+	return s.allowed == nil || s.allowed[name]
+}
+
+func (s *State) GetFunctions() dynaml.Registry {
+	return s.registry
+}
+
+func (s *State) GetSandbox() *dynaml.Sandbox {
+	return s.sandbox
+}
+
+func (s *State) Debug(msgfmt string, args ...interface{}) {
+	if s.debug != nil {
+		fmt.Fprintf(s.debug, msgfmt, args...)
+	}
+}
+
+func (s *State) Explain(path []string, msgfmt string, args ...interface{}) {
+	if s.explainOut == nil {
+		return
+	}
+	for _, e := range s.explain {
+		if matchPath(e, path) {
+			// nodes are evaluated repeatedly by the iteration
+			line := fmt.Sprintf("%s: %s\n", strings.Join(path, "."), fmt.Sprintf(msgfmt, args...))
+			s.lock.Lock()
+			defer s.lock.Unlock()
+			if !s.explained[line] {
+				s.explained[line] = true
+				io.WriteString(s.explainOut, line)
+			}
+			return
+		}
+	}
+}
+
+// matchPath matches a path against a node path. The steps for list
+// entries match with or without the key name.
+func matchPath(pattern []string, path []string) bool {
+	if len(pattern) != len(path) {
+		return false
+	}
+	for i, p := range pattern {
+		if p != path[i] && p != yaml.PathComponent(path[i]) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,40 @@
+package flow
+
+import (
+	"sync"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("State", func() {
+	Describe("executing commands", func() {
+		It("caches the output of a command", func() {
+			state := NewState()
+			first, err := state.Execute([]string{"sh", "-c", "date +%s%N"})
+			Expect(err).NotTo(HaveOccurred())
+			second, err := state.Execute([]string{"sh", "-c", "date +%s%N"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(second).To(Equal(first))
+		})
+
+		It("executes different commands concurrently", func() {
+			state := NewState()
+			var wg sync.WaitGroup
+			start := time.Now()
+			for _, arg := range []string{"a", "b", "c"} {
+				wg.Add(1)
+				go func(arg string) {
+					defer wg.Done()
+					defer GinkgoRecover()
+					result, err := state.Execute([]string{"sh", "-c", "sleep 0.5; echo " + arg})
+					Expect(err).NotTo(HaveOccurred())
+					Expect(string(result)).To(Equal(arg + "\n"))
+				}(arg)
+			}
+			wg.Wait()
+			Expect(time.Since(start)).To(BeNumerically("<", 1400*time.Millisecond))
+		})
+	})
+})
@@ -0,0 +1,230 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/spiff/dynaml"
+	"github.com/cloudfoundry-incubator/spiff/spiffing"
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+const replHelp = `Commands:
+  <expression>         evaluate a dynaml expression (with or without (( )))
+  :show [path]         show the node at a path (dot separated) or the document
+  :unresolved          list the unresolved nodes of the document
+  :let name = <expr>   define a temporary node usable by expressions,
+                       for example :let f = |x|->x * 2 (call it with .f(1))
+  :unlet name          remove a temporary node
+  :doc [n]             show or select the document of a multi-document stream
+  :reload              read and process the files again
+  :help                show this help
+  :quit                leave the repl
+`
+
+// repl is an interactive session evaluating dynaml expressions
+// against a processed template.
+type repl struct {
+	spiff        *spiffing.Spiff
+	templatePath string
+	stubPaths    []string
+
+	documents []yaml.Node
+	current   int
+	defs      map[string]yaml.Node
+
+	out io.Writer
+}
+
+func newRepl(spiff *spiffing.Spiff, templatePath string, stubPaths []string, out io.Writer) *repl {
+	return &repl{
+		spiff:        spiff,
+		templatePath: templatePath,
+		stubPaths:    stubPaths,
+		defs:         map[string]yaml.Node{},
+		out:          out,
+	}
+}
+
+// load reads and processes the template and stub files.
+func (r *repl) load() error {
+	templates, err := r.spiff.ReadFile(r.templatePath)
+	if err != nil {
+		return fmt.Errorf("error reading template [%s]: %s", r.templatePath, err)
+	}
+	stubs := []yaml.Node{}
+	for _, path := range r.stubPaths {
+		docs, err := r.spiff.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("error reading stub [%s]: %s", path, err)
+		}
+		stubs = append(stubs, docs...)
+	}
+
+	documents, _ := r.spiff.Merge(templates, stubs...)
+	r.documents = documents
+	if r.current >= len(r.documents) {
+		r.current = 0
+	}
+
+	r.printf("loaded %s (%d document(s))\n", r.templatePath, len(r.documents))
+	if n := len(dynaml.FindUnresolvedNodes(r.document())); n > 0 {
+		r.printf("%d unresolved node(s), see :unresolved\n", n)
+	}
+	return nil
+}
+
+func (r *repl) printf(format string, args ...interface{}) {
+	fmt.Fprintf(r.out, format, args...)
+}
+
+func (r *repl) document() yaml.Node {
+	if len(r.documents) == 0 {
+		return yaml.NewNode(map[string]yaml.Node{}, r.templatePath)
+	}
+	return r.documents[r.current]
+}
+
+// scope provides the current document with the temporary nodes
+// added to its root.
+func (r *repl) scope() yaml.Node {
+	doc := r.document()
+	m, ok := doc.Value().(map[string]yaml.Node)
+	if !ok || len(r.defs) == 0 {
+		return doc
+	}
+	root := map[string]yaml.Node{}
+	for k, v := range m {
+		root[k] = v
+	}
+	for k, v := range r.defs {
+		root[k] = v
+	}
+	return yaml.SubstituteNode(root, doc)
+}
+
+func (r *repl) print(node yaml.Node) {
+	data, err := r.spiff.Marshal(node, spiffing.YAML)
+	if err != nil {
+		r.printf("error: %s\n", err)
+		return
+	}
+	r.printf("%s", data)
+	if len(data) == 0 || data[len(data)-1] != '\n' {
+		r.printf("\n")
+	}
+}
+
+func (r *repl) run(in io.Reader, prompt string) {
+	scanner := bufio.NewScanner(in)
+	for {
+		r.printf("%s", prompt)
+		if !scanner.Scan() {
+			r.printf("\n")
+			return
+		}
+		if !r.execute(strings.TrimSpace(scanner.Text())) {
+			return
+		}
+	}
+}
+
+// execute handles a single input line. It reports whether
+// the session should be continued.
+func (r *repl) execute(line string) bool {
+	if line == "" {
+		return true
+	}
+	if !strings.HasPrefix(line, ":") {
+		r.evaluate(line)
+		return true
+	}
+
+	fields := strings.Fields(line)
+	args := strings.TrimSpace(line[len(fields[0]):])
+	switch fields[0] {
+	case ":quit", ":q", ":exit":
+		return false
+	case ":help", ":h":
+		r.printf("%s", replHelp)
+	case ":show", ":s":
+		r.show(args)
+	case ":unresolved", ":u":
+		unresolved := dynaml.FindUnresolvedNodes(r.document())
+		if len(unresolved) == 0 {
+			r.printf("no unresolved nodes\n")
+		} else {
+			r.printf("%s\n", dynaml.UnresolvedNodes{Nodes: unresolved}.Error())
+		}
+	case ":let", ":l":
+		r.let(args)
+	case ":unlet":
+		delete(r.defs, args)
+	case ":doc", ":d":
+		r.selectDocument(args)
+	case ":reload", ":r":
+		if err := r.load(); err != nil {
+			r.printf("%s\n", err)
+		}
+	default:
+		r.printf("unknown command '%s' (see :help)\n", fields[0])
+	}
+	return true
+}
+
+func (r *repl) evaluate(expression string) (yaml.Node, bool) {
+	result, err := r.spiff.Evaluate(r.scope(), expression)
+	if err != nil {
+		r.printf("error: %s\n", strings.TrimSpace(err.Error()))
+		return nil, false
+	}
+	r.print(result)
+	return result, true
+}
+
+func (r *repl) show(path string) {
+	if path == "" {
+		r.print(r.document())
+		return
+	}
+	node, ok := yaml.FindR(true, r.scope(), strings.Split(path, ".")...)
+	if !ok {
+		r.printf("'%s' not found\n", path)
+		return
+	}
+	if _, ok := node.Value().(dynaml.Expression); ok {
+		r.printf("unresolved: (( %s ))\t%s\n", node.Value(), yaml.Location(node))
+		return
+	}
+	r.print(node)
+}
+
+func (r *repl) let(args string) {
+	i := strings.Index(args, "=")
+	if i <= 0 {
+		r.printf("usage: :let name = <expression>\n")
+		return
+	}
+	name := strings.TrimSpace(args[:i])
+	if strings.ContainsAny(name, ". \t") {
+		r.printf("invalid name '%s'\n", name)
+		return
+	}
+	if result, ok := r.evaluate(strings.TrimSpace(args[i+1:])); ok {
+		r.defs[name] = result
+	}
+}
+
+func (r *repl) selectDocument(args string) {
+	if args != "" {
+		var n int
+		if _, err := fmt.Sscanf(args, "%d", &n); err != nil || n < 1 || n > len(r.documents) {
+			r.printf("invalid document number '%s'\n", args)
+			return
+		}
+		r.current = n - 1
+	}
+	r.printf("document %d of %d\n", r.current+1, len(r.documents))
+}
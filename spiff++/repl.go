@@ -0,0 +1 @@
+../repl.go
\ No newline at end of file
@@ -0,0 +1 @@
+../testcases.go
\ No newline at end of file
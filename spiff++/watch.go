@@ -0,0 +1 @@
+../watch.go
\ No newline at end of file
@@ -1,20 +1,22 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"log"
 	"os"
 	"path"
+	"sort"
 	"strings"
 
 	"github.com/codegangsta/cli"
 
 	"github.com/cloudfoundry-incubator/candiedyaml"
 	"github.com/cloudfoundry-incubator/spiff/compare"
-	"github.com/cloudfoundry-incubator/spiff/debug"
 	"github.com/cloudfoundry-incubator/spiff/dynaml"
-	"github.com/cloudfoundry-incubator/spiff/flow"
+	"github.com/cloudfoundry-incubator/spiff/spiffing"
 	"github.com/cloudfoundry-incubator/spiff/yaml"
 )
 
@@ -30,7 +32,7 @@ func main() {
 			ShortName:       "m",
 			Usage:           "merge stub files into a manifest template",
 			SkipFlagParsing: true,
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.BoolFlag{
 					Name:  "debug",
 					Usage: "print state info",
@@ -39,33 +41,243 @@ func main() {
 					Name:  "partial",
 					Usage: "allow partial evaluation only",
 				},
-			},
+				cli.StringFlag{
+					Name:  "output",
+					Value: "yaml",
+					Usage: "output format (yaml or json)",
+				},
+				cli.StringFlag{
+					Name:  "errors",
+					Value: "text",
+					Usage: "error format (text or json)",
+				},
+				cli.StringSliceFlag{
+					Name:  "explain",
+					Value: &cli.StringSlice{},
+					Usage: "explain the evaluation of a node (dot separated path)",
+				},
+				cli.StringFlag{
+					Name:  "schema",
+					Usage: "schema file to validate the result against",
+				},
+				cli.StringFlag{
+					Name:  "out, o",
+					Usage: "write the result to the given file",
+				},
+				cli.BoolFlag{
+					Name:  "watch",
+					Usage: "render again whenever an input file changes (requires --out)",
+				},
+			}, safeModeFlags...),
 			Action: func(c *cli.Context) {
 				if len(c.Args()) < 1 {
 					cli.ShowCommandHelp(c, "merge")
 					os.Exit(1)
 				}
-				debug.DebugFlag = c.Bool("debug")
-				merge(c.Args()[0], c.Bool("partial"), c.Args()[1:])
+				checkOutputFormat(c.String("output"), "yaml", "json")
+				checkOutputFormat(c.String("errors"), "text", "json")
+				errorFormat = c.String("errors")
+				spiff := spiffing.New().
+					WithDebug(debugOutput(c)).
+					WithPartial(c.Bool("partial")).
+					WithSandbox(sandbox(c)).
+					WithExplain(os.Stderr, c.StringSlice("explain")...)
+				if c.String("schema") != "" {
+					spiff = spiff.WithSchema(readSchema(c.String("schema")))
+				}
+				if c.Bool("watch") {
+					watchMerge(spiff, c.Args()[0], c.String("output"), c.Args()[1:], outFile(c))
+				}
+				merge(spiff, c.Args()[0], c.String("output"), c.Args()[1:], outFile(c))
+			},
+		},
+		{
+			Name:            "eval",
+			ShortName:       "e",
+			Usage:           "evaluate a dynaml expression against a merged template",
+			SkipFlagParsing: true,
+			Flags: append([]cli.Flag{
+				cli.BoolFlag{
+					Name:  "debug",
+					Usage: "print state info",
+				},
+				cli.StringFlag{
+					Name:  "output",
+					Value: "yaml",
+					Usage: "output format (yaml or json)",
+				},
+			}, safeModeFlags...),
+			Action: func(c *cli.Context) {
+				if len(c.Args()) < 2 {
+					cli.ShowCommandHelp(c, "eval")
+					os.Exit(1)
+				}
+				checkOutputFormat(c.String("output"), "yaml", "json")
+				spiff := spiffing.New().
+					WithDebug(debugOutput(c)).
+					WithPartial(true).
+					WithSandbox(sandbox(c))
+				eval(spiff, c.Args()[0], c.Args()[1], c.String("output"), c.Args()[2:])
+			},
+		},
+		{
+			Name:            "repl",
+			ShortName:       "r",
+			Usage:           "interactively evaluate dynaml expressions against a merged template",
+			SkipFlagParsing: true,
+			Flags: append([]cli.Flag{
+				cli.BoolFlag{
+					Name:  "debug",
+					Usage: "print state info",
+				},
+			}, safeModeFlags...),
+			Action: func(c *cli.Context) {
+				if len(c.Args()) < 1 {
+					cli.ShowCommandHelp(c, "repl")
+					os.Exit(1)
+				}
+				spiff := spiffing.New().
+					WithDebug(debugOutput(c)).
+					WithPartial(true).
+					WithSandbox(sandbox(c))
+				r := newRepl(spiff, c.Args()[0], c.Args()[1:], os.Stdout)
+				if err := r.load(); err != nil {
+					log.Fatalln(err)
+				}
+				r.printf("type :help for the list of commands\n")
+				r.run(os.Stdin, "spiff> ")
 			},
 		},
 		{
 			Name:      "diff",
 			ShortName: "d",
 			Usage:     "structurally compare two YAML files",
-			Flags: []cli.Flag{
+			Flags: append([]cli.Flag{
 				cli.StringFlag{
 					Name:  "separator",
 					Usage: "separator to print between diffs",
 				},
-			},
+				cli.StringFlag{
+					Name:  "output",
+					Usage: "machine readable output format (yaml or json)",
+				},
+				cli.StringSliceFlag{
+					Name:  "keys",
+					Value: &cli.StringSlice{},
+					Usage: "stub file declaring the key fields of lists (__keys)",
+				},
+				cli.StringFlag{
+					Name:  "patch",
+					Usage: "print the differences as patch (json or merge), list entries are addressed by key steps like name:web (no RFC 6901 pointers)",
+				},
+				cli.BoolFlag{
+					Name:  "indices",
+					Usage: "address list entries of patches by index (plain RFC 6901 pointers)",
+				},
+			}, compareFlags...),
 			Action: func(c *cli.Context) {
 				if len(c.Args()) < 2 {
 					cli.ShowCommandHelp(c, "diff")
 					os.Exit(1)
 				}
+				checkOutputFormat(c.String("output"), "", "yaml", "json")
+				checkOutputFormat(c.String("patch"), "", "json", "merge")
 
-				diff(c.Args()[0], c.Args()[1], c.String("separator"))
+				opts := compare.Options{
+					Keys:       readListKeys(c.StringSlice("keys")),
+					IndexPaths: c.Bool("indices"),
+					Ignore:     readIgnoreRules(c),
+					Mode:       compareMode(c),
+				}
+				if c.String("patch") != "" {
+					patch(c.Args()[0], c.Args()[1], c.String("patch"), c.String("output"), opts)
+					return
+				}
+				diff(c.Args()[0], c.Args()[1], c.String("separator"), c.String("output"), opts)
+			},
+		},
+		{
+			Name:  "patch",
+			Usage: "apply a JSON Patch or JSON Merge Patch to a YAML file",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "output",
+					Value: "yaml",
+					Usage: "output format (yaml or json)",
+				},
+			},
+			Action: func(c *cli.Context) {
+				if len(c.Args()) < 2 {
+					cli.ShowCommandHelp(c, "patch")
+					os.Exit(1)
+				}
+				checkOutputFormat(c.String("output"), "yaml", "json")
+				applyPatch(c.Args()[0], c.Args()[1], c.String("output"))
+			},
+		},
+		{
+			Name:  "diff3",
+			Usage: "structurally compare two YAML files derived from a common base",
+			Flags: append([]cli.Flag{
+				cli.StringFlag{
+					Name:  "separator",
+					Usage: "separator to print between diffs",
+				},
+				cli.StringFlag{
+					Name:  "output",
+					Usage: "machine readable output format (yaml or json)",
+				},
+				cli.StringSliceFlag{
+					Name:  "keys",
+					Value: &cli.StringSlice{},
+					Usage: "stub file declaring the key fields of lists (__keys)",
+				},
+			}, compareFlags...),
+			Action: func(c *cli.Context) {
+				if len(c.Args()) < 3 {
+					cli.ShowCommandHelp(c, "diff3")
+					os.Exit(1)
+				}
+				checkOutputFormat(c.String("output"), "", "yaml", "json")
+
+				opts := compare.Options{
+					Keys:   readListKeys(c.StringSlice("keys")),
+					Ignore: readIgnoreRules(c),
+					Mode:   compareMode(c),
+				}
+				if !diff3(c.Args()[0], c.Args()[1], c.Args()[2], c.String("separator"), c.String("output"), opts) {
+					os.Exit(1)
+				}
+			},
+		},
+		{
+			Name:  "test",
+			Usage: "check the merge results of test case directories against expected documents",
+			Flags: append(append([]cli.Flag{
+				cli.BoolFlag{
+					Name:  "update",
+					Usage: "regenerate the expected documents from the current results",
+				},
+				cli.StringSliceFlag{
+					Name:  "keys",
+					Value: &cli.StringSlice{},
+					Usage: "stub file declaring the key fields of lists (__keys)",
+				},
+			}, compareFlags...), safeModeFlags...),
+			Action: func(c *cli.Context) {
+				dirs := []string(c.Args())
+				if len(dirs) == 0 {
+					dirs = []string{"."}
+				}
+				opts := compare.Options{
+					Keys:   readListKeys(c.StringSlice("keys")),
+					Ignore: readIgnoreRules(c),
+					Mode:   compareMode(c),
+				}
+				spiff := spiffing.New().WithSandbox(sandbox(c))
+				if !runTests(spiff, dirs, c.Bool("update"), opts) {
+					os.Exit(1)
+				}
 			},
 		},
 	}
@@ -73,7 +285,196 @@ func main() {
 	app.Run(os.Args)
 }
 
-func merge(templateFilePath string, partial bool, stubFilePaths []string) {
+// debugOutput provides the writer for the debug output
+// requested by the --debug option.
+func debugOutput(c *cli.Context) io.Writer {
+	if c.Bool("debug") {
+		return os.Stderr
+	}
+	return nil
+}
+
+// safeModeFlags are the options of all commands processing templates
+// to restrict the access to the host.
+var safeModeFlags = []cli.Flag{
+	cli.BoolFlag{
+		Name:  "safe",
+		Usage: "safe mode: deny exec, env and read",
+	},
+	cli.StringSliceFlag{
+		Name:  "allow-exec",
+		Value: &cli.StringSlice{},
+		Usage: "command allowed in safe mode",
+	},
+	cli.StringSliceFlag{
+		Name:  "allow-env",
+		Value: &cli.StringSlice{},
+		Usage: "prefix of environment variables allowed in safe mode",
+	},
+	cli.StringSliceFlag{
+		Name:  "allow-read",
+		Value: &cli.StringSlice{},
+		Usage: "directory allowed for reading files in safe mode",
+	},
+}
+
+// compareFlags are the options of the diff commands
+// controlling the comparison.
+var compareFlags = []cli.Flag{
+	cli.StringSliceFlag{
+		Name:  "mode",
+		Value: &cli.StringSlice{},
+		Usage: "comparison mode (strict, numeric or unordered)",
+	},
+	cli.StringSliceFlag{
+		Name:  "ignore",
+		Value: &cli.StringSlice{},
+		Usage: "path pattern to ignore (dot separated, with wildcards)",
+	},
+	cli.StringSliceFlag{
+		Name:  "ignore-file",
+		Value: &cli.StringSlice{},
+		Usage: "file with path patterns to ignore (one per line)",
+	},
+}
+
+// readIgnoreRules provides the ignore rules given by the options.
+func readIgnoreRules(c *cli.Context) compare.IgnoreRules {
+	rules := compare.ParseIgnoreRules(c.StringSlice("ignore")...)
+	for _, filePath := range c.StringSlice("ignore-file") {
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			log.Fatalln(fmt.Sprintf("error reading ignore file [%s]:", path.Clean(filePath)), err)
+		}
+		rules = append(rules, compare.ReadIgnoreRules(data)...)
+	}
+	return rules
+}
+
+// compareMode combines the comparison modes given by the options.
+func compareMode(c *cli.Context) compare.Mode {
+	mode := compare.Strict
+	for _, m := range c.StringSlice("mode") {
+		switch m {
+		case "strict":
+		case "numeric":
+			mode |= compare.NumericValues
+		case "unordered":
+			mode |= compare.UnorderedLists
+		default:
+			log.Fatalln(fmt.Sprintf("invalid comparison mode '%s'", m))
+		}
+	}
+	return mode
+}
+
+// outFile provides the value of the out flag. Without flag parsing
+// by the cli package the values of flag aliases are not merged.
+func outFile(c *cli.Context) string {
+	if out := c.String("out"); out != "" {
+		return out
+	}
+	return c.String("o")
+}
+
+func checkOutputFormat(format string, valid ...string) {
+	for _, v := range valid {
+		if format == v {
+			return
+		}
+	}
+	log.Fatalln(fmt.Sprintf("invalid output format '%s'", format))
+}
+
+// sandbox provides the access restrictions for the safe mode.
+// Allowing dedicated access implicitly enables the safe mode.
+func sandbox(c *cli.Context) *dynaml.Sandbox {
+	commands := c.StringSlice("allow-exec")
+	environment := c.StringSlice("allow-env")
+	directories := c.StringSlice("allow-read")
+
+	if !c.Bool("safe") && len(commands) == 0 && len(environment) == 0 && len(directories) == 0 {
+		return nil
+	}
+	return &dynaml.Sandbox{
+		Commands:    commands,
+		Environment: environment,
+		Directories: directories,
+	}
+}
+
+func parseDocument(filePath string, data []byte) (yaml.Node, error) {
+	if strings.HasSuffix(filePath, ".json") {
+		return yaml.ParseJSON(filePath, data)
+	}
+	return yaml.Parse(filePath, data)
+}
+
+// errorFormat is the format used by fatal to report errors (text or json).
+var errorFormat = "text"
+
+// fatal reports an error and exits. In json format the error is
+// reported as dynaml.ErrorReport, describing unresolved nodes
+// in detail. Additional text is only printed in text format.
+func fatal(message string, document int, err error, text ...interface{}) {
+	if errorFormat != "json" {
+		args := []interface{}{message}
+		if err != nil {
+			args = append(args, err)
+		}
+		log.Fatalln(append(args, text...)...)
+	}
+
+	report := dynaml.ErrorReport{Message: message}
+	if unresolved, ok := err.(dynaml.UnresolvedNodes); ok {
+		report = unresolved.Report()
+		report.Message = message + " " + report.Message
+	} else if err != nil {
+		report.Message = message + " " + err.Error()
+	}
+	report.Document = document
+	data, merr := json.MarshalIndent(report, "", "  ")
+	if merr != nil {
+		log.Fatalln(message, err)
+	}
+	fmt.Fprintln(os.Stderr, string(data))
+	os.Exit(1)
+}
+
+// failure describes an error to be reported by fatal.
+type failure struct {
+	message  string
+	document int
+	err      error
+	text     []interface{}
+}
+
+func (f *failure) Error() string {
+	args := []interface{}{f.message}
+	if f.err != nil {
+		args = append(args, f.err)
+	}
+	return strings.TrimSpace(fmt.Sprintln(append(args, f.text...)...))
+}
+
+func (f *failure) fatal() {
+	fatal(f.message, f.document, f.err, f.text...)
+}
+
+// readDocuments reads and parses the documents of the template
+// and stub files. The file name - denotes stdin.
+func readDocuments(spiff *spiffing.Spiff, templateFilePath string, stubFilePaths []string) ([]yaml.Node, []yaml.Node) {
+	templateYAMLs, stubs, f := loadDocuments(spiff, templateFilePath, stubFilePaths)
+	if f != nil {
+		f.fatal()
+	}
+	return templateYAMLs, stubs
+}
+
+// loadDocuments reads and parses the documents of the template
+// and stub files like readDocuments, but reports errors instead
+// of exiting.
+func loadDocuments(spiff *spiffing.Spiff, templateFilePath string, stubFilePaths []string) ([]yaml.Node, []yaml.Node, *failure) {
 	var templateFile []byte
 	var err error
 	var stdin = false
@@ -86,12 +487,12 @@ func merge(templateFilePath string, partial bool, stubFilePaths []string) {
 	}
 
 	if err != nil {
-		log.Fatalln(fmt.Sprintf("error reading template [%s]:", path.Clean(templateFilePath)), err)
+		return nil, nil, &failure{message: fmt.Sprintf("error reading template [%s]:", path.Clean(templateFilePath)), err: err}
 	}
 
-	templateYAML, err := yaml.Parse(templateFilePath, templateFile)
+	templateYAMLs, err := spiff.Unmarshal(templateFilePath, templateFile)
 	if err != nil {
-		log.Fatalln(fmt.Sprintf("error parsing template [%s]:", path.Clean(templateFilePath)), err)
+		return nil, nil, &failure{message: fmt.Sprintf("error parsing template [%s]:", path.Clean(templateFilePath)), err: err}
 	}
 
 	stubs := []yaml.Node{}
@@ -101,7 +502,7 @@ func merge(templateFilePath string, partial bool, stubFilePaths []string) {
 		var err error
 		if stubFilePath == "-" {
 			if stdin {
-				log.Fatalln(fmt.Sprintf("stdin cannot be used twice"))
+				return nil, nil, &failure{message: "stdin cannot be used twice"}
 			}
 			stubFile, err = ioutil.ReadAll(os.Stdin)
 			stdin = true
@@ -109,58 +510,215 @@ func merge(templateFilePath string, partial bool, stubFilePaths []string) {
 			stubFile, err = ioutil.ReadFile(stubFilePath)
 		}
 		if err != nil {
-			log.Fatalln(fmt.Sprintf("error reading stub [%s]:", path.Clean(stubFilePath)), err)
+			return nil, nil, &failure{message: fmt.Sprintf("error reading stub [%s]:", path.Clean(stubFilePath)), err: err}
 		}
 
-		stubYAML, err := yaml.Parse(stubFilePath, stubFile)
+		stubYAMLs, err := spiff.Unmarshal(stubFilePath, stubFile)
 		if err != nil {
-			log.Fatalln(fmt.Sprintf("error parsing stub [%s]:", path.Clean(stubFilePath)), err)
+			return nil, nil, &failure{message: fmt.Sprintf("error parsing stub [%s]:", path.Clean(stubFilePath)), err: err}
 		}
 
-		stubs = append(stubs, stubYAML)
+		stubs = append(stubs, stubYAMLs...)
 	}
 
-	flowed, err := flow.Cascade(templateYAML, partial, stubs...)
-	if !partial && err != nil {
-		legend := "\nerror classification:\n" +
-			" *: error in local dynaml expression\n" +
-			" @: dependent of or involved in a cycle\n" +
-			" -: depending on a node with an error"
-		log.Fatalln("error generating manifest:", err, legend)
+	return templateYAMLs, stubs, nil
+}
+
+func merge(spiff *spiffing.Spiff, templateFilePath string, format string, stubFilePaths []string, outFilePath string) {
+	output, f := render(spiff, templateFilePath, format, stubFilePaths)
+	if f != nil {
+		f.fatal()
 	}
-	if err != nil {
-		flowed = dynaml.ResetUnresolvedNodes(flowed)
+	if outFilePath != "" {
+		if err := ioutil.WriteFile(outFilePath, output, 0644); err != nil {
+			log.Fatalln(fmt.Sprintf("error writing output [%s]:", path.Clean(outFilePath)), err)
+		}
+		return
 	}
-	yaml, err := candiedyaml.Marshal(flowed)
-	if err != nil {
-		log.Fatalln("error marshalling manifest:", err)
+	fmt.Print(string(output))
+}
+
+// errorClassification explains the tags of unresolved nodes.
+const errorClassification = "\nerror classification:\n" +
+	" *: error in local dynaml expression\n" +
+	" %: involved in a cycle\n" +
+	" @: dependent of a cycle or an unresolved node\n" +
+	" -: depending on a node with an error"
+
+// render merges the template with the stubs and provides the
+// marshalled documents.
+func render(spiff *spiffing.Spiff, templateFilePath string, format string, stubFilePaths []string) ([]byte, *failure) {
+	templateYAMLs, stubs, f := loadDocuments(spiff, templateFilePath, stubFilePaths)
+	if f != nil {
+		return nil, f
 	}
 
-	fmt.Println(string(yaml))
+	flowed, err := spiff.Merge(templateYAMLs, stubs...)
+	if !spiff.Partial() && err != nil {
+		doc := ""
+		no := 0
+		if derr, ok := err.(spiffing.DocumentError); ok {
+			doc = fmt.Sprintf(" (document %d)", derr.Document)
+			no = derr.Document
+			err = derr.Err
+		}
+		text := []interface{}{}
+		if _, ok := err.(yaml.SchemaViolations); !ok {
+			text = append(text, errorClassification)
+		}
+		return nil, &failure{message: fmt.Sprintf("error generating manifest%s:", doc), document: no, err: err, text: text}
+	}
+
+	results := [][]byte{}
+	for no, node := range flowed {
+		if err != nil {
+			node = dynaml.ResetUnresolvedNodes(node)
+		}
+		yaml, err := spiff.Marshal(node, format)
+		if err != nil {
+			doc := ""
+			if len(flowed) > 1 {
+				doc = fmt.Sprintf(" (document %d)", no+1)
+			}
+			return nil, &failure{message: fmt.Sprintf("error marshalling manifest%s:", doc), document: no + 1, err: err}
+		}
+		results = append(results, yaml)
+	}
+
+	output := []byte{}
+	for _, yaml := range results {
+		if len(results) > 1 && format != "json" {
+			output = append(output, "---\n"...)
+		}
+		output = append(output, yaml...)
+		output = append(output, '\n')
+	}
+	return output, nil
 }
 
-func diff(aFilePath, bFilePath string, separator string) {
-	aFile, err := ioutil.ReadFile(aFilePath)
-	if err != nil {
-		log.Fatalln(fmt.Sprintf("error reading a [%s]:", path.Clean(aFilePath)), err)
+// eval evaluates an expression against every document of the merged
+// template. Unresolved nodes of the template are only reported if the
+// evaluation of the expression fails because of them.
+func eval(spiff *spiffing.Spiff, expression string, templateFilePath string, format string, stubFilePaths []string) {
+	templateYAMLs, stubs := readDocuments(spiff, templateFilePath, stubFilePaths)
+
+	results := [][]byte{}
+	for no, template := range templateYAMLs {
+		doc := ""
+		if len(templateYAMLs) > 1 {
+			doc = fmt.Sprintf(" (document %d)", no+1)
+		}
+		node, merr := spiff.Cascade(template, stubs...)
+		value, err := spiff.Evaluate(node, expression)
+		if err != nil {
+			unresolved, ok := err.(dynaml.UnresolvedNodes)
+			if nested, nok := merr.(dynaml.UnresolvedNodes); ok && nok && !localErrors(unresolved) {
+				// the expression depends on unresolved nodes of the document
+				unresolved.Nodes = append(unresolved.Nodes, nested.Nodes...)
+				unresolved.Cycles = append(unresolved.Cycles, nested.Cycles...)
+				err = unresolved
+			}
+			text := []interface{}{}
+			if ok {
+				text = append(text, errorClassification)
+			}
+			f := &failure{message: fmt.Sprintf("error evaluating expression%s:", doc), document: no + 1, err: err, text: text}
+			f.fatal()
+		}
+		yaml, err := spiff.Marshal(value, format)
+		if err != nil {
+			log.Fatalln(fmt.Sprintf("error marshalling result%s:", doc), err)
+		}
+		results = append(results, yaml)
 	}
 
-	aYAML, err := yaml.Parse(aFilePath, aFile)
+	for _, yaml := range results {
+		if len(results) > 1 && format != "json" {
+			fmt.Println("---")
+		}
+		fmt.Println(strings.TrimSuffix(string(yaml), "\n"))
+	}
+}
+
+// localErrors checks whether unresolved nodes contain errors
+// in their own expressions.
+func localErrors(unresolved dynaml.UnresolvedNodes) bool {
+	for _, node := range unresolved.Nodes {
+		if node.HasError() {
+			return true
+		}
+	}
+	return false
+}
+
+// readSchema reads a schema file used to validate merge results.
+func readSchema(filePath string) yaml.Node {
+	data, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		log.Fatalln(fmt.Sprintf("error reading schema [%s]:", path.Clean(filePath)), err)
+	}
+	schema, err := parseDocument(filePath, data)
 	if err != nil {
-		log.Fatalln(fmt.Sprintf("error parsing a [%s]:", path.Clean(aFilePath)), err)
+		log.Fatalln(fmt.Sprintf("error parsing schema [%s]:", path.Clean(filePath)), err)
+	}
+	return schema
+}
+
+// readListKeys reads the list key declarations of stub files.
+func readListKeys(filePaths []string) yaml.ListKeys {
+	keys := yaml.ListKeys{}
+	for _, filePath := range filePaths {
+		data, err := ioutil.ReadFile(filePath)
+		if err != nil {
+			log.Fatalln(fmt.Sprintf("error reading keys [%s]:", path.Clean(filePath)), err)
+		}
+		stub, err := parseDocument(filePath, data)
+		if err != nil {
+			log.Fatalln(fmt.Sprintf("error parsing keys [%s]:", path.Clean(filePath)), err)
+		}
+		node, ok := yaml.Find(stub, yaml.ListKeysField)
+		if !ok {
+			log.Fatalln(fmt.Sprintf("no list keys (%s) declared in [%s]", yaml.ListKeysField, path.Clean(filePath)))
+		}
+		declared, err := yaml.ParseListKeys(node)
+		if err != nil {
+			log.Fatalln(fmt.Sprintf("error parsing keys [%s]:", path.Clean(filePath)), err)
+		}
+		keys = append(keys, declared...)
 	}
+	sort.Stable(keys)
+	return keys
+}
 
-	bFile, err := ioutil.ReadFile(bFilePath)
+// readDiffInput reads a document to compare. The role
+// names the document in error messages.
+func readDiffInput(role string, filePath string) yaml.Node {
+	data, err := ioutil.ReadFile(filePath)
 	if err != nil {
-		log.Fatalln(fmt.Sprintf("error reading b [%s]:", path.Clean(bFilePath)), err)
+		log.Fatalln(fmt.Sprintf("error reading %s [%s]:", role, path.Clean(filePath)), err)
 	}
 
-	bYAML, err := yaml.Parse(bFilePath, bFile)
+	node, err := parseDocument(filePath, data)
 	if err != nil {
-		log.Fatalln(fmt.Sprintf("error parsing b [%s]:", path.Clean(bFilePath)), err)
+		log.Fatalln(fmt.Sprintf("error parsing %s [%s]:", role, path.Clean(filePath)), err)
 	}
+	return node
+}
+
+func diff(aFilePath, bFilePath string, separator string, format string, opts compare.Options) {
+	aYAML := readDiffInput("a", aFilePath)
+	bYAML := readDiffInput("b", bFilePath)
 
-	diffs := compare.Compare(aYAML, bYAML)
+	diffs := compare.CompareWithOptions(aYAML, bYAML, opts)
+
+	if format != "" {
+		result, err := spiffing.New().Marshal(compare.DiffsAsNode(diffs), format)
+		if err != nil {
+			log.Fatalln("error marshalling diffs:", err)
+		}
+		fmt.Println(string(result))
+		return
+	}
 
 	if len(diffs) == 0 {
 		fmt.Println("no differences!")
@@ -168,7 +726,7 @@ func diff(aFilePath, bFilePath string, separator string) {
 	}
 
 	for _, diff := range diffs {
-		fmt.Println("Difference in", strings.Join(diff.Path, "."))
+		fmt.Println("Difference in", strings.Join(diff.Path, ".")+typeNote(diff.A, diff.B))
 
 		if diff.A != nil {
 			ayaml, err := candiedyaml.Marshal(diff.A)
@@ -191,3 +749,145 @@ func diff(aFilePath, bFilePath string, separator string) {
 		fmt.Printf(separator)
 	}
 }
+
+// patch prints the differences of two documents as JSON Patch
+// or JSON Merge Patch.
+func patch(aFilePath, bFilePath string, kind string, format string, opts compare.Options) {
+	aYAML := readDiffInput("a", aFilePath)
+	bYAML := readDiffInput("b", bFilePath)
+
+	// list entries are addressed by their position
+	opts.Mode &^= compare.UnorderedLists
+
+	var result yaml.Node
+	if kind == "merge" {
+		result = compare.MergePatch(bYAML, compare.CompareWithOptions(aYAML, bYAML, opts))
+	} else {
+		result = compare.PatchAsNode(compare.JSONPatch(aYAML, bYAML, opts))
+	}
+	if format == "" {
+		format = spiffing.JSON
+	}
+	data, err := spiffing.New().Marshal(result, format)
+	if err != nil {
+		log.Fatalln("error marshalling patch:", err)
+	}
+	fmt.Println(string(data))
+}
+
+// applyPatch prints a document with a patch applied. Lists
+// are handled as JSON Patch, maps as JSON Merge Patch.
+func applyPatch(docFilePath, patchFilePath string, format string) {
+	doc := readDiffInput("document", docFilePath)
+	patch := readDiffInput("patch", patchFilePath)
+
+	if _, ok := patch.Value().([]yaml.Node); ok {
+		ops, err := compare.ParsePatch(patch)
+		if err != nil {
+			log.Fatalln(fmt.Sprintf("error parsing patch [%s]:", path.Clean(patchFilePath)), err)
+		}
+		doc, err = compare.ApplyPatch(doc, ops)
+		if err != nil {
+			log.Fatalln("error applying patch:", err)
+		}
+	} else {
+		doc = compare.ApplyMergePatch(doc, patch)
+	}
+
+	data, err := spiffing.New().Marshal(doc, format)
+	if err != nil {
+		log.Fatalln("error marshalling document:", err)
+	}
+	fmt.Println(string(data))
+}
+
+// typeNote describes the differing types of simple values,
+// which may be rendered identically (like 1 and 1.0).
+func typeNote(a, b yaml.Node) string {
+	if a == nil || b == nil {
+		return ""
+	}
+	ta, tb := typeName(a.Value()), typeName(b.Value())
+	if ta == "" || tb == "" || ta == tb {
+		return ""
+	}
+	return fmt.Sprintf(" (%s vs %s)", ta, tb)
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case int64, int:
+		return "int"
+	case float64:
+		return "float"
+	case string:
+		return "string"
+	case bool:
+		return "bool"
+	case nil:
+		return "null"
+	}
+	return ""
+}
+
+// diff3 prints the changes of two documents derived from a common
+// base. It reports whether the changes are free of conflicts.
+func diff3(baseFilePath, oursFilePath, theirsFilePath string, separator string, format string, opts compare.Options) bool {
+	baseYAML := readDiffInput("base", baseFilePath)
+	oursYAML := readDiffInput("ours", oursFilePath)
+	theirsYAML := readDiffInput("theirs", theirsFilePath)
+
+	diffs := compare.Compare3WithOptions(baseYAML, oursYAML, theirsYAML, opts)
+	ok := len(compare.Conflicts(diffs)) == 0
+
+	if format != "" {
+		result, err := spiffing.New().Marshal(compare.Diffs3AsNode(diffs), format)
+		if err != nil {
+			log.Fatalln("error marshalling diffs:", err)
+		}
+		fmt.Println(string(result))
+		return ok
+	}
+
+	if len(diffs) == 0 {
+		fmt.Println("no differences!")
+		return ok
+	}
+
+	for _, diff := range diffs {
+		location := strings.Join(diff.Path, ".")
+		switch diff.Change {
+		case compare.ChangedOurs:
+			fmt.Println("Changed in", oursFilePath+":", location)
+		case compare.ChangedTheirs:
+			fmt.Println("Changed in", theirsFilePath+":", location)
+		case compare.ChangedBoth:
+			fmt.Println("Changed in both:", location)
+		default:
+			fmt.Println("Conflict in", location)
+		}
+
+		printDiffValue(baseFilePath, diff.Base, "")
+		printDiffValue(oursFilePath, diff.Ours, "\x1b[31m")
+		printDiffValue(theirsFilePath, diff.Theirs, "\x1b[32m")
+
+		fmt.Print(separator)
+	}
+	return ok
+}
+
+func printDiffValue(filePath string, node yaml.Node, color string) {
+	if node == nil {
+		fmt.Printf("  %s has no value\n", filePath)
+		return
+	}
+	data, err := candiedyaml.Marshal(node)
+	if err != nil {
+		panic(err)
+	}
+	value := strings.Replace(string(data), "\n", "\n    ", -1)
+	if color != "" {
+		value = color + value + "\x1b[0m"
+	}
+	fmt.Printf("  %s has:\n    %s\n", filePath, value)
+}
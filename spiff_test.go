@@ -1,14 +1,19 @@
 package main
 
 import (
+	"encoding/json"
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"strings"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	. "github.com/onsi/gomega/gbytes"
 	. "github.com/onsi/gomega/gexec"
+
+	"github.com/cloudfoundry-incubator/spiff/spiffing"
 )
 
 var _ = Describe("Running spiff", func() {
@@ -53,5 +58,589 @@ foo: bar
 				Expect(merge.Out).To(Say(`foo: bar`))
 			})
 		})
+
+		Context("when given multi document streams", func() {
+			var template *os.File
+			var stub *os.File
+
+			BeforeEach(func() {
+				var err error
+
+				template, err = ioutil.TempFile(os.TempDir(), "template.yml")
+				Expect(err).NotTo(HaveOccurred())
+				template.Write([]byte(`
+---
+foo: (( merge ))
+---
+bar: (( merge ))
+`))
+				stub, err = ioutil.TempFile(os.TempDir(), "stub.yml")
+				Expect(err).NotTo(HaveOccurred())
+				stub.Write([]byte(`
+---
+foo: first
+bar: overridden
+---
+bar: second
+`))
+				merge, err = Start(exec.Command(spiff, "merge", template.Name(), stub.Name()), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				os.Remove(template.Name())
+				os.Remove(stub.Name())
+			})
+
+			It("resolves every template document against all stub documents", func() {
+				Expect(merge.Wait()).To(Exit(0))
+				Expect(merge.Out).To(Say(`---\nfoo: first\n`))
+				Expect(merge.Out).To(Say(`---\nbar: second\n`))
+			})
+		})
+
+		Context("when given json input and json output", func() {
+			var dir string
+
+			BeforeEach(func() {
+				var err error
+
+				dir, err = ioutil.TempDir(os.TempDir(), "spiff")
+				Expect(err).NotTo(HaveOccurred())
+				template := filepath.Join(dir, "template.json")
+				err = ioutil.WriteFile(template, []byte(`{ "foo": "(( bar ))", "bar": [ 1.5, true ] }`), 0644)
+				Expect(err).NotTo(HaveOccurred())
+				merge, err = Start(exec.Command(spiff, "merge", "--output", "json", template), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(dir)
+			})
+
+			It("resolves the template and prints it as json", func() {
+				Expect(merge.Wait()).To(Exit(0))
+				Expect(merge.Out).To(Say(`"bar": \[\s+1.5,\s+true\s+\],\s+"foo": \[\s+1.5,\s+true\s+\]\s+}`))
+			})
+		})
+
+		Context("when running in safe mode", func() {
+			var template *os.File
+
+			BeforeEach(func() {
+				var err error
+
+				template, err = ioutil.TempFile(os.TempDir(), "safe.yml")
+				Expect(err).NotTo(HaveOccurred())
+				template.Write([]byte(`
+---
+foo: (( exec("echo", "bar") ))
+`))
+			})
+
+			AfterEach(func() {
+				os.Remove(template.Name())
+			})
+
+			It("denies the execution of commands", func() {
+				var err error
+				merge, err = Start(exec.Command(spiff, "merge", "--safe", template.Name()), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(merge.Wait()).To(Exit(1))
+				Expect(merge.Err).To(Say("command 'echo' not allowed in safe mode"))
+			})
+
+			It("executes allowed commands", func() {
+				var err error
+				merge, err = Start(exec.Command(spiff, "merge", "--safe", "--allow-exec", "echo", template.Name()), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(merge.Wait()).To(Exit(0))
+				Expect(merge.Out).To(Say("foo: bar"))
+			})
+		})
+
+		Context("when requesting errors as json", func() {
+			var template *os.File
+
+			BeforeEach(func() {
+				var err error
+
+				template, err = ioutil.TempFile(os.TempDir(), "errors.yml")
+				Expect(err).NotTo(HaveOccurred())
+				template.Write([]byte(`
+---
+a: (( b ))
+b: (( a ))
+c: (( 1 / 0 ))
+`))
+				merge, err = Start(exec.Command(spiff, "merge", "--errors", "json", template.Name()), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				os.Remove(template.Name())
+			})
+
+			It("prints the classified nodes", func() {
+				Expect(merge.Wait()).To(Exit(1))
+
+				var report struct {
+					Message string
+					Nodes   []struct {
+						Path           string
+						Source         string
+						Line           int
+						Expression     string
+						Classification string
+					}
+					Cycles [][]struct {
+						Path   string
+						Source string
+						Line   int
+						Column int
+					}
+				}
+				Expect(json.Unmarshal(merge.Err.Contents(), &report)).To(Succeed())
+				Expect(report.Message).To(Equal("error generating manifest: unresolved nodes"))
+				Expect(report.Nodes).To(HaveLen(3))
+				Expect(report.Nodes[0].Path).To(Equal("c"))
+				Expect(report.Nodes[0].Source).To(Equal(template.Name()))
+				Expect(report.Nodes[0].Line).To(Equal(5))
+				Expect(report.Nodes[0].Expression).To(Equal("(( 1 / 0 ))"))
+				Expect(report.Nodes[0].Classification).To(Equal("error"))
+				Expect(report.Nodes[1].Classification).To(Equal("cycle"))
+				Expect(report.Cycles).To(HaveLen(1))
+				Expect(report.Cycles[0]).To(HaveLen(2))
+				Expect(report.Cycles[0][0].Path).To(Equal("a"))
+				Expect(report.Cycles[0][0].Source).To(Equal(template.Name()))
+				Expect(report.Cycles[0][0].Line).To(Equal(3))
+				Expect(report.Cycles[0][0].Column).To(Equal(4))
+				Expect(report.Cycles[0][1].Path).To(Equal("b"))
+				Expect(report.Cycles[0][1].Line).To(Equal(4))
+			})
+		})
+
+		Context("when given an invalid output format", func() {
+			BeforeEach(func() {
+				var err error
+				merge, err = Start(exec.Command(spiff, "merge", "--output", "xml", "foo.yml"), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("fails", func() {
+				Expect(merge.Wait()).To(Exit(1))
+				Expect(merge.Err).To(Say("invalid output format 'xml'"))
+			})
+		})
+
+		Context("when watching the input files", func() {
+			var dir string
+			var out string
+
+			BeforeEach(func() {
+				var err error
+
+				dir, err = ioutil.TempDir(os.TempDir(), "watch")
+				Expect(err).NotTo(HaveOccurred())
+				out = filepath.Join(dir, "out.yml")
+				ioutil.WriteFile(filepath.Join(dir, "template.yml"), []byte(`
+---
+foo: (( merge ))
+bar: (( read("`+filepath.Join(dir, "value.yml")+`") ))
+`), 0644)
+				ioutil.WriteFile(filepath.Join(dir, "stub.yml"), []byte("foo: first\n"), 0644)
+				ioutil.WriteFile(filepath.Join(dir, "value.yml"), []byte("value: 1\n"), 0644)
+
+				merge, err = Start(exec.Command(spiff, "merge", "--watch", "-o", out,
+					filepath.Join(dir, "template.yml"), filepath.Join(dir, "stub.yml")), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				merge.Kill().Wait()
+				os.RemoveAll(dir)
+			})
+
+			readOut := func() string {
+				data, _ := ioutil.ReadFile(out)
+				return string(data)
+			}
+
+			It("renders again when a stub or a read file changes", func() {
+				Eventually(readOut, 5).Should(Equal("bar:\n  value: 1\nfoo: first\n\n"))
+
+				ioutil.WriteFile(filepath.Join(dir, "stub.yml"), []byte("foo: second\n"), 0644)
+				Eventually(readOut, 5).Should(Equal("bar:\n  value: 1\nfoo: second\n\n"))
+
+				ioutil.WriteFile(filepath.Join(dir, "value.yml"), []byte("value: 22\n"), 0644)
+				Eventually(readOut, 5).Should(Equal("bar:\n  value: 22\nfoo: second\n\n"))
+				Expect(merge).NotTo(Exit())
+			})
+		})
+
+		Context("when watching without an output file", func() {
+			BeforeEach(func() {
+				var err error
+				merge, err = Start(exec.Command(spiff, "merge", "--watch", "foo.yml"), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("fails", func() {
+				Expect(merge.Wait()).To(Exit(1))
+				Expect(merge.Err).To(Say("watch mode requires an output file"))
+			})
+		})
+
+		Context("when the result cannot be marshalled", func() {
+			var dir string
+
+			BeforeEach(func() {
+				var err error
+				dir, err = ioutil.TempDir(os.TempDir(), "render")
+				Expect(err).NotTo(HaveOccurred())
+				ioutil.WriteFile(filepath.Join(dir, "template.yml"), []byte("foo: bar\n"), 0644)
+			})
+
+			AfterEach(func() {
+				os.RemoveAll(dir)
+			})
+
+			It("reports the failure to the caller", func() {
+				output, f := render(spiffing.New(), filepath.Join(dir, "template.yml"), "xml", nil)
+				Expect(output).To(BeNil())
+				Expect(f).NotTo(BeNil())
+				Expect(f.Error()).To(ContainSubstring("error marshalling manifest: invalid output format 'xml'"))
+			})
+		})
+	})
+
+	Describe("eval", func() {
+		var eval *Session
+		var template *os.File
+
+		BeforeEach(func() {
+			var err error
+
+			template, err = ioutil.TempFile(os.TempDir(), "template.yml")
+			Expect(err).NotTo(HaveOccurred())
+			template.Write([]byte(`
+---
+jobs:
+  - name: web
+    instances: (( 1 + 1 ))
+broken: (( unknown ))
+`))
+		})
+
+		AfterEach(func() {
+			os.Remove(template.Name())
+		})
+
+		Context("when given a resolvable expression", func() {
+			BeforeEach(func() {
+				var err error
+				eval, err = Start(exec.Command(spiff, "eval", "jobs.web.instances * 2", template.Name()), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("prints the result", func() {
+				Expect(eval.Wait()).To(Exit(0))
+				Expect(string(eval.Out.Contents())).To(Equal("4\n"))
+			})
+		})
+
+		Context("when the expression depends on unresolved nodes", func() {
+			BeforeEach(func() {
+				var err error
+				eval, err = Start(exec.Command(spiff, "eval", "(( broken ))", template.Name()), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("prints the issue with the unresolved nodes of the template", func() {
+				Expect(eval.Wait()).To(Exit(1))
+				Expect(eval.Err).To(Say(`error evaluating expression`))
+				Expect(eval.Err).To(Say(`-'broken' unresolved`))
+				Expect(eval.Err).To(Say(`broken\t\(\)\t\*'unknown' not found`))
+				Expect(eval.Err).To(Say(`error classification:`))
+			})
+		})
+	})
+
+	Describe("repl", func() {
+		var repl *Session
+		var template *os.File
+
+		BeforeEach(func() {
+			var err error
+
+			template, err = ioutil.TempFile(os.TempDir(), "template.yml")
+			Expect(err).NotTo(HaveOccurred())
+			template.Write([]byte(`
+---
+foo:
+  bar: (( 1 + 2 ))
+broken: (( unknown ))
+`))
+			cmd := exec.Command(spiff, "repl", template.Name())
+			cmd.Stdin = strings.NewReader(`foo.bar * 2
+:show foo
+:unresolved
+:let f = |x|->x * 10
+.f(foo.bar)
+:quit
+`)
+			repl, err = Start(cmd, GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.Remove(template.Name())
+		})
+
+		It("evaluates the given expressions and commands", func() {
+			Expect(repl.Wait()).To(Exit(0))
+			Expect(repl.Out).To(Say(`1 unresolved node\(s\)`))
+			Expect(repl.Out).To(Say(`spiff> 6`))
+			Expect(repl.Out).To(Say(`spiff> bar: 3`))
+			Expect(repl.Out).To(Say(`'unknown' not found`))
+			Expect(repl.Out).To(Say(`spiff> 30`))
+		})
+	})
+
+	Describe("diff", func() {
+		var diff *Session
+
+		Context("when asked for machine readable output", func() {
+			var a *os.File
+			var b *os.File
+
+			BeforeEach(func() {
+				var err error
+
+				a, err = ioutil.TempFile(os.TempDir(), "a.yml")
+				Expect(err).NotTo(HaveOccurred())
+				a.Write([]byte(`
+---
+foo: 1
+bar: 2
+`))
+				b, err = ioutil.TempFile(os.TempDir(), "b.yml")
+				Expect(err).NotTo(HaveOccurred())
+				b.Write([]byte(`
+---
+foo: 3
+bar: 2
+`))
+				diff, err = Start(exec.Command(spiff, "diff", "--output", "json", a.Name(), b.Name()), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			AfterEach(func() {
+				os.Remove(a.Name())
+				os.Remove(b.Name())
+			})
+
+			It("prints the differences as a list", func() {
+				Expect(diff.Wait()).To(Exit(0))
+				Expect(diff.Out).To(Say(`\[\s+{\s+"a": 1,\s+"b": 3,\s+"path": \[\s+"foo"\s+\]\s+}\s+\]`))
+			})
+		})
+	})
+
+	Describe("diff3", func() {
+		var diff *Session
+		var files []*os.File
+
+		write := func(content string) string {
+			f, err := ioutil.TempFile(os.TempDir(), "diff3.yml")
+			Expect(err).NotTo(HaveOccurred())
+			f.Write([]byte(content))
+			files = append(files, f)
+			return f.Name()
+		}
+
+		AfterEach(func() {
+			for _, f := range files {
+				os.Remove(f.Name())
+			}
+			files = nil
+		})
+
+		Context("when the changes conflict", func() {
+			BeforeEach(func() {
+				base := write("foo: 1\nbar: 1\n")
+				ours := write("foo: 2\nbar: 1\n")
+				theirs := write("foo: 3\nbar: 2\n")
+
+				var err error
+				diff, err = Start(exec.Command(spiff, "diff3", "--output", "json", base, ours, theirs), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("classifies the changes and fails", func() {
+				Expect(diff.Wait()).To(Exit(1))
+				Expect(diff.Out).To(Say(`"base": 1,\s+"change": "theirs",\s+"ours": 1,\s+"path": \[\s+"bar"\s+\],\s+"theirs": 2`))
+				Expect(diff.Out).To(Say(`"base": 1,\s+"change": "conflict",\s+"ours": 2,\s+"path": \[\s+"foo"\s+\],\s+"theirs": 3`))
+			})
+		})
+
+		Context("when the changes are compatible", func() {
+			BeforeEach(func() {
+				base := write("foo: 1\nbar: 1\n")
+				ours := write("foo: 2\nbar: 1\n")
+				theirs := write("foo: 2\nbar: 2\n")
+
+				var err error
+				diff, err = Start(exec.Command(spiff, "diff3", base, ours, theirs), GinkgoWriter, GinkgoWriter)
+				Expect(err).NotTo(HaveOccurred())
+			})
+
+			It("prints the changes", func() {
+				Expect(diff.Wait()).To(Exit(0))
+				Expect(diff.Out).To(Say(`Changed in .*: bar`))
+				Expect(diff.Out).To(Say(`Changed in both: foo`))
+			})
+		})
+	})
+
+	Describe("patch", func() {
+		var patch *Session
+		var doc *os.File
+		var ops *os.File
+
+		BeforeEach(func() {
+			var err error
+
+			doc, err = ioutil.TempFile(os.TempDir(), "doc.yml")
+			Expect(err).NotTo(HaveOccurred())
+			doc.Write([]byte(`
+---
+jobs:
+- name: web
+  instances: 1
+`))
+			ops, err = ioutil.TempFile(os.TempDir(), "patch.json")
+			Expect(err).NotTo(HaveOccurred())
+			ops.Write([]byte(`[ { "op": "replace", "path": "/jobs/name:web/instances", "value": 2 } ]`))
+
+			patch, err = Start(exec.Command(spiff, "patch", doc.Name(), ops.Name()), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+		})
+
+		AfterEach(func() {
+			os.Remove(doc.Name())
+			os.Remove(ops.Name())
+		})
+
+		It("prints the patched document", func() {
+			Expect(patch.Wait()).To(Exit(0))
+			Expect(patch.Out).To(Say(`jobs:\n- instances: 2\n  name: web\n`))
+		})
+	})
+
+	Describe("test", func() {
+		var dir string
+
+		BeforeEach(func() {
+			var err error
+
+			dir, err = ioutil.TempDir(os.TempDir(), "cases")
+			Expect(err).NotTo(HaveOccurred())
+			for _, name := range []string{"ok", "error"} {
+				Expect(os.Mkdir(filepath.Join(dir, name), 0755)).To(Succeed())
+			}
+			ioutil.WriteFile(filepath.Join(dir, "ok", "template.yml"), []byte(`
+---
+foo: (( merge ))
+bar: (( foo "-bar" ))
+`), 0644)
+			ioutil.WriteFile(filepath.Join(dir, "ok", "stub.yml"), []byte("foo: foo\n"), 0644)
+			ioutil.WriteFile(filepath.Join(dir, "ok", "expected.yml"), []byte("foo: foo\nbar: foo-bar\n"), 0644)
+			ioutil.WriteFile(filepath.Join(dir, "error", "template.yml"), []byte("foo: (( 1 / 0 ))\n"), 0644)
+			ioutil.WriteFile(filepath.Join(dir, "error", "expected-error"), []byte("division by zero\n"), 0644)
+		})
+
+		AfterEach(func() {
+			os.RemoveAll(dir)
+		})
+
+		It("passes for matching results", func() {
+			test, err := Start(exec.Command(spiff, "test", dir), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(test.Wait()).To(Exit(0))
+			Expect(test.Out).To(Say("PASS .*error\n"))
+			Expect(test.Out).To(Say("PASS .*ok\n"))
+			Expect(test.Out).To(Say("2 passed, 0 failed"))
+		})
+
+		It("reports the differences and fails", func() {
+			ioutil.WriteFile(filepath.Join(dir, "ok", "stub.yml"), []byte("foo: other\n"), 0644)
+
+			test, err := Start(exec.Command(spiff, "test", dir), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(test.Wait()).To(Exit(1))
+			Expect(test.Out).To(Say("FAIL .*ok\n"))
+			Expect(test.Out).To(Say(`difference in bar:\n    expected: "foo-bar"\n    actual:   "other-bar"`))
+			Expect(test.Out).To(Say("1 passed, 1 failed"))
+		})
+
+		It("reports changed orders of list entries", func() {
+			ioutil.WriteFile(filepath.Join(dir, "ok", "template.yml"), []byte("list: [ { name: a }, { name: b } ]\n"), 0644)
+			os.Remove(filepath.Join(dir, "ok", "stub.yml"))
+			ioutil.WriteFile(filepath.Join(dir, "ok", "expected.yml"), []byte("list: [ { name: b }, { name: a } ]\n"), 0644)
+
+			test, err := Start(exec.Command(spiff, "test", dir), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(test.Wait()).To(Exit(1))
+			Expect(test.Out).To(Say("FAIL .*ok\n"))
+			Expect(test.Out).To(Say(`difference in list:\n    expected: \[{"name":"b"},{"name":"a"}\]\n    actual:   \[{"name":"a"},{"name":"b"}\]`))
+		})
+
+		It("ignores other files and directories in test case directories", func() {
+			Expect(os.Mkdir(filepath.Join(dir, "ok", "stubs"), 0755)).To(Succeed())
+			ioutil.WriteFile(filepath.Join(dir, "ok", "stub.yml~"), []byte("foo: other\n"), 0644)
+
+			test, err := Start(exec.Command(spiff, "test", dir), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(test.Wait()).To(Exit(0))
+			Expect(test.Out).To(Say("2 passed, 0 failed"))
+		})
+
+		It("updates the expected results", func() {
+			ioutil.WriteFile(filepath.Join(dir, "ok", "stub.yml"), []byte("foo: other\n"), 0644)
+
+			test, err := Start(exec.Command(spiff, "test", "--update", dir), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(test.Wait()).To(Exit(0))
+
+			expected, err := ioutil.ReadFile(filepath.Join(dir, "ok", "expected.yml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(expected)).To(Equal("bar: other-bar\nfoo: other\n"))
+		})
+
+		It("passes after updating the expected results of multiple documents", func() {
+			ioutil.WriteFile(filepath.Join(dir, "ok", "template.yml"), []byte(`
+---
+foo: (( merge ))
+---
+bar: (( "foo" "-bar" ))
+---
+- (( 1 + 1 ))
+`), 0644)
+			os.Remove(filepath.Join(dir, "ok", "expected.yml"))
+
+			update, err := Start(exec.Command(spiff, "test", "--update", dir), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(update.Wait()).To(Exit(0))
+
+			expected, err := ioutil.ReadFile(filepath.Join(dir, "ok", "expected.yml"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(expected)).To(Equal("---\nfoo: foo\n---\nbar: foo-bar\n---\n- 2\n"))
+
+			test, err := Start(exec.Command(spiff, "test", dir), GinkgoWriter, GinkgoWriter)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(test.Wait()).To(Exit(0))
+			Expect(test.Out).To(Say("2 passed, 0 failed"))
+		})
 	})
 })
@@ -0,0 +1,299 @@
+// Package spiffing provides the processing functionality of spiff
+// as library.
+//
+// A Spiff object keeps the settings for processing documents.
+// Settings are modified with the With... methods, which always
+// return a modified copy. Therefore a configured Spiff object
+// can be shared and used concurrently. Every processing request
+// uses its own processing state (file and command caches).
+package spiffing
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+
+	"github.com/cloudfoundry-incubator/spiff/compare"
+	"github.com/cloudfoundry-incubator/spiff/dynaml"
+	"github.com/cloudfoundry-incubator/spiff/flow"
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+// Output formats supported by Marshal
+const (
+	YAML = "yaml"
+	JSON = "json"
+)
+
+type Spiff struct {
+	partial    bool
+	stubs      []yaml.Node
+	environ    []string
+	fileSystem dynaml.FileSystem
+	registry   dynaml.Registry
+	functions  []string
+	sandbox    *dynaml.Sandbox
+	debug      io.Writer
+	explainOut io.Writer
+	explain    []string
+	schema     yaml.Node
+	compare    compare.Options
+}
+
+// New creates a Spiff object using the file system and the
+// environment of the operating system.
+func New() *Spiff {
+	return &Spiff{
+		environ:    os.Environ(),
+		fileSystem: dynaml.OSFileSystem,
+	}
+}
+
+// WithPartial enables or disables partial evaluation. With partial
+// evaluation unresolved nodes are not treated as error.
+func (s *Spiff) WithPartial(partial bool) *Spiff {
+	n := *s
+	n.partial = partial
+	return &n
+}
+
+// Partial reports whether partial evaluation is enabled.
+func (s *Spiff) Partial() bool {
+	return s.partial
+}
+
+// WithStubs sets additional stubs used for every processing request.
+// They are used before the stubs given for a dedicated request, so
+// request specific stubs override them.
+func (s *Spiff) WithStubs(stubs ...yaml.Node) *Spiff {
+	n := *s
+	n.stubs = stubs
+	return &n
+}
+
+// WithEnvironment sets the environment (list of name=value settings)
+// visible for the env function.
+func (s *Spiff) WithEnvironment(environ []string) *Spiff {
+	n := *s
+	n.environ = environ
+	return &n
+}
+
+// WithFileSystem sets the file system used to read files.
+func (s *Spiff) WithFileSystem(fs dynaml.FileSystem) *Spiff {
+	n := *s
+	n.fileSystem = fs
+	return &n
+}
+
+// WithRegistry sets the registry of functions callable by
+// dynaml expressions.
+func (s *Spiff) WithRegistry(registry dynaml.Registry) *Spiff {
+	n := *s
+	n.registry = registry
+	return &n
+}
+
+// WithFunction adds or overrides a function callable by dynaml
+// expressions. An optional signature is used to check the arguments.
+func (s *Spiff) WithFunction(name string, f dynaml.Function, signature ...dynaml.Signature) *Spiff {
+	n := *s
+	if s.registry == nil {
+		n.registry = dynaml.NewRegistry()
+	} else {
+		n.registry = s.registry.Copy()
+	}
+	n.registry.RegisterFunction(name, f, signature...)
+	return &n
+}
+
+// Functions lists the names of the functions callable by dynaml expressions.
+func (s *Spiff) Functions() []string {
+	if s.registry == nil {
+		return dynaml.Builtins()
+	}
+	return s.registry.Names()
+}
+
+// WithFunctions restricts the functions callable by dynaml
+// expressions to the given names. Called without names,
+// all functions are allowed again.
+func (s *Spiff) WithFunctions(names ...string) *Spiff {
+	n := *s
+	if len(names) == 0 {
+		n.functions = nil
+	} else {
+		n.functions = names
+	}
+	return &n
+}
+
+// WithSandbox enables the safe mode restricting the access
+// of the functions exec, env and read to the given allow-lists.
+// A nil sandbox disables the safe mode.
+func (s *Spiff) WithSandbox(sandbox *dynaml.Sandbox) *Spiff {
+	n := *s
+	n.sandbox = sandbox
+	return &n
+}
+
+// WithDebug sets the writer for the debug output of
+// the document processing.
+func (s *Spiff) WithDebug(w io.Writer) *Spiff {
+	n := *s
+	n.debug = w
+	return &n
+}
+
+// WithExplain enables recording the evaluation steps of the nodes
+// with the given paths (dot separated) to the given writer. This
+// covers the evaluated expressions, the references they use, the
+// values taken from stubs and the final result.
+func (s *Spiff) WithExplain(w io.Writer, paths ...string) *Spiff {
+	n := *s
+	n.explainOut = w
+	n.explain = paths
+	return &n
+}
+
+// WithSchema sets a schema every processing result is validated
+// against (see yaml.ValidateSchema). Violations are reported as
+// yaml.SchemaViolations error.
+func (s *Spiff) WithSchema(schema yaml.Node) *Spiff {
+	n := *s
+	n.schema = schema
+	return &n
+}
+
+// WithCompareOptions sets the options used by Diff to compare
+// documents (see compare.Options).
+func (s *Spiff) WithCompareOptions(opts compare.Options) *Spiff {
+	n := *s
+	n.compare = opts
+	return &n
+}
+
+func (s *Spiff) newState() *flow.State {
+	state := flow.NewState().
+		WithFileSystem(s.fileSystem).
+		WithEnvironment(s.environ).
+		WithSandbox(s.sandbox).
+		WithDebug(s.debug)
+	if s.registry != nil {
+		state.WithRegistry(s.registry)
+	}
+	if s.functions != nil {
+		state.WithFunctions(s.functions...)
+	}
+	if s.explainOut != nil && len(s.explain) > 0 {
+		state.WithExplain(s.explainOut, s.explain...)
+	}
+	return state
+}
+
+// Unmarshal parses the documents of a yaml stream. If the name
+// has the suffix .json, the source is parsed as sequence of
+// JSON values.
+func (s *Spiff) Unmarshal(name string, source []byte) ([]yaml.Node, error) {
+	if strings.HasSuffix(name, ".json") {
+		return yaml.ParseJSONMulti(name, source)
+	}
+	return yaml.ParseMulti(name, source)
+}
+
+// ReadFile reads and parses the documents of a file using the
+// configured file system.
+func (s *Spiff) ReadFile(path string) ([]yaml.Node, error) {
+	data, err := s.fileSystem.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return s.Unmarshal(path, data)
+}
+
+// Marshal renders a document in the given format (YAML or JSON).
+func (s *Spiff) Marshal(node yaml.Node, format string) ([]byte, error) {
+	switch format {
+	case YAML:
+		return candiedyaml.Marshal(node)
+	case JSON:
+		return yaml.ToJSON(node)
+	default:
+		return nil, fmt.Errorf("invalid output format '%s'", format)
+	}
+}
+
+// Cascade processes a template with the given stubs. Later stubs
+// override earlier ones. The given stub list is not modified.
+// In partial mode an evaluation error is returned together with
+// the partially evaluated document.
+// With a schema a successfully processed document is validated.
+func (s *Spiff) Cascade(template yaml.Node, stubs ...yaml.Node) (yaml.Node, error) {
+	all := make([]yaml.Node, 0, len(s.stubs)+len(stubs))
+	all = append(all, s.stubs...)
+	all = append(all, stubs...)
+	result, err := flow.CascadeWithState(s.newState(), template, s.partial, all...)
+	if err != nil || s.schema == nil {
+		return result, err
+	}
+	violations, err := yaml.ValidateSchema(result, s.schema)
+	if err != nil {
+		return result, err
+	}
+	if len(violations) > 0 {
+		return result, violations
+	}
+	return result, nil
+}
+
+// Merge processes every document of a template stream separately
+// against all stubs. For multiple documents errors are reported as
+// DocumentError. In partial mode all documents are processed and
+// the first error is returned together with the results.
+func (s *Spiff) Merge(templates []yaml.Node, stubs ...yaml.Node) ([]yaml.Node, error) {
+	var failed error
+
+	results := []yaml.Node{}
+	for i, template := range templates {
+		result, err := s.Cascade(template, stubs...)
+		if err != nil {
+			if len(templates) > 1 {
+				err = DocumentError{i + 1, err}
+			}
+			if !s.partial {
+				return nil, err
+			}
+			if failed == nil {
+				failed = err
+			}
+		}
+		results = append(results, result)
+	}
+	return results, failed
+}
+
+// Evaluate evaluates a dynaml expression in the root scope of a
+// document, typically the result of a previous merge.
+func (s *Spiff) Evaluate(document yaml.Node, expression string) (yaml.Node, error) {
+	return flow.EvaluateWithState(s.newState(), document, expression)
+}
+
+// Diff determines the structural differences of two documents
+// using the configured compare options.
+func (s *Spiff) Diff(a, b yaml.Node) []compare.Diff {
+	return compare.CompareWithOptions(a, b, s.compare)
+}
+
+// DocumentError describes an error of a dedicated document
+// of a multi-document stream.
+type DocumentError struct {
+	Document int
+	Err      error
+}
+
+func (e DocumentError) Error() string {
+	return fmt.Sprintf("(document %d) %s", e.Document, e.Err)
+}
@@ -0,0 +1,287 @@
+package spiffing
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+
+	"github.com/cloudfoundry-incubator/spiff/compare"
+	"github.com/cloudfoundry-incubator/spiff/dynaml"
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+type fakeFileSystem map[string]string
+
+func (fs fakeFileSystem) ReadFile(path string) ([]byte, error) {
+	data, ok := fs[path]
+	if !ok {
+		return nil, os.ErrNotExist
+	}
+	return []byte(data), nil
+}
+
+func marshal(spiff *Spiff, node yaml.Node) string {
+	data, err := spiff.Marshal(node, YAML)
+	Expect(err).NotTo(HaveOccurred())
+	return string(data)
+}
+
+var _ = Describe("Spiffing", func() {
+	spiff := New()
+
+	Context("cascading", func() {
+		template := parseYAML(`
+foo: (( merge ))
+bar: (( foo ))
+`)
+
+		It("processes a template with stubs", func() {
+			result, err := spiff.Cascade(template, parseYAML("foo: first"), parseYAML("foo: second"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(marshal(spiff, result)).To(Equal("bar: second\nfoo: second\n"))
+		})
+
+		It("does not modify the given stubs", func() {
+			stubs := []yaml.Node{parseYAML("foo: (( bar ))\nbar: alice")}
+			_, err := spiff.Cascade(template, stubs...)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(stubs[0]).To(Equal(parseYAML("foo: (( bar ))\nbar: alice")))
+		})
+
+		It("uses configured stubs before the given ones", func() {
+			s := spiff.WithStubs(parseYAML("foo: default"))
+			result, err := s.Cascade(template)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(marshal(s, result)).To(Equal("bar: default\nfoo: default\n"))
+
+			result, err = s.Cascade(template, parseYAML("foo: given"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(marshal(s, result)).To(Equal("bar: given\nfoo: given\n"))
+		})
+
+		It("fails for unresolved nodes", func() {
+			_, err := spiff.Cascade(template)
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("returns the partial result in partial mode", func() {
+			result, err := spiff.WithPartial(true).Cascade(parseYAML("foo: (( bar ))\nalice: bob"))
+			Expect(err).To(HaveOccurred())
+			Expect(result).NotTo(BeNil())
+		})
+
+		It("validates the result against the configured schema", func() {
+			s := spiff.WithSchema(parseYAML(`
+properties:
+  foo:
+    type: integer
+`))
+			_, err := s.Cascade(template, parseYAML("foo: 1"))
+			Expect(err).NotTo(HaveOccurred())
+
+			_, err = s.Cascade(template, parseYAML("foo: two"))
+			Expect(err).To(BeAssignableToTypeOf(yaml.SchemaViolations{}))
+			Expect(err.(yaml.SchemaViolations)[0].Path).To(Equal([]string{"foo"}))
+		})
+	})
+
+	Context("settings", func() {
+		It("leaves the original object unchanged", func() {
+			s := spiff.WithPartial(true)
+			Expect(s.partial).To(BeTrue())
+			Expect(spiff.partial).To(BeFalse())
+		})
+
+		It("uses the configured environment", func() {
+			s := spiff.WithEnvironment([]string{"ALICE=bob"})
+			result, err := s.Cascade(parseYAML(`foo: (( env("ALICE") ))`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(marshal(s, result)).To(Equal("foo: bob\n"))
+		})
+
+		It("uses the configured file system", func() {
+			s := spiff.WithFileSystem(fakeFileSystem{"data.yml": "alice: (( \"b\" \"ob\" ))"})
+			result, err := s.Cascade(parseYAML(`foo: (( read("data.yml") ))`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(marshal(s, result)).To(Equal("foo:\n  alice: bob\n"))
+		})
+
+		It("rejects reading multiple documents", func() {
+			s := spiff.WithFileSystem(fakeFileSystem{"data.yml": "alice: bob\n---\nbob: alice\n"})
+			_, err := s.Cascade(parseYAML(`foo: (( read("data.yml") ))`))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("single document expected, but found 2 documents"))
+		})
+
+		It("provides the yaml output of commands", func() {
+			result, err := spiff.Cascade(parseYAML(`foo: '(( exec("sh", "-c", "echo ---; echo alice: bob") ))'`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(marshal(spiff, result)).To(Equal("foo:\n  alice: bob\n"))
+		})
+
+		It("provides command output with multiple documents as text", func() {
+			result, err := spiff.Cascade(parseYAML(`foo: '(( exec("sh", "-c", "echo ---; echo alice: bob; echo ---; echo bob: alice") ))'`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(result.Value().(map[string]yaml.Node)["foo"].Value()).To(Equal("---\nalice: bob\n---\nbob: alice"))
+		})
+
+		It("restricts the callable functions", func() {
+			s := spiff.WithFunctions("join")
+			result, err := s.Cascade(parseYAML(`foo: (( join(",", "a", "b") ))`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(marshal(s, result)).To(Equal("foo: a,b\n"))
+
+			_, err = s.Cascade(parseYAML(`foo: (( env("HOME") ))`))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("function 'env' not allowed"))
+		})
+
+		It("calls registered functions", func() {
+			s := spiff.WithFunction("twice", func(arguments []interface{}, binding dynaml.Binding) (interface{}, dynaml.EvaluationInfo, bool) {
+				return arguments[0].(int64) * 2, dynaml.DefaultInfo(), true
+			}, dynaml.Signature{MinArgs: 1, MaxArgs: 1, Types: []dynaml.ArgumentType{dynaml.TypeInt}})
+
+			Expect(s.Functions()).To(ContainElement("twice"))
+			Expect(spiff.Functions()).NotTo(ContainElement("twice"))
+
+			result, err := s.Cascade(parseYAML(`foo: (( twice(21) ))`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(marshal(s, result)).To(Equal("foo: 42\n"))
+
+			_, err = s.Cascade(parseYAML(`foo: (( twice("a") ))`))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("argument 1 for function 'twice' must be of type int"))
+		})
+
+		It("does not call disabled functions", func() {
+			registry := dynaml.NewRegistry()
+			registry.UnregisterFunction("exec")
+			_, err := spiff.WithRegistry(registry).Cascade(parseYAML(`foo: (( exec("echo") ))`))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("unknown function 'exec'"))
+		})
+
+		It("restricts the host access in safe mode", func() {
+			s := spiff.
+				WithEnvironment([]string{"ALICE=bob", "BOB=alice"}).
+				WithFileSystem(fakeFileSystem{"/data/file.yml": "foo: bar"}).
+				WithSandbox(&dynaml.Sandbox{Environment: []string{"AL"}})
+
+			result, err := s.Cascade(parseYAML(`foo: (( env("ALICE") ))`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(marshal(s, result)).To(Equal("foo: bob\n"))
+
+			_, err = s.Cascade(parseYAML(`foo: (( env("BOB") ))`))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("environment variable 'BOB' not allowed in safe mode"))
+
+			_, err = s.Cascade(parseYAML(`foo: (( read("/data/file.yml") ))`))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("file '/data/file.yml' not allowed in safe mode"))
+
+			_, err = s.Cascade(parseYAML(`foo: (( exec("echo", "bar") ))`))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("command 'echo' not allowed in safe mode"))
+		})
+
+		It("writes debug output to the configured writer", func() {
+			buffer := &bytes.Buffer{}
+			_, err := spiff.WithDebug(buffer).Cascade(parseYAML("foo: (( \"bar\" ))"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buffer.String()).To(ContainSubstring("FLOW"))
+		})
+
+		It("writes the debug output of dynaml functions to the configured writer", func() {
+			buffer := &bytes.Buffer{}
+			_, err := spiff.WithDebug(buffer).Cascade(parseYAML(`foo: (( [ 1 ] == [ 2 ] ))`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(buffer.String()).To(ContainSubstring("compare list entry 0 mismatch"))
+		})
+
+		It("can be used concurrently", func() {
+			done := make(chan string)
+			for i := 0; i < 10; i++ {
+				go func(i int) {
+					defer GinkgoRecover()
+					s := spiff.WithEnvironment([]string{fmt.Sprintf("VALUE=%d", i)})
+					result, err := s.Cascade(parseYAML(`foo: (( env("VALUE") ))`))
+					Expect(err).NotTo(HaveOccurred())
+					done <- fmt.Sprintf("%d:%s", i, result.Value().(map[string]yaml.Node)["foo"].Value())
+				}(i)
+			}
+			for i := 0; i < 10; i++ {
+				var i1, i2 int
+				fmt.Sscanf(<-done, "%d:%d", &i1, &i2)
+				Expect(i1).To(Equal(i2))
+			}
+		})
+	})
+
+	Context("merging", func() {
+		It("processes every template document", func() {
+			templates, err := spiff.Unmarshal("test", []byte("foo: (( merge ))\n---\nbar: (( merge ))\n"))
+			Expect(err).NotTo(HaveOccurred())
+			results, err := spiff.Merge(templates, parseYAML("foo: 1\nbar: 2"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(results).To(HaveLen(2))
+			Expect(marshal(spiff, results[0])).To(Equal("foo: 1\n"))
+			Expect(marshal(spiff, results[1])).To(Equal("bar: 2\n"))
+		})
+
+		It("reports the failing document", func() {
+			templates, err := spiff.Unmarshal("test", []byte("foo: 1\n---\nbar: (( merge ))\n"))
+			Expect(err).NotTo(HaveOccurred())
+			_, err = spiff.Merge(templates)
+			Expect(err).To(BeAssignableToTypeOf(DocumentError{}))
+			Expect(err.(DocumentError).Document).To(Equal(2))
+		})
+	})
+
+	Context("marshalling", func() {
+		It("parses and renders json", func() {
+			docs, err := spiff.Unmarshal("test.json", []byte(`{"foo": [1, "bar"]}`))
+			Expect(err).NotTo(HaveOccurred())
+			data, err := spiff.Marshal(docs[0], JSON)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal("{\n  \"foo\": [\n    1,\n    \"bar\"\n  ]\n}"))
+		})
+
+		It("rejects unknown formats", func() {
+			_, err := spiff.Marshal(parseYAML("foo: bar"), "xml")
+			Expect(err).To(MatchError("invalid output format 'xml'"))
+		})
+	})
+
+	Context("evaluating", func() {
+		It("evaluates expressions in the root scope of a document", func() {
+			result, err := spiff.Cascade(parseYAML("foo:\n  bar: (( 1 + 2 ))\n"))
+			Expect(err).NotTo(HaveOccurred())
+			value, err := spiff.Evaluate(result, "(( foo.bar * 2 ))")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(value.Value()).To(Equal(int64(6)))
+		})
+
+		It("reports evaluation issues", func() {
+			_, err := spiff.Evaluate(parseYAML("foo: bar"), "unknown")
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(ContainSubstring("'unknown' not found"))
+		})
+	})
+
+	Context("diffing", func() {
+		It("reports differences", func() {
+			diffs := spiff.Diff(parseYAML("foo: 1"), parseYAML("foo: 2"))
+			Expect(diffs).To(HaveLen(1))
+			Expect(diffs[0].Path).To(Equal([]string{"foo"}))
+		})
+
+		It("uses the configured compare options", func() {
+			numeric := spiff.WithCompareOptions(compare.Options{Mode: compare.NumericValues})
+			Expect(spiff.Diff(parseYAML("foo: 1"), parseYAML("foo: \"1.0\""))).To(HaveLen(1))
+			Expect(numeric.Diff(parseYAML("foo: 1"), parseYAML("foo: \"1.0\""))).To(BeEmpty())
+		})
+	})
+})
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/cloudfoundry-incubator/spiff/compare"
+	"github.com/cloudfoundry-incubator/spiff/spiffing"
+	"github.com/cloudfoundry-incubator/spiff/yaml"
+)
+
+// names of the files of a test case directory
+const (
+	testTemplate      = "template.yml"
+	testExpected      = "expected.yml"
+	testExpectedError = "expected-error"
+	testStubPrefix    = "stub"
+)
+
+// extensions of the stub files of a test case directory
+var testStubExtensions = []string{".yml", ".json"}
+
+// testCase describes a directory containing a template, its stubs
+// and the expected result or error.
+type testCase struct {
+	dir   string
+	stubs []string
+}
+
+func (t testCase) file(name string) string {
+	return filepath.Join(t.dir, name)
+}
+
+// findTestCases provides the test case directories found in the
+// given directory trees, ordered by their path.
+func findTestCases(dirs []string) ([]testCase, error) {
+	cases := []testCase{}
+	for _, dir := range dirs {
+		err := filepath.Walk(dir, func(filePath string, info os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			if !info.IsDir() {
+				return nil
+			}
+			if _, err := os.Stat(filepath.Join(filePath, testTemplate)); err != nil {
+				return nil
+			}
+			stubs, err := findStubs(filePath)
+			if err != nil {
+				return err
+			}
+			cases = append(cases, testCase{dir: filePath, stubs: stubs})
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return cases, nil
+}
+
+// findStubs provides the stub files of a test case directory,
+// ordered by their name.
+func findStubs(dir string) ([]string, error) {
+	stubs := []string{}
+	for _, ext := range testStubExtensions {
+		files, err := filepath.Glob(filepath.Join(dir, testStubPrefix+"*"+ext))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if info, err := os.Stat(file); err == nil && !info.IsDir() {
+				stubs = append(stubs, file)
+			}
+		}
+	}
+	sort.Strings(stubs)
+	return stubs, nil
+}
+
+// runTests runs all test cases found in the given directories and
+// reports mismatches between the merge results and the expected
+// documents. The order of list entries is always relevant. With
+// update the expected files are regenerated from the current
+// results instead. It returns whether all test cases passed.
+func runTests(spiff *spiffing.Spiff, dirs []string, update bool, opts compare.Options) bool {
+	cases, err := findTestCases(dirs)
+	if err != nil {
+		log.Fatalln("error searching test cases:", err)
+	}
+	if len(cases) == 0 {
+		log.Fatalln("no test cases found")
+	}
+
+	opts.Mode |= compare.OrderedEntries
+	failed := 0
+	for _, t := range cases {
+		results, err := runTestCase(spiff, t)
+		if update {
+			if err := updateTestCase(spiff, t, results, err); err != nil {
+				log.Fatalln(fmt.Sprintf("error updating test case [%s]:", t.dir), err)
+			}
+			fmt.Println("UPDATED", t.dir)
+			continue
+		}
+		problems := checkTestCase(spiff, t, results, err, opts)
+		if len(problems) == 0 {
+			fmt.Println("PASS", t.dir)
+			continue
+		}
+		failed++
+		fmt.Println("FAIL", t.dir)
+		for _, p := range problems {
+			fmt.Println(" ", strings.Replace(p, "\n", "\n  ", -1))
+		}
+	}
+
+	if !update {
+		fmt.Printf("%d passed, %d failed\n", len(cases)-failed, failed)
+	}
+	return failed == 0
+}
+
+// runTestCase merges the template of a test case with its stubs.
+func runTestCase(spiff *spiffing.Spiff, t testCase) ([]yaml.Node, error) {
+	templates, stubs, f := loadDocuments(spiff, t.file(testTemplate), t.stubs)
+	if f != nil {
+		return nil, f
+	}
+	return spiff.Merge(templates, stubs...)
+}
+
+// checkTestCase compares the outcome of a test case with the
+// expected result or error and describes the mismatches.
+func checkTestCase(spiff *spiffing.Spiff, t testCase, results []yaml.Node, err error, opts compare.Options) []string {
+	expectedError, rerr := ioutil.ReadFile(t.file(testExpectedError))
+	if rerr == nil {
+		message := strings.TrimSpace(string(expectedError))
+		if err == nil {
+			return []string{fmt.Sprintf("expected error %q, but merge succeeded", message)}
+		}
+		if !strings.Contains(err.Error(), message) {
+			return []string{fmt.Sprintf("expected error %q, but got: %s", message, err)}
+		}
+		return nil
+	}
+	if err != nil {
+		return []string{fmt.Sprintf("unexpected error: %s", err)}
+	}
+
+	data, rerr := ioutil.ReadFile(t.file(testExpected))
+	if rerr != nil {
+		return []string{fmt.Sprintf("error reading expected result: %s", rerr)}
+	}
+	expected, rerr := spiff.Unmarshal(t.file(testExpected), data)
+	if rerr != nil {
+		return []string{fmt.Sprintf("error parsing expected result: %s", rerr)}
+	}
+	if len(expected) != len(results) {
+		return []string{fmt.Sprintf("expected %d documents, but got %d", len(expected), len(results))}
+	}
+
+	problems := []string{}
+	for i := range results {
+		doc := ""
+		if len(results) > 1 {
+			doc = fmt.Sprintf(" (document %d)", i+1)
+		}
+		for _, diff := range compare.CompareWithOptions(expected[i], results[i], opts) {
+			problems = append(problems, fmt.Sprintf("difference in %s%s:\n  expected: %s\n  actual:   %s",
+				strings.Join(diff.Path, "."), doc, testValue(spiff, diff.A), testValue(spiff, diff.B)))
+		}
+	}
+	return problems
+}
+
+// updateTestCase replaces the expected files of a test case by
+// the current outcome. Multiple documents are each introduced
+// by a document separator.
+func updateTestCase(spiff *spiffing.Spiff, t testCase, results []yaml.Node, err error) error {
+	if err != nil {
+		os.Remove(t.file(testExpected))
+		return ioutil.WriteFile(t.file(testExpectedError), []byte(err.Error()+"\n"), 0644)
+	}
+
+	output := []byte{}
+	for _, node := range results {
+		data, err := spiff.Marshal(node, "yaml")
+		if err != nil {
+			return err
+		}
+		if len(results) > 1 {
+			output = append(output, "---\n"...)
+		}
+		output = append(output, data...)
+		if !bytes.HasSuffix(output, []byte("\n")) {
+			output = append(output, '\n')
+		}
+	}
+	os.Remove(t.file(testExpectedError))
+	return ioutil.WriteFile(t.file(testExpected), output, 0644)
+}
+
+// testValue formats a (possibly missing) value as single line.
+func testValue(spiff *spiffing.Spiff, node yaml.Node) string {
+	if node == nil {
+		return "<missing>"
+	}
+	data, err := spiff.Marshal(node, "json")
+	if err != nil {
+		return fmt.Sprintf("%v", node.Value())
+	}
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, data); err != nil {
+		return string(data)
+	}
+	return buf.String()
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"path"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/cloudfoundry-incubator/spiff/spiffing"
+)
+
+// watchInterval is the interval used to poll the watched files.
+const watchInterval = 500 * time.Millisecond
+
+// stamp describes the state of a file used to detect changes.
+type stamp struct {
+	exists  bool
+	size    int64
+	modTime time.Time
+}
+
+func fileStamp(filePath string) stamp {
+	info, err := os.Stat(filePath)
+	if err != nil {
+		return stamp{}
+	}
+	return stamp{exists: true, size: info.Size(), modTime: info.ModTime()}
+}
+
+// watchedFiles is a file system recording all files read by the
+// processing. The content of files is kept across processing runs
+// until a change of the file is detected.
+type watchedFiles struct {
+	lock   sync.Mutex
+	stamps map[string]stamp
+	cache  map[string][]byte
+}
+
+func newWatchedFiles(filePaths ...string) *watchedFiles {
+	w := &watchedFiles{
+		stamps: map[string]stamp{},
+		cache:  map[string][]byte{},
+	}
+	for _, filePath := range filePaths {
+		w.watch(filePath)
+	}
+	return w
+}
+
+// watch adds a file to the set of watched files.
+func (w *watchedFiles) watch(filePath string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	if _, ok := w.stamps[filePath]; !ok {
+		w.stamps[filePath] = fileStamp(filePath)
+	}
+}
+
+// ReadFile reads a file and adds it to the watched files. Files
+// that cannot be read are watched, too, to notice their creation.
+func (w *watchedFiles) ReadFile(filePath string) ([]byte, error) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	if data, ok := w.cache[filePath]; ok {
+		return data, nil
+	}
+	s := fileStamp(filePath)
+	data, err := ioutil.ReadFile(filePath)
+	w.stamps[filePath] = s
+	if err != nil {
+		return nil, err
+	}
+	w.cache[filePath] = data
+	return data, nil
+}
+
+// changed provides the sorted list of watched files changed since
+// the last call and drops their cached content.
+func (w *watchedFiles) changed() []string {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+
+	changed := []string{}
+	for filePath, old := range w.stamps {
+		s := fileStamp(filePath)
+		if s != old {
+			w.stamps[filePath] = s
+			delete(w.cache, filePath)
+			changed = append(changed, filePath)
+		}
+	}
+	sort.Strings(changed)
+	return changed
+}
+
+// watchMerge renders the merge result to the output file and
+// renders it again whenever the template, a stub or a file read
+// by the processing changes. Errors are reported without
+// terminating the watch.
+func watchMerge(spiff *spiffing.Spiff, templateFilePath string, format string, stubFilePaths []string, outFilePath string) {
+	if outFilePath == "" {
+		log.Fatalln("watch mode requires an output file")
+	}
+	for _, filePath := range append([]string{templateFilePath}, stubFilePaths...) {
+		if filePath == "-" {
+			log.Fatalln("stdin cannot be used in watch mode")
+		}
+	}
+
+	files := newWatchedFiles(append([]string{templateFilePath}, stubFilePaths...)...)
+	spiff = spiff.WithFileSystem(files)
+
+	for {
+		output, f := render(spiff, templateFilePath, format, stubFilePaths)
+		if f != nil {
+			fmt.Fprintln(os.Stderr, f.Error())
+		} else if err := ioutil.WriteFile(outFilePath, output, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "error writing output [%s]: %s\n", path.Clean(outFilePath), err)
+		} else {
+			fmt.Fprintf(os.Stderr, "%s: generated %s\n", time.Now().Format("15:04:05"), path.Clean(outFilePath))
+		}
+
+		for {
+			time.Sleep(watchInterval)
+			if changed := files.changed(); len(changed) > 0 {
+				fmt.Fprintf(os.Stderr, "changed: %v\n", changed)
+				break
+			}
+		}
+	}
+}
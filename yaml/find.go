@@ -4,8 +4,6 @@ import (
 	"regexp"
 	"strconv"
 	"strings"
-
-	"github.com/cloudfoundry-incubator/spiff/debug"
 )
 
 var listIndex = regexp.MustCompile(`^\[(\d+)\]$`)
@@ -40,7 +38,6 @@ func FindString(root Node, path ...string) (string, bool) {
 func FindStringR(raw bool, root Node, path ...string) (string, bool) {
 	node, ok := FindR(raw, root, path...)
 	if !ok {
-		debug.Debug("%v not found", path)
 		return "", false
 	}
 
@@ -112,7 +109,7 @@ func stepThroughList(raw bool, here []Node, step string, key string) (Node, bool
 			continue
 		}
 
-		name, ok := FindStringR(raw, sub, key)
+		name, ok := ListEntryKey(raw, sub, key)
 		if !ok {
 			continue
 		}
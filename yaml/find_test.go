@@ -33,7 +33,7 @@ foo:
 			It("accepts string keys to index maps", func() {
 				val, found := Find(tree, "foo", "bar", "baz")
 				Expect(found).To(BeTrue())
-				Expect(val).To(Equal(node("found")))
+				Expect(val).To(Equal(nodeAt("found", 5, 10)))
 			})
 		})
 
@@ -49,7 +49,7 @@ foo:
 			It("accepts [x] to index lists", func() {
 				val, found := Find(tree, "foo", "bar", "[1]", "fizz")
 				Expect(found).To(BeTrue())
-				Expect(val).To(Equal(node("right")))
+				Expect(val).To(Equal(nodeAt("right", 6, 13)))
 			})
 		})
 
@@ -24,3 +24,7 @@ func parseYAML(source string) Node {
 func node(val interface{}) Node {
 	return NewNode(val, "test")
 }
+
+func nodeAt(val interface{}, line, column int) Node {
+	return NewPositionedNode(val, "test", Position{line, column})
+}
@@ -0,0 +1,116 @@
+package yaml
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/cloudfoundry-incubator/candiedyaml"
+)
+
+func ParseJSON(sourceName string, source []byte) (Node, error) {
+	docs, err := ParseJSONMulti(sourceName, source)
+	if err != nil {
+		return nil, err
+	}
+	if len(docs) != 1 {
+		return nil, MultiDocumentError{len(docs)}
+	}
+	return docs[0], nil
+}
+
+// ParseJSONMulti parses a stream of concatenated JSON values.
+func ParseJSONMulti(sourceName string, source []byte) ([]Node, error) {
+	result := []Node{}
+	decoder := json.NewDecoder(bytes.NewReader(source))
+	decoder.UseNumber()
+
+	for {
+		var parsed interface{}
+
+		err := decoder.Decode(&parsed)
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		node, err := sanitize(sourceName, nil, parsed)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, node)
+	}
+	if len(result) == 0 {
+		result = append(result, NewNode(nil, sourceName))
+	}
+	return result, nil
+}
+
+func ToJSON(node Node) ([]byte, error) {
+	value, err := Normalize(node)
+	if err != nil {
+		return nil, err
+	}
+
+	buffer := &bytes.Buffer{}
+	encoder := json.NewEncoder(buffer)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(value)
+	if err != nil {
+		return nil, err
+	}
+	return bytes.TrimSuffix(buffer.Bytes(), []byte("\n")), nil
+}
+
+// Normalize converts a node tree into plain go values
+// (maps, lists and simple values).
+func Normalize(node Node) (interface{}, error) {
+	if node == nil {
+		return nil, nil
+	}
+	return normalize(node.Value())
+}
+
+func normalize(value interface{}) (interface{}, error) {
+	switch v := value.(type) {
+	case map[string]Node:
+		result := map[string]interface{}{}
+		for key, val := range v {
+			n, err := Normalize(val)
+			if err != nil {
+				return nil, err
+			}
+			result[key] = n
+		}
+		return result, nil
+
+	case []Node:
+		result := []interface{}{}
+		for _, val := range v {
+			n, err := Normalize(val)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, n)
+		}
+		return result, nil
+
+	case []byte:
+		return string(v), nil
+
+	case string, int64, float64, bool, nil:
+		return v, nil
+
+	case candiedyaml.Marshaler:
+		_, m, err := v.MarshalYAML()
+		if err != nil {
+			return nil, err
+		}
+		return normalize(m)
+	}
+
+	return nil, fmt.Errorf("value type (%T) not supported: %#v", value, value)
+}
@@ -0,0 +1,63 @@
+package yaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("JSON", func() {
+	Describe("parsing", func() {
+		It("parses maps, lists and simple values", func() {
+			parsed, err := ParseJSON("test", []byte(`{"a": 1, "b": [1.5, true, null], "c": "x"}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal(node(map[string]Node{
+				"a": node(int64(1)),
+				"b": node([]Node{node(1.5), node(true), node(nil)}),
+				"c": node("x"),
+			})))
+		})
+
+		It("parses a stream of concatenated values", func() {
+			parsed, err := ParseJSONMulti("test", []byte(`{"a": 1} {"b": 2}`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed).To(Equal([]Node{
+				node(map[string]Node{"a": node(int64(1))}),
+				node(map[string]Node{"b": node(int64(2))}),
+			}))
+		})
+
+		It("fails for more than one value", func() {
+			_, err := ParseJSON("test", []byte(`1 2`))
+			Expect(err).To(Equal(MultiDocumentError{2}))
+		})
+
+		It("fails for malformed input", func() {
+			_, err := ParseJSON("test", []byte(`{"a": `))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("generating", func() {
+		It("renders nodes as indented json", func() {
+			data, err := ToJSON(parseYAML(`
+b: [ 1, 2.5 ]
+a: "<x>"
+c: ~
+`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(data)).To(Equal(`{
+  "a": "<x>",
+  "b": [
+    1,
+    2.5
+  ],
+  "c": null
+}`))
+		})
+
+		It("fails for unsupported values", func() {
+			_, err := ToJSON(node(struct{}{}))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+})
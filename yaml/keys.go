@@ -0,0 +1,144 @@
+package yaml
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ListKeysField is the top level field of a stub declaring the
+// key fields identifying the entries of lists.
+const ListKeysField = "__keys"
+
+// ListKey declares the key field(s) for the lists matching a path.
+// The steps of the path match map keys and names of list entries,
+// the step * matches any step.
+type ListKey struct {
+	Path []string
+	Key  string
+}
+
+// ListKeys is a set of key declarations.
+type ListKeys []ListKey
+
+// ParseListKeys parses a map of dot separated path patterns to key
+// names. Composite keys are given as comma separated field names or
+// as list of field names. Invalid declarations are skipped and
+// reported by the returned error.
+func ParseListKeys(node Node) (ListKeys, error) {
+	if node == nil {
+		return nil, nil
+	}
+	m, ok := node.Value().(map[string]Node)
+	if !ok {
+		return nil, fmt.Errorf("list key declaration must be a map")
+	}
+
+	keys := ListKeys{}
+	invalid := []string{}
+	for path, value := range m {
+		key := ""
+		switch v := value.Value().(type) {
+		case string:
+			key = v
+		case []Node:
+			fields := []string{}
+			for _, f := range v {
+				if s, ok := f.Value().(string); ok {
+					fields = append(fields, s)
+				} else {
+					fields = nil
+					break
+				}
+			}
+			key = strings.Join(fields, ",")
+		}
+		if key == "" {
+			invalid = append(invalid, path)
+			continue
+		}
+		keys = append(keys, ListKey{strings.Split(path, "."), key})
+	}
+	sort.Sort(keys)
+
+	if len(invalid) > 0 {
+		sort.Strings(invalid)
+		return keys, fmt.Errorf("invalid list key declaration for %s", strings.Join(invalid, ", "))
+	}
+	return keys, nil
+}
+
+// Lookup provides the key declared for the list with the given path,
+// or an empty string. Declarations with fewer wildcards take
+// precedence.
+func (keys ListKeys) Lookup(path []string) string {
+	for _, k := range keys {
+		if k.matches(path) {
+			return k.Key
+		}
+	}
+	return ""
+}
+
+func (k ListKey) matches(path []string) bool {
+	if len(k.Path) != len(path) {
+		return false
+	}
+	for i, p := range k.Path {
+		if p != "*" && p != path[i] && p != PathComponent(path[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+func (k ListKey) wildcards() int {
+	count := 0
+	for _, p := range k.Path {
+		if p == "*" {
+			count++
+		}
+	}
+	return count
+}
+
+func (keys ListKeys) Len() int      { return len(keys) }
+func (keys ListKeys) Swap(i, j int) { keys[i], keys[j] = keys[j], keys[i] }
+func (keys ListKeys) Less(i, j int) bool {
+	if wi, wj := keys[i].wildcards(), keys[j].wildcards(); wi != wj {
+		return wi < wj
+	}
+	return strings.Join(keys[i].Path, ".") < strings.Join(keys[j].Path, ".")
+}
+
+// KeyFields provides the field names of a (composite) key name.
+func KeyFields(keyName string) []string {
+	if keyName == "" {
+		return []string{"name"}
+	}
+	return strings.Split(keyName, ",")
+}
+
+// IsKeyField checks whether a field is part of a (composite) key.
+func IsKeyField(field string, keyName string) bool {
+	for _, f := range KeyFields(keyName) {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
+
+// ListEntryKey provides the value of the (composite) key of a list
+// entry. The values of composite keys are separated by commas.
+func ListEntryKey(raw bool, entry Node, keyName string) (string, bool) {
+	values := []string{}
+	for _, field := range KeyFields(keyName) {
+		value, ok := FindStringR(raw, entry, field)
+		if !ok {
+			return "", false
+		}
+		values = append(values, value)
+	}
+	return strings.Join(values, ","), true
+}
@@ -0,0 +1,76 @@
+package yaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("list keys", func() {
+	Describe("ParseListKeys", func() {
+		It("accepts field names and lists of field names", func() {
+			keys, err := ParseListKeys(parseYAML(`
+---
+jobs: name
+groups.*.instances: [ name, az ]
+`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(keys).To(Equal(ListKeys{
+				{Path: []string{"jobs"}, Key: "name"},
+				{Path: []string{"groups", "*", "instances"}, Key: "name,az"},
+			}))
+		})
+
+		It("reports invalid declarations", func() {
+			keys, err := ParseListKeys(parseYAML(`
+---
+jobs: name
+networks: 1
+`))
+			Expect(err).To(MatchError("invalid list key declaration for networks"))
+			Expect(keys).To(HaveLen(1))
+		})
+	})
+
+	Describe("Lookup", func() {
+		keys, _ := ParseListKeys(parseYAML(`
+---
+groups.*.instances: name,az
+groups.main.instances: id
+`))
+
+		It("prefers declarations without wildcards", func() {
+			Expect(keys.Lookup([]string{"groups", "name:main", "instances"})).To(Equal("id"))
+			Expect(keys.Lookup([]string{"groups", "name:other", "instances"})).To(Equal("name,az"))
+		})
+
+		It("provides no key for undeclared paths", func() {
+			Expect(keys.Lookup([]string{"groups"})).To(Equal(""))
+		})
+	})
+
+	Describe("composite keys", func() {
+		tree := parseYAML(`
+---
+instances:
+- name: web
+  az: z1
+  size: 1
+- name: web
+  az: z2
+  size: 2
+`)
+
+		It("finds list entries by all key fields", func() {
+			size, found := FindInt(tree, "instances", "name,az:web,z2", "size")
+			Expect(found).To(BeTrue())
+			Expect(size).To(Equal(int64(2)))
+		})
+
+		It("provides the composite key of an entry", func() {
+			entry, _ := Find(tree, "instances", "[0]")
+			key, ok := ListEntryKey(false, entry, "name,az")
+			Expect(ok).To(BeTrue())
+			Expect(key).To(Equal("web,z1"))
+		})
+	})
+})
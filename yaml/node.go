@@ -13,10 +13,12 @@ type Node interface {
 
 	Value() interface{}
 	SourceName() string
+	Position() Position
 	RedirectPath() []string
 	Flags() NodeFlags
 	Temporary() bool
 	Local() bool
+	Deleted() bool
 	ReplaceFlag() bool
 	Preferred() bool
 	Merged() bool
@@ -31,9 +33,16 @@ type Node interface {
 	EquivalentToNode(Node) bool
 }
 
+// EquivalentValue is implemented by values embedding nodes
+// to compare them independently of their source positions.
+type EquivalentValue interface {
+	EquivalentToValue(interface{}) bool
+}
+
 type AnnotatedNode struct {
 	value      interface{}
 	sourceName string
+	position   Position
 	Annotation
 }
 
@@ -49,6 +58,7 @@ func NewIssue(msg string, args ...interface{}) Issue {
 const (
 	FLAG_TEMPORARY = 0x001
 	FLAG_LOCAL     = 0x002
+	FLAG_DELETE    = 0x004
 )
 
 type NodeFlags int
@@ -72,6 +82,13 @@ func (f *NodeFlags) SetLocal() *NodeFlags {
 	*f |= FLAG_LOCAL
 	return f
 }
+func (f NodeFlags) Deleted() bool {
+	return (f & FLAG_DELETE) != 0
+}
+func (f *NodeFlags) SetDeleted() *NodeFlags {
+	*f |= FLAG_DELETE
+	return f
+}
 
 type Annotation struct {
 	redirectPath []string
@@ -87,58 +104,62 @@ type Annotation struct {
 }
 
 func NewNode(value interface{}, sourcePath string) Node {
-	return AnnotatedNode{MassageType(value), sourcePath, EmptyAnnotation()}
+	return AnnotatedNode{MassageType(value), sourcePath, Position{}, EmptyAnnotation()}
+}
+
+func NewPositionedNode(value interface{}, sourcePath string, pos Position) Node {
+	return AnnotatedNode{MassageType(value), sourcePath, pos, EmptyAnnotation()}
 }
 
 func ReplaceValue(value interface{}, node Node) Node {
-	return AnnotatedNode{value, node.SourceName(), node.GetAnnotation()}
+	return AnnotatedNode{value, node.SourceName(), node.Position(), node.GetAnnotation()}
 }
 func ReferencedNode(node Node) Node {
-	return AnnotatedNode{node.Value(), node.SourceName(), NewReferencedAnnotation(node)}
+	return AnnotatedNode{node.Value(), node.SourceName(), node.Position(), NewReferencedAnnotation(node)}
 }
 
 func SubstituteNode(value interface{}, node Node) Node {
-	return AnnotatedNode{MassageType(value), node.SourceName(), node.GetAnnotation()}
+	return AnnotatedNode{MassageType(value), node.SourceName(), node.Position(), node.GetAnnotation()}
 }
 
 func RedirectNode(value interface{}, node Node, redirect []string) Node {
-	return AnnotatedNode{MassageType(value), node.SourceName(), node.GetAnnotation().SetRedirectPath(redirect)}
+	return AnnotatedNode{MassageType(value), node.SourceName(), node.Position(), node.GetAnnotation().SetRedirectPath(redirect)}
 }
 
 func ReplaceNode(value interface{}, node Node, redirect []string) Node {
-	return AnnotatedNode{MassageType(value), node.SourceName(), node.GetAnnotation().SetReplaceFlag().SetRedirectPath(redirect)}
+	return AnnotatedNode{MassageType(value), node.SourceName(), node.Position(), node.GetAnnotation().SetReplaceFlag().SetRedirectPath(redirect)}
 }
 
 func PreferredNode(node Node) Node {
-	return AnnotatedNode{node.Value(), node.SourceName(), node.GetAnnotation().SetPreferred()}
+	return AnnotatedNode{node.Value(), node.SourceName(), node.Position(), node.GetAnnotation().SetPreferred()}
 }
 
 func MergedNode(node Node) Node {
-	return AnnotatedNode{node.Value(), node.SourceName(), node.GetAnnotation().SetMerged()}
+	return AnnotatedNode{node.Value(), node.SourceName(), node.Position(), node.GetAnnotation().SetMerged()}
 }
 
 func KeyNameNode(node Node, keyName string) Node {
-	return AnnotatedNode{node.Value(), node.SourceName(), node.GetAnnotation().AddKeyName(keyName)}
+	return AnnotatedNode{node.Value(), node.SourceName(), node.Position(), node.GetAnnotation().AddKeyName(keyName)}
 }
 
 func IssueNode(node Node, error bool, failed bool, issue Issue) Node {
-	return AnnotatedNode{node.Value(), node.SourceName(), node.GetAnnotation().AddIssue(error, failed, issue)}
+	return AnnotatedNode{node.Value(), node.SourceName(), node.Position(), node.GetAnnotation().AddIssue(error, failed, issue)}
 }
 
 func UndefinedNode(node Node) Node {
-	return AnnotatedNode{node.Value(), node.SourceName(), node.GetAnnotation().SetUndefined()}
+	return AnnotatedNode{node.Value(), node.SourceName(), node.Position(), node.GetAnnotation().SetUndefined()}
 }
 
 func AddFlags(node Node, flags NodeFlags) Node {
-	return AnnotatedNode{node.Value(), node.SourceName(), node.GetAnnotation().AddFlags(flags)}
+	return AnnotatedNode{node.Value(), node.SourceName(), node.Position(), node.GetAnnotation().AddFlags(flags)}
 }
 
 func TemporaryNode(node Node) Node {
-	return AnnotatedNode{node.Value(), node.SourceName(), node.GetAnnotation().SetTemporary()}
+	return AnnotatedNode{node.Value(), node.SourceName(), node.Position(), node.GetAnnotation().SetTemporary()}
 }
 
 func LocalNode(node Node) Node {
-	return AnnotatedNode{node.Value(), node.SourceName(), node.GetAnnotation().SetLocal()}
+	return AnnotatedNode{node.Value(), node.SourceName(), node.Position(), node.GetAnnotation().SetLocal()}
 }
 
 func MassageType(value interface{}) interface{} {
@@ -265,6 +286,10 @@ func (n AnnotatedNode) SourceName() string {
 	return n.sourceName
 }
 
+func (n AnnotatedNode) Position() Position {
+	return n.position
+}
+
 func (n AnnotatedNode) GetAnnotation() Annotation {
 	return n.Annotation
 }
@@ -331,6 +356,10 @@ func (n AnnotatedNode) EquivalentToNode(o Node) bool {
 		return true
 	}
 
+	if e, ok := n.Value().(EquivalentValue); ok {
+		return e.EquivalentToValue(o.Value())
+	}
+
 	b := reflect.DeepEqual(n.Value(), o.Value())
 
 	return b
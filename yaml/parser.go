@@ -1,9 +1,12 @@
 package yaml
 
 import (
+	"bytes"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"reflect"
+	"strings"
 
 	"github.com/cloudfoundry-incubator/candiedyaml"
 )
@@ -16,18 +19,123 @@ func (e NonStringKeyError) Error() string {
 	return fmt.Sprintf("map key must be a string: %#v", e.Key)
 }
 
-func Parse(sourceName string, source []byte) (Node, error) {
-	var parsed interface{}
+type MultiDocumentError struct {
+	Count int
+}
+
+func (e MultiDocumentError) Error() string {
+	return fmt.Sprintf("single document expected, but found %d documents", e.Count)
+}
 
-	err := candiedyaml.Unmarshal(source, &parsed)
+// Parse parses a yaml source containing a single document. Streams
+// with multiple documents are rejected with a MultiDocumentError.
+func Parse(sourceName string, source []byte) (Node, error) {
+	docs, err := ParseMulti(sourceName, source)
 	if err != nil {
 		return nil, err
 	}
+	if len(docs) != 1 {
+		return nil, MultiDocumentError{len(docs)}
+	}
+	return docs[0], nil
+}
+
+// ParseMulti parses all documents of a yaml stream.
+func ParseMulti(sourceName string, source []byte) ([]Node, error) {
+	chunks := splitDocuments(source)
+	if len(chunks) == 0 {
+		// an empty stream is handled as empty document
+		chunks = append(chunks, nil)
+	}
 
-	return sanitize(sourceName, parsed)
+	result := []Node{}
+	for i, chunk := range chunks {
+		var parsed interface{}
+
+		err := candiedyaml.Unmarshal(chunk, &parsed)
+		if err != nil {
+			if len(chunks) > 1 {
+				return nil, fmt.Errorf("document %d: %s", i+1, err)
+			}
+			return nil, err
+		}
+
+		// positions are only used if the scanned document fits
+		var pos *positions
+		if docs := scanPositions(chunk); len(docs) == 1 && docs[0].matches(parsed) {
+			pos = docs[0]
+		}
+		node, err := sanitize(sourceName, pos, parsed)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, node)
+	}
+	return result, nil
+}
+
+/*
+ * Document markers (--- and ...) at the beginning of a line always
+ * separate the documents of a yaml stream, they cannot be part of
+ * a scalar or collection. Therefore the stream is split at these
+ * lines and every document is parsed separately. The chunks keep
+ * the line numbers of the stream by preceding empty lines.
+ */
+
+type documentChunk struct {
+	start    int
+	explicit bool
+	content  bool
 }
 
-func sanitize(sourceName string, root interface{}) (Node, error) {
+// splitDocuments provides the sources of the documents of a yaml stream.
+func splitDocuments(source []byte) [][]byte {
+	lines := bytes.SplitAfter(source, []byte("\n"))
+	chunks := [][]byte{}
+
+	cur := documentChunk{}
+	emit := func(end int) {
+		if cur.explicit || cur.content {
+			chunk := bytes.Repeat([]byte("\n"), cur.start)
+			chunks = append(chunks, append(chunk, bytes.Join(lines[cur.start:end], nil)...))
+		}
+		cur = documentChunk{start: end}
+	}
+
+	for i, line := range lines {
+		text := strings.TrimRight(string(line), "\r\n")
+		switch {
+		case documentMarker(text, "---"):
+			if cur.explicit || cur.content {
+				emit(i)
+			}
+			cur.explicit = true
+			if rest := strings.TrimLeft(text[3:], " \t"); rest != "" && rest[0] != '#' {
+				cur.content = true
+			}
+		case documentMarker(text, "..."):
+			emit(i + 1)
+		default:
+			content := strings.TrimLeft(text, " \t")
+			if content == "" || content[0] == '#' {
+				continue
+			}
+			if content[0] == '%' && len(content) == len(text) && !cur.explicit && !cur.content {
+				// directive for the next document
+				continue
+			}
+			cur.content = true
+		}
+	}
+	emit(len(lines))
+	return chunks
+}
+
+func documentMarker(text string, marker string) bool {
+	return text == marker || strings.HasPrefix(text, marker+" ") || strings.HasPrefix(text, marker+"\t")
+}
+
+func sanitize(sourceName string, pos *positions, root interface{}) (Node, error) {
 	switch rootVal := root.(type) {
 	case map[interface{}]interface{}:
 		sanitized := map[string]Node{}
@@ -38,7 +146,7 @@ func sanitize(sourceName string, root interface{}) (Node, error) {
 				return nil, NonStringKeyError{key}
 			}
 
-			sub, err := sanitize(sourceName, val)
+			sub, err := sanitize(sourceName, pos.sub(str), val)
 			if err != nil {
 				return nil, err
 			}
@@ -46,13 +154,27 @@ func sanitize(sourceName string, root interface{}) (Node, error) {
 			sanitized[str] = sub
 		}
 
-		return NewNode(sanitized, sourceName), nil
+		return NewPositionedNode(sanitized, sourceName, pos.position()), nil
+
+	case map[string]interface{}:
+		sanitized := map[string]Node{}
+
+		for key, val := range rootVal {
+			sub, err := sanitize(sourceName, pos.sub(key), val)
+			if err != nil {
+				return nil, err
+			}
+
+			sanitized[key] = sub
+		}
+
+		return NewPositionedNode(sanitized, sourceName, pos.position()), nil
 
 	case []interface{}:
 		sanitized := []Node{}
 
-		for _, val := range rootVal {
-			sub, err := sanitize(sourceName, val)
+		for i, val := range rootVal {
+			sub, err := sanitize(sourceName, pos.sub(fmt.Sprintf("[%d]", i)), val)
 			if err != nil {
 				return nil, err
 			}
@@ -60,10 +182,20 @@ func sanitize(sourceName string, root interface{}) (Node, error) {
 			sanitized = append(sanitized, sub)
 		}
 
-		return NewNode(sanitized, sourceName), nil
+		return NewPositionedNode(sanitized, sourceName, pos.position()), nil
+
+	case json.Number:
+		if i, err := rootVal.Int64(); err == nil {
+			return NewPositionedNode(i, sourceName, pos.position()), nil
+		}
+		f, err := rootVal.Float64()
+		if err != nil {
+			return nil, err
+		}
+		return NewPositionedNode(f, sourceName, pos.position()), nil
 
 	case string, []byte, int64, float64, bool, nil:
-		return NewNode(rootVal, sourceName), nil
+		return NewPositionedNode(rootVal, sourceName, pos.position()), nil
 	}
 
 	return nil, errors.New(fmt.Sprintf("unknown type (%s) during sanitization: %#v\n", reflect.TypeOf(root).String(), root))
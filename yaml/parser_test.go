@@ -11,12 +11,12 @@ var _ = Describe("YAML Parser", func() {
 		It("parses maps as strings mapping to Nodes", func() {
 			parsed, err := Parse("test", []byte(`foo: "fizz \"buzz\""`))
 			Expect(err).NotTo(HaveOccurred())
-			Expect(parsed).To(Equal(node(map[string]Node{"foo": node(`fizz "buzz"`)})))
+			Expect(parsed).To(Equal(nodeAt(map[string]Node{"foo": nodeAt(`fizz "buzz"`, 1, 6)}, 1, 1)))
 		})
 
 		It("parses maps with block string values", func() {
-			parsesAs("foo: |\n  sup\n  :3", map[string]Node{"foo": node("sup\n:3")})
-			parsesAs("foo: >\n  sup\n  :3", map[string]Node{"foo": node("sup :3")})
+			parsesAs("foo: |\n  sup\n  :3", map[string]Node{"foo": nodeAt("sup\n:3", 1, 6)})
+			parsesAs("foo: >\n  sup\n  :3", map[string]Node{"foo": nodeAt("sup :3", 1, 6)})
 		})
 
 		Context("keys are not strings", func() {
@@ -29,7 +29,7 @@ var _ = Describe("YAML Parser", func() {
 
 	Context("value is a list", func() {
 		It("parses with Node contents", func() {
-			parsesAs("- 1\n- two", []Node{node(1), node("two")})
+			parsesAs("- 1\n- two", []Node{nodeAt(1, 1, 3), nodeAt("two", 2, 3)})
 		})
 	})
 
@@ -54,6 +54,170 @@ var _ = Describe("YAML Parser", func() {
 		})
 	})
 
+	Context("source positions", func() {
+		It("records line and column of nested nodes", func() {
+			parsed, err := Parse("test", []byte(`
+---
+# comment
+foo:
+  bar: (( merge ))
+  list:
+  - name: alice
+    age: 25
+  -   plain
+  - - nested
+  text: |
+    line: 1
+    - line 2
+  flow: [ a,
+     b ]
+other: "quoted"
+`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(parsed.Position()).To(Equal(Position{4, 1}))
+
+			expectPosition(parsed, Position{5, 8}, "foo", "bar")
+			expectPosition(parsed, Position{6, 3}, "foo", "list")
+			expectPosition(parsed, Position{7, 5}, "foo", "list", "[0]")
+			expectPosition(parsed, Position{7, 11}, "foo", "list", "[0]", "name")
+			expectPosition(parsed, Position{8, 10}, "foo", "list", "[0]", "age")
+			expectPosition(parsed, Position{9, 7}, "foo", "list", "[1]")
+			expectPosition(parsed, Position{10, 7}, "foo", "list", "[2]", "[0]")
+			expectPosition(parsed, Position{11, 9}, "foo", "text")
+			expectPosition(parsed, Position{14, 9}, "foo", "flow")
+			expectPosition(parsed, Position{}, "foo", "flow", "[1]")
+			expectPosition(parsed, Position{16, 8}, "other")
+		})
+
+		It("handles lists indented like their parent key", func() {
+			parsed, err := Parse("test", []byte("foo:\n- a\n- b\nbar: c"))
+			Expect(err).NotTo(HaveOccurred())
+
+			expectPosition(parsed, Position{2, 3}, "foo", "[0]")
+			expectPosition(parsed, Position{3, 3}, "foo", "[1]")
+			expectPosition(parsed, Position{4, 6}, "bar")
+		})
+
+		It("handles block scalars, flow collections, quoted keys and aliases", func() {
+			parsed, err := Parse("test", []byte(`text: |
+  key: value
+  - entry
+folded: >
+  a: [ b
+flow: {
+  a: 1,
+  b: [ "]", 3 ] }
+"quoted: key": 1
+'single: key': 2
+base: &base
+  x: 1
+alias: *base
+plain: [ 5", b ]
+last: 3
+`))
+			Expect(err).NotTo(HaveOccurred())
+
+			expectPosition(parsed, Position{1, 7}, "text")
+			expectPosition(parsed, Position{4, 9}, "folded")
+			expectPosition(parsed, Position{6, 7}, "flow")
+			expectPosition(parsed, Position{}, "flow", "a")
+			expectPosition(parsed, Position{}, "flow", "b", "[1]")
+			expectPosition(parsed, Position{9, 16}, "quoted: key")
+			expectPosition(parsed, Position{10, 16}, "single: key")
+			expectPosition(parsed, Position{11, 1}, "base")
+			expectPosition(parsed, Position{12, 6}, "base", "x")
+			expectPosition(parsed, Position{13, 8}, "alias")
+			expectPosition(parsed, Position{}, "alias", "x")
+			expectPosition(parsed, Position{14, 8}, "plain")
+			expectPosition(parsed, Position{15, 7}, "last")
+		})
+
+		It("provides no positions if the scanned structure does not match", func() {
+			parsed, err := Parse("test", []byte("a: 1\n? b\n: 2\n"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(parsed.Position()).To(Equal(Position{}))
+			expectPosition(parsed, Position{}, "a")
+			expectPosition(parsed, Position{}, "b")
+		})
+	})
+
+	Context("multiple documents", func() {
+		source := []byte(`
+---
+foo: 1
+---
+- bar
+...
+---
+alice: (( bob ))
+`)
+
+		It("parses all documents of a stream", func() {
+			docs, err := ParseMulti("test", source)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(docs).To(Equal([]Node{
+				nodeAt(map[string]Node{"foo": nodeAt(1, 3, 6)}, 3, 1),
+				nodeAt([]Node{nodeAt("bar", 5, 3)}, 5, 1),
+				nodeAt(map[string]Node{"alice": nodeAt("(( bob ))", 8, 8)}, 8, 1),
+			}))
+		})
+
+		It("parses a single document as stream", func() {
+			docs, err := ParseMulti("test", []byte("---\nfoo: 1\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(docs).To(Equal([]Node{
+				nodeAt(map[string]Node{"foo": nodeAt(1, 2, 6)}, 2, 1),
+			}))
+		})
+
+		It("handles empty documents, comments and directives", func() {
+			docs, err := ParseMulti("test", []byte(`# leading comment
+%YAML 1.1
+---
+---
+# only a comment
+...
+%YAML 1.1
+--- |
+  text
+... # end
+# trailing comment
+`))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(docs).To(HaveLen(3))
+			Expect(docs[0].Value()).To(BeNil())
+			Expect(docs[1].Value()).To(BeNil())
+			Expect(docs[2].Value()).To(Equal("text\n"))
+		})
+
+		It("provides no document for a stream containing only comments", func() {
+			docs, err := ParseMulti("test", []byte("# comment\n\n...\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(docs).To(HaveLen(1))
+			Expect(docs[0].Value()).To(BeNil())
+		})
+
+		It("reports errors with the line in the stream", func() {
+			_, err := ParseMulti("test", []byte("foo: 1\n---\nbar: 2\n---\nfoo: [\n"))
+			Expect(err).To(HaveOccurred())
+			Expect(err.Error()).To(HavePrefix("document 3: "))
+			Expect(err.Error()).To(ContainSubstring("line 5"))
+		})
+
+		It("parses documents the position scan cannot separate", func() {
+			docs, err := ParseMulti("test", []byte("a: [ 5\", b ]\n---\nc: 1\n"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(docs).To(HaveLen(2))
+			Expect(docs[1].Value()).To(HaveKey("c"))
+		})
+
+		It("is rejected for a single document", func() {
+			_, err := Parse("test", source)
+			Expect(err).To(Equal(MultiDocumentError{3}))
+		})
+	})
+
 	Context("value type is unsupported (datetime)", func() {
 		It("fails", func() {
 			sourceName := "test"
@@ -69,5 +233,11 @@ var _ = Describe("YAML Parser", func() {
 func parsesAs(source string, expr interface{}) {
 	parsed, err := Parse("test", []byte(source))
 	Expect(err).NotTo(HaveOccurred())
-	Expect(parsed).To(Equal(node(expr)))
+	Expect(parsed).To(Equal(nodeAt(expr, 1, 1)))
+}
+
+func expectPosition(root Node, pos Position, path ...string) {
+	found, ok := Find(root, path...)
+	Expect(ok).To(BeTrue())
+	Expect(found.Position()).To(Equal(pos))
 }
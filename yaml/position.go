@@ -0,0 +1,419 @@
+package yaml
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type Position struct {
+	Line   int
+	Column int
+}
+
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	if !p.IsValid() {
+		return ""
+	}
+	return fmt.Sprintf("%d:%d", p.Line, p.Column)
+}
+
+func Location(node Node) string {
+	if node.Position().IsValid() {
+		return node.SourceName() + ":" + node.Position().String()
+	}
+	return node.SourceName()
+}
+
+/*
+ * The yaml parser does not provide source positions for parsed values.
+ * Therefore the block structure of the source is scanned separately to
+ * determine the line and column of every map and list entry.
+ * Values nested in flow style collections ({...} or [...]) or
+ * reached via aliases have no position. The scanned structure is
+ * checked against the parsed document, if they do not match no
+ * positions are provided for the document at all.
+ */
+
+type positions struct {
+	pos      Position
+	children map[string]*positions
+}
+
+func newPositions(pos Position) *positions {
+	return &positions{pos, map[string]*positions{}}
+}
+
+func (p *positions) position() Position {
+	if p == nil {
+		return Position{}
+	}
+	return p.pos
+}
+
+func (p *positions) sub(step string) *positions {
+	if p == nil {
+		return nil
+	}
+	return p.children[step]
+}
+
+// matches checks whether the scanned structure fits a parsed value.
+// Collections without scanned entries (flow style or aliases) match
+// any value.
+func (p *positions) matches(value interface{}) bool {
+	if p == nil || len(p.children) == 0 {
+		return true
+	}
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		if len(v) != len(p.children) {
+			return false
+		}
+		for key, val := range v {
+			str, ok := key.(string)
+			if !ok || !p.matchesEntry(str, val) {
+				return false
+			}
+		}
+	case map[string]interface{}:
+		if len(v) != len(p.children) {
+			return false
+		}
+		for key, val := range v {
+			if !p.matchesEntry(key, val) {
+				return false
+			}
+		}
+	case []interface{}:
+		if len(v) != len(p.children) {
+			return false
+		}
+		for i, val := range v {
+			if !p.matchesEntry(fmt.Sprintf("[%d]", i), val) {
+				return false
+			}
+		}
+	default:
+		return false
+	}
+	return true
+}
+
+func (p *positions) matchesEntry(step string, value interface{}) bool {
+	sub, ok := p.children[step]
+	return ok && sub.matches(value)
+}
+
+func (p *positions) add(path []string, pos Position) {
+	for _, step := range path {
+		sub := p.children[step]
+		if sub == nil {
+			sub = newPositions(pos)
+			p.children[step] = sub
+		}
+		p = sub
+	}
+	p.pos = pos
+}
+
+type positionFrame struct {
+	indent int
+	list   bool
+	path   []string
+	index  int
+}
+
+type pendingContainer struct {
+	indent int
+	path   []string
+}
+
+type positionScanner struct {
+	docs    []*positions
+	current *positions
+
+	started  bool
+	explicit bool
+	ended    bool
+
+	frames  []*positionFrame
+	pending *pendingContainer
+
+	// lines more indented are skipped (block scalars, continuation lines)
+	skipIndent int
+	// lines are skipped until the flow collection is closed
+	flowDepth int
+}
+
+// scanPositions determines the source positions for the
+// documents of a yaml stream.
+func scanPositions(source []byte) []*positions {
+	s := &positionScanner{}
+	s.newDocument()
+	for i, line := range strings.Split(string(source), "\n") {
+		s.scanLine(i+1, strings.TrimRight(line, "\r"))
+	}
+	return s.docs
+}
+
+func (s *positionScanner) newDocument() {
+	s.current = newPositions(Position{1, 1})
+	s.docs = append(s.docs, s.current)
+	s.started = false
+	s.explicit = false
+	s.ended = false
+	s.frames = nil
+	s.pending = nil
+	s.skipIndent = -1
+	s.flowDepth = 0
+}
+
+func (s *positionScanner) scanLine(line int, text string) {
+	if s.flowDepth > 0 {
+		s.flowDepth = flowBalance(text, s.flowDepth)
+		return
+	}
+
+	content := strings.TrimLeft(text, " ")
+	indent := len(text) - len(content)
+	if content == "" || content[0] == '#' {
+		return
+	}
+	if indent == 0 {
+		if content == "---" || strings.HasPrefix(content, "--- ") || strings.HasPrefix(content, "---\t") {
+			if s.started || s.explicit || s.ended {
+				s.newDocument()
+			}
+			s.explicit = true
+			s.skipIndent = -1
+			rest := strings.TrimLeft(content[3:], " \t")
+			if rest != "" && rest[0] != '#' {
+				s.scanNode(line, len(content)-len(rest), rest, false)
+			}
+			return
+		}
+		if content == "..." || strings.HasPrefix(content, "... ") {
+			s.ended = true
+			return
+		}
+		if content[0] == '%' {
+			return
+		}
+	}
+	if s.skipIndent >= 0 {
+		if indent > s.skipIndent {
+			return
+		}
+		s.skipIndent = -1
+	}
+	s.scanNode(line, indent, content, false)
+}
+
+func (s *positionScanner) scanNode(line int, col int, content string, compact bool) {
+	if s.ended {
+		s.newDocument()
+	}
+	if !s.started {
+		s.started = true
+		s.current.pos = Position{line, col + 1}
+	}
+
+	var key, rest string
+
+	dash := isDash(content)
+	if dash {
+		rest = strings.TrimLeft(content[1:], " \t")
+	} else {
+		var ok bool
+		key, rest, ok = splitKey(content)
+		if !ok {
+			if p := s.pending; p != nil && col > p.indent {
+				s.current.add(p.path, Position{line, col + 1})
+			}
+			s.pending = nil
+			s.skipValue(col, content)
+			return
+		}
+	}
+
+	frame := s.frame(col, dash)
+	var path []string
+	if dash {
+		path = addStep(frame.path, fmt.Sprintf("[%d]", frame.index))
+		frame.index++
+	} else {
+		path = addStep(frame.path, key)
+	}
+
+	value := stripProperties(rest)
+	vcol := col + len(content) - len(value)
+	if value == "" || value[0] == '#' {
+		s.current.add(path, Position{line, col + 1})
+		s.pending = &pendingContainer{col, path}
+		return
+	}
+	s.current.add(path, Position{line, vcol + 1})
+
+	if dash && !strings.HasPrefix(value, "((") {
+		if _, _, ok := splitKey(value); ok || isDash(value) {
+			// compact notation for nested collection
+			s.pending = &pendingContainer{col, path}
+			s.scanNode(line, vcol, value, true)
+			return
+		}
+	}
+	s.skipValue(col, value)
+}
+
+func (s *positionScanner) skipValue(indent int, value string) {
+	switch value[0] {
+	case '[', '{':
+		s.flowDepth = flowBalance(value, 0)
+		if s.flowDepth < 0 {
+			s.flowDepth = 0
+		}
+	}
+	s.skipIndent = indent
+}
+
+func (s *positionScanner) frame(col int, list bool) *positionFrame {
+	if p := s.pending; p != nil {
+		s.pending = nil
+		if col > p.indent || (list && col == p.indent) {
+			frame := &positionFrame{col, list, p.path, 0}
+			s.frames = append(s.frames, frame)
+			return frame
+		}
+	}
+
+	for len(s.frames) > 0 {
+		top := s.frames[len(s.frames)-1]
+		if top.indent < col || (top.indent == col && top.list == list) {
+			return top
+		}
+		s.frames = s.frames[:len(s.frames)-1]
+	}
+
+	frame := &positionFrame{col, list, nil, 0}
+	s.frames = append(s.frames, frame)
+	return frame
+}
+
+func isDash(content string) bool {
+	return content == "-" || strings.HasPrefix(content, "- ") || strings.HasPrefix(content, "-\t")
+}
+
+func splitKey(content string) (string, string, bool) {
+	if content == "" || strings.HasPrefix(content, "((") {
+		return "", "", false
+	}
+
+	switch content[0] {
+	case '"', '\'':
+		end := closingQuote(content)
+		if end < 0 {
+			return "", "", false
+		}
+		rest := strings.TrimLeft(content[end+1:], " \t")
+		if !strings.HasPrefix(rest, ":") || (len(rest) > 1 && rest[1] != ' ' && rest[1] != '\t') {
+			return "", "", false
+		}
+		key := content[1:end]
+		if content[0] == '"' {
+			if unquoted, err := strconv.Unquote(content[:end+1]); err == nil {
+				key = unquoted
+			}
+		} else {
+			key = strings.Replace(key, "''", "'", -1)
+		}
+		return key, strings.TrimLeft(rest[1:], " \t"), true
+
+	case '[', '{', '|', '>', '#', '&', '*', '!', '%', '@', '`', '?', ',', ']', '}':
+		return "", "", false
+	}
+
+	for i := 0; i < len(content); i++ {
+		switch content[i] {
+		case '#':
+			if i > 0 && (content[i-1] == ' ' || content[i-1] == '\t') {
+				return "", "", false
+			}
+		case ':':
+			if i+1 == len(content) || content[i+1] == ' ' || content[i+1] == '\t' {
+				return strings.TrimRight(content[:i], " \t"), strings.TrimLeft(content[i+1:], " \t"), true
+			}
+		}
+	}
+	return "", "", false
+}
+
+func closingQuote(content string) int {
+	quote := content[0]
+	for i := 1; i < len(content); i++ {
+		switch {
+		case quote == '"' && content[i] == '\\':
+			i++
+		case content[i] == quote:
+			if quote == '\'' && i+1 < len(content) && content[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i
+		}
+	}
+	return -1
+}
+
+func stripProperties(value string) string {
+	for value != "" && (value[0] == '&' || value[0] == '!') {
+		i := strings.IndexAny(value, " \t")
+		if i < 0 {
+			return ""
+		}
+		value = strings.TrimLeft(value[i:], " \t")
+	}
+	return value
+}
+
+func flowBalance(text string, depth int) int {
+	var quote byte
+
+	for i := 0; i < len(text); i++ {
+		c := text[i]
+		if quote != 0 {
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch c {
+		case '"', '\'':
+			// quotes only start a scalar, not inside plain ones
+			if i == 0 || strings.IndexByte(" \t[{,:", text[i-1]) >= 0 {
+				quote = c
+			}
+		case '[', '{':
+			depth++
+		case ']', '}':
+			depth--
+		case '#':
+			if i == 0 || text[i-1] == ' ' || text[i-1] == '\t' {
+				return depth
+			}
+		}
+	}
+	return depth
+}
+
+func addStep(path []string, step string) []string {
+	newPath := make([]string, len(path))
+	copy(newPath, path)
+	return append(newPath, step)
+}
@@ -0,0 +1,229 @@
+package yaml
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+// scannedPositions flattens the scanned positions of the documents
+// of a stream to a map of dot separated paths, prefixed by the
+// document index.
+func scannedPositions(source string) map[string]string {
+	result := map[string]string{}
+	var walk func(prefix string, p *positions)
+	walk = func(prefix string, p *positions) {
+		result[prefix] = p.pos.String()
+		for step, sub := range p.children {
+			walk(prefix+"."+step, sub)
+		}
+	}
+	for i, doc := range scanPositions([]byte(source)) {
+		walk(strconv.Itoa(i), doc)
+	}
+	return result
+}
+
+type scannerCase struct {
+	name      string
+	source    string
+	positions map[string]string
+}
+
+var scannerCases = []scannerCase{
+	{
+		"block scalars",
+		`text: |
+  key: value
+  - entry
+---
+keep: |+2
+    indented
+  - entry
+
+strip: >-
+  a: [ b
+list:
+- |
+  - x
+- >
+  y: z
+last: 1
+`,
+		map[string]string{
+			"0":          "1:1",
+			"0.text":     "1:7",
+			"1":          "5:1",
+			"1.keep":     "5:7",
+			"1.strip":    "9:8",
+			"1.list":     "11:1",
+			"1.list.[0]": "12:3",
+			"1.list.[1]": "14:3",
+			"1.last":     "16:7",
+		},
+	},
+	{
+		"compact nested lists",
+		`- - a
+  - b
+- - - c
+  - d
+- name: e
+  sub:
+  - - f
+    - name: g
+      value: h
+`,
+		map[string]string{
+			"0":                       "1:1",
+			"0.[0]":                   "1:3",
+			"0.[0].[0]":               "1:5",
+			"0.[0].[1]":               "2:5",
+			"0.[1]":                   "3:3",
+			"0.[1].[0]":               "3:5",
+			"0.[1].[0].[0]":           "3:7",
+			"0.[1].[1]":               "4:5",
+			"0.[2]":                   "5:3",
+			"0.[2].name":              "5:9",
+			"0.[2].sub":               "6:3",
+			"0.[2].sub.[0]":           "7:5",
+			"0.[2].sub.[0].[0]":       "7:7",
+			"0.[2].sub.[0].[1]":       "8:7",
+			"0.[2].sub.[0].[1].name":  "8:13",
+			"0.[2].sub.[0].[1].value": "9:14",
+		},
+	},
+	{
+		"quoted keys containing colons and hashes",
+		`"a: #b": 1
+'c # d:': 2 # comment
+"e\": f": 3
+'g'': h': 4
+"#i":
+  'j: k': 5
+plain#key: 6
+`,
+		map[string]string{
+			"0":           "1:1",
+			"0.a: #b":     "1:10",
+			"0.c # d:":    "2:11",
+			"0.e\": f":    "3:11",
+			"0.g': h":     "4:11",
+			"0.#i":        "5:1",
+			"0.#i.j: k":   "6:11",
+			"0.plain#key": "7:12",
+		},
+	},
+	{
+		"multi-line flow collections",
+		`a: [
+  1,
+  { b: "]" }, # ] in a comment
+]
+c: {
+  d: [ '}',
+       "[" ] }
+e:
+- { x: 1,
+    z: 2 }
+- z
+f: 3
+`,
+		map[string]string{
+			"0":       "1:1",
+			"0.a":     "1:4",
+			"0.c":     "5:4",
+			"0.e":     "8:1",
+			"0.e.[0]": "9:3",
+			"0.e.[1]": "11:3",
+			"0.f":     "12:4",
+		},
+	},
+	{
+		"document ends",
+		`a: 1
+...
+---
+b: 2
+... # comment
+--- # comment
+c: 3
+...
+# comment
+---
+- d
+...
+`,
+		map[string]string{
+			"0":     "1:1",
+			"0.a":   "1:4",
+			"1":     "4:1",
+			"1.b":   "4:4",
+			"2":     "7:1",
+			"2.c":   "7:4",
+			"3":     "11:1",
+			"3.[0]": "11:3",
+		},
+	},
+}
+
+var _ = Describe("Position scanner", func() {
+	for _, c := range scannerCases {
+		c := c
+		It("determines the positions for "+c.name, func() {
+			Expect(scannedPositions(c.source)).To(Equal(c.positions))
+		})
+
+		It("provides positions matching the parsed documents for "+c.name, func() {
+			docs, err := ParseMulti("test", []byte(c.source))
+			Expect(err).NotTo(HaveOccurred())
+
+			documents := map[string]bool{}
+			for path := range c.positions {
+				documents[strings.SplitN(path, ".", 2)[0]] = true
+			}
+			Expect(docs).To(HaveLen(len(documents)))
+
+			paths := []string{}
+			for path := range c.positions {
+				paths = append(paths, path)
+			}
+			sort.Strings(paths)
+			for _, path := range paths {
+				steps := strings.Split(path, ".")
+				index, err := strconv.Atoi(steps[0])
+				Expect(err).NotTo(HaveOccurred())
+				node := docs[index]
+				Expect(node).NotTo(BeNil())
+				found, ok := scannerFind(node, steps[1:])
+				Expect(ok).To(BeTrue(), path)
+				Expect(found.Position().String()).To(Equal(c.positions[path]), path)
+			}
+		})
+	}
+})
+
+func scannerFind(node Node, steps []string) (Node, bool) {
+	for _, step := range steps {
+		switch v := node.Value().(type) {
+		case map[string]Node:
+			sub, ok := v[step]
+			if !ok {
+				return nil, false
+			}
+			node = sub
+		case []Node:
+			index, err := strconv.Atoi(strings.Trim(step, "[]"))
+			if err != nil || index >= len(v) {
+				return nil, false
+			}
+			node = v[index]
+		default:
+			return nil, false
+		}
+	}
+	return node, true
+}
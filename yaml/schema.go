@@ -0,0 +1,362 @@
+package yaml
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// SchemaViolation describes a node not matching its schema.
+// Node is nil for missing required fields.
+type SchemaViolation struct {
+	Path  []string
+	Node  Node
+	Issue Issue
+}
+
+func (v SchemaViolation) String() string {
+	path := schemaPath(v.Path)
+	if v.Node != nil && Location(v.Node) != "" {
+		path += " (" + Location(v.Node) + ")"
+	}
+	return path + ": " + v.Issue.Issue
+}
+
+// SchemaViolations is the error reported for a document not
+// matching a schema.
+type SchemaViolations []SchemaViolation
+
+// Issue provides an issue with the given message and a nested
+// issue for every violation.
+func (v SchemaViolations) Issue(msgfmt string, args ...interface{}) Issue {
+	issue := NewIssue(msgfmt, args...)
+	for _, violation := range v {
+		issue.Nested = append(issue.Nested, NewIssue("%s", violation))
+	}
+	return issue
+}
+
+func (v SchemaViolations) Error() string {
+	message := "schema violations:"
+	for _, violation := range v {
+		message += "\n\t" + violation.String()
+	}
+	return message
+}
+
+/*
+ * A schema is a (yaml) document describing the acceptable values
+ * with a subset of the JSON Schema keywords:
+ *
+ *   type:                 string, integer, number, boolean, object, array
+ *                         or null (or a list of those)
+ *   enum:                 list of acceptable values
+ *   minimum, maximum:     bounds for numbers
+ *   minLength, maxLength: bounds for the length of strings
+ *   pattern:              regular expression for strings
+ *   items:                schema for all entries of a list
+ *   minItems, maxItems:   bounds for the length of lists
+ *   properties:           schemas for the fields of a map
+ *   required:             list of mandatory fields of a map
+ *   additionalProperties: false or schema for fields not described
+ *                         by properties
+ *
+ * Other keywords (like description) are ignored.
+ */
+
+// ValidateSchema checks a document against a schema. An error is
+// returned for invalid schemas.
+func ValidateSchema(node Node, schema Node) (SchemaViolations, error) {
+	v := &validator{}
+	if err := v.validate(node, schema, []string{}); err != nil {
+		return nil, err
+	}
+	return v.violations, nil
+}
+
+type validator struct {
+	violations SchemaViolations
+}
+
+func (v *validator) violation(path []string, node Node, msgfmt string, args ...interface{}) {
+	v.violations = append(v.violations, SchemaViolation{path, node, NewIssue(msgfmt, args...)})
+}
+
+func (v *validator) validate(node Node, schema Node, path []string) error {
+	s, ok := schema.Value().(map[string]Node)
+	if !ok {
+		return schemaError(schema, "schema for %s must be a map", schemaPath(path))
+	}
+
+	if t, ok := s["type"]; ok {
+		types, err := schemaTypes(t)
+		if err != nil {
+			return err
+		}
+		found := schemaType(node.Value())
+		matched := false
+		for _, t := range types {
+			if t == found || (t == "number" && found == "integer") {
+				matched = true
+			}
+		}
+		if !matched {
+			v.violation(path, node, "%s expected, but found %s", strings.Join(types, " or "), found)
+			return nil
+		}
+	}
+
+	if e, ok := s["enum"]; ok {
+		values, ok := e.Value().([]Node)
+		if !ok {
+			return schemaError(e, "enum for %s must be a list", schemaPath(path))
+		}
+		matched := false
+		for _, value := range values {
+			if schemaEqual(node.Value(), value.Value()) {
+				matched = true
+			}
+		}
+		if !matched {
+			v.violation(path, node, "value not in enum")
+		}
+	}
+
+	switch value := node.Value().(type) {
+	case int64, float64:
+		n, _ := schemaNumber(value)
+		if err := v.bound(s, "minimum", path, node, func(b float64) bool { return n >= b }, "value %v less than minimum %v", value); err != nil {
+			return err
+		}
+		if err := v.bound(s, "maximum", path, node, func(b float64) bool { return n <= b }, "value %v greater than maximum %v", value); err != nil {
+			return err
+		}
+
+	case string:
+		l := float64(len(value))
+		if err := v.bound(s, "minLength", path, node, func(b float64) bool { return l >= b }, "length %d less than %v", len(value)); err != nil {
+			return err
+		}
+		if err := v.bound(s, "maxLength", path, node, func(b float64) bool { return l <= b }, "length %d greater than %v", len(value)); err != nil {
+			return err
+		}
+		if p, ok := s["pattern"]; ok {
+			pattern, ok := p.Value().(string)
+			if !ok {
+				return schemaError(p, "pattern for %s must be a string", schemaPath(path))
+			}
+			re, err := regexp.Compile(pattern)
+			if err != nil {
+				return schemaError(p, "invalid pattern for %s: %s", schemaPath(path), err)
+			}
+			if !re.MatchString(value) {
+				v.violation(path, node, "value does not match pattern %q", pattern)
+			}
+		}
+
+	case []Node:
+		l := float64(len(value))
+		if err := v.bound(s, "minItems", path, node, func(b float64) bool { return l >= b }, "%d entries, but at least %v required", len(value)); err != nil {
+			return err
+		}
+		if err := v.bound(s, "maxItems", path, node, func(b float64) bool { return l <= b }, "%d entries, but at most %v allowed", len(value)); err != nil {
+			return err
+		}
+		if items, ok := s["items"]; ok {
+			for i, entry := range value {
+				if err := v.validate(entry, items, schemaStep(path, fmt.Sprintf("[%d]", i))); err != nil {
+					return err
+				}
+			}
+		}
+
+	case map[string]Node:
+		return v.validateMap(value, s, path)
+	}
+	return nil
+}
+
+func (v *validator) validateMap(value map[string]Node, s map[string]Node, path []string) error {
+	if r, ok := s["required"]; ok {
+		required, ok := r.Value().([]Node)
+		if !ok {
+			return schemaError(r, "required for %s must be a list", schemaPath(path))
+		}
+		for _, field := range required {
+			name, ok := field.Value().(string)
+			if !ok {
+				return schemaError(field, "required fields for %s must be strings", schemaPath(path))
+			}
+			if _, ok := value[name]; !ok {
+				v.violation(schemaStep(path, name), nil, "required field missing")
+			}
+		}
+	}
+
+	properties := map[string]Node{}
+	if p, ok := s["properties"]; ok {
+		properties, ok = p.Value().(map[string]Node)
+		if !ok {
+			return schemaError(p, "properties for %s must be a map", schemaPath(path))
+		}
+	}
+	additional := s["additionalProperties"]
+
+	keys := []string{}
+	for key := range value {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		field := schemaStep(path, key)
+		if schema, ok := properties[key]; ok {
+			if err := v.validate(value[key], schema, field); err != nil {
+				return err
+			}
+			continue
+		}
+		if additional == nil {
+			continue
+		}
+		if allowed, ok := additional.Value().(bool); ok {
+			if !allowed {
+				v.violation(field, value[key], "field not allowed")
+			}
+			continue
+		}
+		if err := v.validate(value[key], additional, field); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// bound checks a numeric bound keyword of a schema. The message
+// gets the bound as additional last argument.
+func (v *validator) bound(s map[string]Node, keyword string, path []string, node Node, check func(float64) bool, msgfmt string, args ...interface{}) error {
+	b, ok := s[keyword]
+	if !ok {
+		return nil
+	}
+	n, ok := schemaNumber(b.Value())
+	if !ok {
+		return schemaError(b, "%s for %s must be a number", keyword, schemaPath(path))
+	}
+	if !check(n) {
+		v.violation(path, node, msgfmt, append(args, b.Value())...)
+	}
+	return nil
+}
+
+var schemaTypeNames = map[string]bool{
+	"string": true, "integer": true, "number": true, "boolean": true,
+	"object": true, "array": true, "null": true,
+}
+
+func schemaTypes(node Node) ([]string, error) {
+	names := []Node{node}
+	if list, ok := node.Value().([]Node); ok {
+		names = list
+	}
+	types := []string{}
+	for _, n := range names {
+		name, ok := n.Value().(string)
+		if !ok || !schemaTypeNames[name] {
+			return nil, schemaError(n, "invalid schema type %v", n.Value())
+		}
+		types = append(types, name)
+	}
+	return types, nil
+}
+
+// schemaType provides the schema type name of a node value.
+func schemaType(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "string"
+	case int64, int:
+		return "integer"
+	case float64:
+		return "number"
+	case bool:
+		return "boolean"
+	case map[string]Node:
+		return "object"
+	case []Node:
+		return "array"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+func schemaNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
+
+// schemaEqual compares node values, integers and floats are
+// compared by their numeric value.
+func schemaEqual(a, b interface{}) bool {
+	if na, ok := schemaNumber(a); ok {
+		nb, ok := schemaNumber(b)
+		return ok && na == nb
+	}
+	switch va := a.(type) {
+	case map[string]Node:
+		vb, ok := b.(map[string]Node)
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for k, n := range va {
+			m, ok := vb[k]
+			if !ok || !schemaEqual(n.Value(), m.Value()) {
+				return false
+			}
+		}
+		return true
+	case []Node:
+		vb, ok := b.([]Node)
+		if !ok || len(va) != len(vb) {
+			return false
+		}
+		for i := range va {
+			if !schemaEqual(va[i].Value(), vb[i].Value()) {
+				return false
+			}
+		}
+		return true
+	case string, bool, nil:
+		return a == b
+	}
+	return false
+}
+
+func schemaError(node Node, msgfmt string, args ...interface{}) error {
+	msg := fmt.Sprintf(msgfmt, args...)
+	if Location(node) != "" {
+		msg += " (" + Location(node) + ")"
+	}
+	return fmt.Errorf("invalid schema: %s", msg)
+}
+
+func schemaPath(path []string) string {
+	if len(path) == 0 {
+		return "<root>"
+	}
+	return strings.Join(path, ".")
+}
+
+func schemaStep(path []string, step string) []string {
+	dup := make([]string, len(path), len(path)+1)
+	copy(dup, path)
+	return append(dup, step)
+}
@@ -0,0 +1,111 @@
+package yaml
+
+import (
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("schemas", func() {
+	schema := parseYAML(`
+---
+type: object
+required: [ name, jobs ]
+additionalProperties: false
+properties:
+  name:
+    type: string
+    pattern: "^[a-z-]+$"
+    maxLength: 10
+  jobs:
+    type: array
+    minItems: 1
+    items:
+      type: object
+      required: [ name ]
+      properties:
+        instances:
+          type: integer
+          minimum: 0
+        size:
+          enum: [ small, large ]
+        ratio:
+          type: [ number, "null" ]
+          maximum: 1
+`)
+
+	validate := func(source string) []string {
+		violations, err := ValidateSchema(parseYAML(source), schema)
+		Expect(err).NotTo(HaveOccurred())
+		result := []string{}
+		for _, v := range violations {
+			result = append(result, v.String())
+		}
+		return result
+	}
+
+	It("accepts matching documents", func() {
+		Expect(validate(`
+---
+name: cf
+jobs:
+- name: web
+  instances: 2
+  size: small
+  ratio: 0.5
+- name: db
+  ratio: ~
+`)).To(BeEmpty())
+	})
+
+	It("reports all violations with their paths", func() {
+		Expect(validate(`
+---
+name: Cloud-Foundry
+jobs:
+- name: web
+  instances: two
+  size: medium
+- instances: -1
+  ratio: 2
+other: 1
+`)).To(Equal([]string{
+			"jobs.[0].instances (test:6:14): integer expected, but found string",
+			"jobs.[0].size (test:7:9): value not in enum",
+			"jobs.[1].name: required field missing",
+			"jobs.[1].instances (test:8:14): value -1 less than minimum 0",
+			"jobs.[1].ratio (test:9:10): value 2 greater than maximum 1",
+			"name (test:3:7): length 13 greater than 10",
+			"name (test:3:7): value does not match pattern \"^[a-z-]+$\"",
+			"other (test:10:8): field not allowed",
+		}))
+	})
+
+	It("reports missing fields and wrong types", func() {
+		Expect(validate(`
+---
+jobs: []
+`)).To(Equal([]string{
+			"name: required field missing",
+			"jobs (test:3:7): 0 entries, but at least 1 required",
+		}))
+		Expect(validate(`
+---
+- name: cf
+`)).To(Equal([]string{
+			"<root> (test:3:1): object expected, but found array",
+		}))
+	})
+
+	It("rejects invalid schemas", func() {
+		_, err := ValidateSchema(parseYAML("foo: bar"), parseYAML("type: text"))
+		Expect(err).To(MatchError("invalid schema: invalid schema type text (test:1:7)"))
+	})
+
+	It("provides the violations as nested issues", func() {
+		violations, _ := ValidateSchema(parseYAML("name: Foo"), schema)
+		issue := violations.Issue("schema validation failed")
+		Expect(issue.Issue).To(Equal("schema validation failed"))
+		Expect(issue.Nested).To(HaveLen(2))
+		Expect(issue.Nested[0].Issue).To(Equal("jobs: required field missing"))
+	})
+})